@@ -0,0 +1,238 @@
+package cypressfakedb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"time"
+)
+
+const DRIVER_NAME = "cypressfakedb"
+
+func init() {
+	sql.Register(DRIVER_NAME, &fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver *fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDB, exists := lookupFakeDB(name)
+	if !exists {
+		return nil, errors.New("cypressfakedb: no fake database named '" + name + "', create it with NewFakeDB")
+	}
+	return &fakeConn{fakeDB: fakeDB}, nil
+}
+
+/**************** CONNECTION ****************/
+
+type fakeConn struct {
+	fakeDB *FakeDB
+	inTx   bool
+	closed bool
+}
+
+func (conn *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: conn, query: query}, nil
+}
+
+func (conn *fakeConn) Close() error {
+	conn.closed = true
+	return nil
+}
+
+func (conn *fakeConn) Begin() (driver.Tx, error) {
+	return conn.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (conn *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	conn.fakeDB.mutex.Lock()
+	defer conn.fakeDB.mutex.Unlock()
+
+	if conn.fakeDB.beginErr != nil {
+		return nil, conn.fakeDB.beginErr
+	}
+
+	conn.fakeDB.begins++
+	conn.inTx = true
+	return &fakeTx{conn: conn}, nil
+}
+
+func (conn *fakeConn) Ping(ctx context.Context) error {
+	conn.fakeDB.mutex.Lock()
+	defer conn.fakeDB.mutex.Unlock()
+	return conn.fakeDB.pingErr
+}
+
+func (conn *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	script := conn.fakeDB.record(query, namedValuesToValues(args), conn.inTx)
+	if err := waitFor(ctx, script.delay); err != nil {
+		return nil, err
+	}
+	if script.err != nil {
+		return nil, script.err
+	}
+	return &fakeRows{script: script}, nil
+}
+
+func (conn *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	script := conn.fakeDB.record(query, namedValuesToValues(args), conn.inTx)
+	if err := waitFor(ctx, script.delay); err != nil {
+		return nil, err
+	}
+	if script.err != nil {
+		return nil, script.err
+	}
+	return &fakeResult{rowsAffected: script.rowsAffected, lastInsertId: script.lastInsertId}, nil
+}
+
+// CheckNamedValue keeps whatever the repository binds, the fake database never interprets arguments
+func (conn *fakeConn) CheckNamedValue(namedValue *driver.NamedValue) error {
+	converted, err := driver.DefaultParameterConverter.ConvertValue(namedValue.Value)
+	if err == nil {
+		namedValue.Value = converted
+	}
+	return nil
+}
+
+/**************** STATEMENT ****************/
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (stmt *fakeStmt) Close() error {
+	return nil
+}
+
+func (stmt *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (stmt *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return stmt.conn.ExecContext(context.Background(), stmt.query, valuesToNamedValues(args))
+}
+
+func (stmt *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return stmt.conn.QueryContext(context.Background(), stmt.query, valuesToNamedValues(args))
+}
+
+/**************** TRANSACTION ****************/
+
+type fakeTx struct {
+	conn *fakeConn
+}
+
+func (tx *fakeTx) Commit() error {
+	fakeDB := tx.conn.fakeDB
+	fakeDB.mutex.Lock()
+	defer fakeDB.mutex.Unlock()
+
+	tx.conn.inTx = false
+	if fakeDB.commitErr != nil {
+		return fakeDB.commitErr
+	}
+	fakeDB.commits++
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	fakeDB := tx.conn.fakeDB
+	fakeDB.mutex.Lock()
+	defer fakeDB.mutex.Unlock()
+
+	tx.conn.inTx = false
+	if fakeDB.rollbackErr != nil {
+		return fakeDB.rollbackErr
+	}
+	fakeDB.rollbacks++
+	return nil
+}
+
+/**************** RESULTS ****************/
+
+type fakeResult struct {
+	rowsAffected, lastInsertId int64
+}
+
+func (result *fakeResult) LastInsertId() (int64, error) {
+	return result.lastInsertId, nil
+}
+
+func (result *fakeResult) RowsAffected() (int64, error) {
+	return result.rowsAffected, nil
+}
+
+type fakeRows struct {
+	script *ScriptedResult
+	index  int
+}
+
+func (rows *fakeRows) Columns() []string {
+	return rows.script.columns
+}
+
+func (rows *fakeRows) Close() error {
+	return nil
+}
+
+func (rows *fakeRows) Next(dest []driver.Value) error {
+	if rows.index >= len(rows.script.rows) {
+		return io.EOF
+	}
+
+	copy(dest, rows.script.rows[rows.index])
+	rows.index++
+	return nil
+}
+
+func (rows *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < len(rows.script.columnTypes) {
+		return rows.script.columnTypes[index]
+	}
+	return "VARCHAR"
+}
+
+/**************** UTILITY FUNCTIONS ****************/
+
+func waitFor(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func namedValuesToValues(namedValues []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(namedValues))
+	for index, namedValue := range namedValues {
+		values[index] = namedValue.Value
+	}
+	return values
+}
+
+func valuesToNamedValues(values []driver.Value) []driver.NamedValue {
+	namedValues := make([]driver.NamedValue, len(values))
+	for index, value := range values {
+		namedValues[index] = driver.NamedValue{Ordinal: index + 1, Value: value}
+	}
+	return namedValues
+}
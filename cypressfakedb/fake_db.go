@@ -0,0 +1,299 @@
+package cypressfakedb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordedStatement is a statement the repository layer sent to the fake database
+type RecordedStatement struct {
+	Query string
+	Args  []driver.Value
+	InTx  bool
+}
+
+// ScriptedResult is what the fake database answers when a statement matches it
+type ScriptedResult struct {
+	fakeDB       *FakeDB
+	matcher      func(query string) bool
+	description  string
+	columns      []string
+	columnTypes  []string
+	rows         [][]driver.Value
+	rowsAffected int64
+	lastInsertId int64
+	err          error
+	delay        time.Duration
+	times        int
+	used         int
+}
+
+type FakeDB struct {
+	mutex       sync.Mutex
+	name        string
+	statements  []*RecordedStatement
+	scripts     []*ScriptedResult
+	begins      int
+	commits     int
+	rollbacks   int
+	beginErr    error
+	commitErr   error
+	rollbackErr error
+	pingErr     error
+}
+
+var (
+	registryMutex sync.Mutex
+	fakeDBs       = make(map[string]*FakeDB)
+	fakeDBCounter = 0
+)
+
+// NewFakeDB registers a new fake database. Its name is the data source name to hand to sql.Open(DRIVER_NAME, ...)
+func NewFakeDB(name ...string) *FakeDB {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	fakeDBCounter++
+	dbName := fmt.Sprintf("fakedb_%d", fakeDBCounter)
+	if name != nil && name[0] != "" {
+		dbName = name[0]
+	}
+
+	fakeDB := &FakeDB{name: dbName}
+	fakeDBs[dbName] = fakeDB
+	return fakeDB
+}
+
+func lookupFakeDB(name string) (*FakeDB, bool) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	fakeDB, exists := fakeDBs[name]
+	return fakeDB, exists
+}
+
+func (fakeDB *FakeDB) GetName() string {
+	return fakeDB.name
+}
+
+/**************** SCRIPTING ****************/
+
+// Expect answers the next statement containing queryFragment (case insensitive, whitespace collapsed)
+func (fakeDB *FakeDB) Expect(queryFragment string) *ScriptedResult {
+	fragment := normalizeQuery(queryFragment)
+	return fakeDB.addScript(&ScriptedResult{
+		description: queryFragment,
+		matcher: func(query string) bool {
+			return strings.Contains(normalizeQuery(query), fragment)
+		},
+	})
+}
+
+func (fakeDB *FakeDB) ExpectRegexp(pattern string) *ScriptedResult {
+	regexMatcher := regexp.MustCompile(pattern)
+	return fakeDB.addScript(&ScriptedResult{
+		description: pattern,
+		matcher:     regexMatcher.MatchString,
+	})
+}
+
+// ExpectAny answers the next statement whatever it is
+func (fakeDB *FakeDB) ExpectAny() *ScriptedResult {
+	return fakeDB.addScript(&ScriptedResult{
+		description: "*",
+		matcher: func(query string) bool {
+			return true
+		},
+	})
+}
+
+func (fakeDB *FakeDB) addScript(script *ScriptedResult) *ScriptedResult {
+	script.fakeDB = fakeDB
+	script.times = 1
+
+	fakeDB.mutex.Lock()
+	fakeDB.scripts = append(fakeDB.scripts, script)
+	fakeDB.mutex.Unlock()
+	return script
+}
+
+func (script *ScriptedResult) WithColumns(columns ...string) *ScriptedResult {
+	script.columns = columns
+	return script
+}
+
+// WithColumnTypes sets the DatabaseTypeName reported per column, e.g. INT4, FLOAT8, BOOL, VARCHAR
+func (script *ScriptedResult) WithColumnTypes(columnTypes ...string) *ScriptedResult {
+	script.columnTypes = columnTypes
+	return script
+}
+
+func (script *ScriptedResult) AddRow(values ...interface{}) *ScriptedResult {
+	row := make([]driver.Value, len(values))
+	for index, value := range values {
+		converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if err != nil {
+			panic(fmt.Sprintf("cypressfakedb: row value %v: %v", value, err))
+		}
+		row[index] = converted
+	}
+	script.rows = append(script.rows, row)
+	return script
+}
+
+func (script *ScriptedResult) WithRowsAffected(rowsAffected int64) *ScriptedResult {
+	script.rowsAffected = rowsAffected
+	return script
+}
+
+func (script *ScriptedResult) WithLastInsertId(lastInsertId int64) *ScriptedResult {
+	script.lastInsertId = lastInsertId
+	return script
+}
+
+func (script *ScriptedResult) WithError(err error) *ScriptedResult {
+	script.err = err
+	return script
+}
+
+// WithDelay holds the statement back, or until its context is done, to exercise query timeouts
+func (script *ScriptedResult) WithDelay(delay time.Duration) *ScriptedResult {
+	script.delay = delay
+	return script
+}
+
+// Times lets the script answer more than one statement. Zero or less answers every statement it matches
+func (script *ScriptedResult) Times(times int) *ScriptedResult {
+	script.times = times
+	return script
+}
+
+// WasUsed tells whether the script answered a statement, the driver counts the uses under the lock of the database
+func (script *ScriptedResult) WasUsed() bool {
+	script.fakeDB.mutex.Lock()
+	defer script.fakeDB.mutex.Unlock()
+	return script.used > 0
+}
+
+func (fakeDB *FakeDB) FailPing(err error) *FakeDB {
+	fakeDB.mutex.Lock()
+	fakeDB.pingErr = err
+	fakeDB.mutex.Unlock()
+	return fakeDB
+}
+
+func (fakeDB *FakeDB) FailBegin(err error) *FakeDB {
+	fakeDB.mutex.Lock()
+	fakeDB.beginErr = err
+	fakeDB.mutex.Unlock()
+	return fakeDB
+}
+
+func (fakeDB *FakeDB) FailCommit(err error) *FakeDB {
+	fakeDB.mutex.Lock()
+	fakeDB.commitErr = err
+	fakeDB.mutex.Unlock()
+	return fakeDB
+}
+
+func (fakeDB *FakeDB) FailRollback(err error) *FakeDB {
+	fakeDB.mutex.Lock()
+	fakeDB.rollbackErr = err
+	fakeDB.mutex.Unlock()
+	return fakeDB
+}
+
+// Reset forgets the recorded statements, the scripts and the transaction counters
+func (fakeDB *FakeDB) Reset() {
+	fakeDB.mutex.Lock()
+	defer fakeDB.mutex.Unlock()
+
+	fakeDB.statements = nil
+	fakeDB.scripts = nil
+	fakeDB.begins, fakeDB.commits, fakeDB.rollbacks = 0, 0, 0
+	fakeDB.beginErr, fakeDB.commitErr, fakeDB.rollbackErr, fakeDB.pingErr = nil, nil, nil, nil
+}
+
+/**************** INSPECTION ****************/
+
+func (fakeDB *FakeDB) GetStatements() []*RecordedStatement {
+	fakeDB.mutex.Lock()
+	defer fakeDB.mutex.Unlock()
+
+	statements := make([]*RecordedStatement, len(fakeDB.statements))
+	copy(statements, fakeDB.statements)
+	return statements
+}
+
+func (fakeDB *FakeDB) GetLastStatement() *RecordedStatement {
+	fakeDB.mutex.Lock()
+	defer fakeDB.mutex.Unlock()
+
+	if len(fakeDB.statements) == 0 {
+		return nil
+	}
+	return fakeDB.statements[len(fakeDB.statements)-1]
+}
+
+// GetUnusedScripts lists what was expected but never asked for
+func (fakeDB *FakeDB) GetUnusedScripts() []string {
+	fakeDB.mutex.Lock()
+	defer fakeDB.mutex.Unlock()
+
+	var unused []string
+	for _, script := range fakeDB.scripts {
+		if script.used == 0 {
+			unused = append(unused, script.description)
+		}
+	}
+	return unused
+}
+
+func (fakeDB *FakeDB) GetBegins() int {
+	fakeDB.mutex.Lock()
+	defer fakeDB.mutex.Unlock()
+	return fakeDB.begins
+}
+
+func (fakeDB *FakeDB) GetCommits() int {
+	fakeDB.mutex.Lock()
+	defer fakeDB.mutex.Unlock()
+	return fakeDB.commits
+}
+
+func (fakeDB *FakeDB) GetRollbacks() int {
+	fakeDB.mutex.Lock()
+	defer fakeDB.mutex.Unlock()
+	return fakeDB.rollbacks
+}
+
+/**************** UTILITY FUNCTIONS ****************/
+
+func (fakeDB *FakeDB) record(query string, args []driver.Value, inTx bool) *ScriptedResult {
+	fakeDB.mutex.Lock()
+	defer fakeDB.mutex.Unlock()
+
+	fakeDB.statements = append(fakeDB.statements, &RecordedStatement{Query: query, Args: args, InTx: inTx})
+
+	for _, script := range fakeDB.scripts {
+		if script.times > 0 && script.used >= script.times {
+			continue
+		}
+
+		if script.matcher(query) {
+			script.used++
+			return script
+		}
+	}
+
+	//UNSCRIPTED STATEMENTS SUCCEED WITHOUT ROWS
+	return &ScriptedResult{}
+}
+
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
@@ -0,0 +1,130 @@
+package cypressfakedb_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codecypress/go-ancillary-utils/cypressfakedb"
+)
+
+func openFakeDB(t *testing.T) (*sql.DB, *cypressfakedb.FakeDB) {
+	t.Helper()
+
+	fakeDB := cypressfakedb.NewFakeDB()
+	db, err := sql.Open(cypressfakedb.DRIVER_NAME, fakeDB.GetName())
+	if err != nil {
+		t.Fatalf("opening %s: %v", fakeDB.GetName(), err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db, fakeDB
+}
+
+func TestExpectMatchesIgnoringCaseAndWhitespace(t *testing.T) {
+	db, fakeDB := openFakeDB(t)
+	fakeDB.Expect("from   PEOPLE where").WithColumns("id", "name").AddRow(7, "Jane")
+
+	var id int64
+	var name string
+	if err := db.QueryRow("SELECT id, name\n\tFROM people WHERE id = $1", 7).Scan(&id, &name); err != nil {
+		t.Fatalf("scripted query: %v", err)
+	}
+	if id != 7 || name != "Jane" {
+		t.Errorf("row = %d, %s, want 7, Jane", id, name)
+	}
+
+	statement := fakeDB.GetLastStatement()
+	if len(statement.Args) != 1 || statement.Args[0] != int64(7) {
+		t.Errorf("args = %v", statement.Args)
+	}
+}
+
+func TestScriptsAnswerAsManyTimesAsExpected(t *testing.T) {
+	db, fakeDB := openFakeDB(t)
+	fakeDB.Expect("UPDATE").WithRowsAffected(3).Times(2)
+
+	for attempt, want := range []int64{3, 3, 0} {
+		result, err := db.Exec("UPDATE people SET name = $1", "Jane")
+		if err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected != want {
+			t.Errorf("attempt %d affected %d row(s), want %d", attempt, rowsAffected, want)
+		}
+	}
+}
+
+func TestScriptedErrorsAndUnusedScripts(t *testing.T) {
+	db, fakeDB := openFakeDB(t)
+	fakeDB.Expect("INSERT INTO").WithError(errors.New("duplicate key value"))
+	fakeDB.ExpectRegexp("^DELETE")
+
+	if _, err := db.Exec("INSERT INTO people (name) VALUES ($1)", "Jane"); err == nil || err.Error() != "duplicate key value" {
+		t.Errorf("err = %v, want the scripted error", err)
+	}
+	if unused := fakeDB.GetUnusedScripts(); len(unused) != 1 || unused[0] != "^DELETE" {
+		t.Errorf("unused scripts = %v", unused)
+	}
+}
+
+func TestTransactionsAreCounted(t *testing.T) {
+	db, fakeDB := openFakeDB(t)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if _, err = tx.Exec("DELETE FROM people"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	if !fakeDB.GetLastStatement().InTx {
+		t.Error("statement not recorded as part of the transaction")
+	}
+	if fakeDB.GetBegins() != 1 || fakeDB.GetRollbacks() != 1 || fakeDB.GetCommits() != 0 {
+		t.Errorf("begins = %d, rollbacks = %d, commits = %d, want 1, 1 and 0", fakeDB.GetBegins(), fakeDB.GetRollbacks(), fakeDB.GetCommits())
+	}
+
+	fakeDB.FailBegin(errors.New("too many connections"))
+	if _, err = db.Begin(); err == nil {
+		t.Error("Begin succeeded after FailBegin")
+	}
+}
+
+func TestDelayedScriptsHonourTheContext(t *testing.T) {
+	db, fakeDB := openFakeDB(t)
+	fakeDB.Expect("SELECT").WithColumns("id").AddRow(1).WithDelay(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := db.QueryContext(ctx, "SELECT id FROM people")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the deadline", err)
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Errorf("query took %v, the delay ignored the context", elapsed)
+	}
+}
+
+func TestResetForgetsStatementsAndScripts(t *testing.T) {
+	db, fakeDB := openFakeDB(t)
+	fakeDB.Expect("SELECT")
+
+	if _, err := db.Exec("SELECT 1"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	fakeDB.Reset()
+
+	if len(fakeDB.GetStatements()) != 0 || len(fakeDB.GetUnusedScripts()) != 0 || fakeDB.GetLastStatement() != nil {
+		t.Errorf("statements = %v, unused scripts = %v after Reset", fakeDB.GetStatements(), fakeDB.GetUnusedScripts())
+	}
+}
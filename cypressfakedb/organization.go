@@ -0,0 +1,101 @@
+package cypressfakedb
+
+import (
+	"database/sql"
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+// RegisterOrganization opens a pool on a new fake database and hands it to the connection manager under
+// organizationId, so the repository functions run against it. The dialect defaults to PostgreSQL
+func RegisterOrganization(organizationId string, databaseServer ...cypressutils.DbTypes) (*FakeDB, error) {
+	server := cypressutils.PostgreSQL
+	if databaseServer != nil && databaseServer[0] != "" {
+		server = databaseServer[0]
+	}
+
+	fakeDB := NewFakeDB()
+
+	dbPool, err := sql.Open(DRIVER_NAME, fakeDB.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	conDSN := &cypressutils.DBConDSN{
+		OrganizationId: organizationId,
+		DatabaseServer: server,
+		DatabaseName:   fakeDB.GetName(),
+		DatabaseHost:   DRIVER_NAME,
+	}
+
+	err = cypressutils.SetupDSNWithPool(organizationId, conDSN, dbPool)
+	if err != nil {
+		dbPool.Close()
+		return nil, err
+	}
+	return fakeDB, nil
+}
+
+// RegisterOrganizationWithReplicas is RegisterOrganization with replicaCount read replicas, each on a fake database
+// of its own. Replicas are registered first so that the connection manager does not try to open them
+func RegisterOrganizationWithReplicas(organizationId string, replicaCount int, databaseServer ...cypressutils.DbTypes) (*FakeDB, []*FakeDB, error) {
+	server := cypressutils.PostgreSQL
+	if databaseServer != nil && databaseServer[0] != "" {
+		server = databaseServer[0]
+	}
+
+	replicaDBs := make([]*FakeDB, 0, replicaCount)
+	replicas := make([]*cypressutils.DBConDSN, 0, replicaCount)
+
+	for index := 0; index < replicaCount; index++ {
+		replicaDB := NewFakeDB()
+
+		dbPool, err := sql.Open(DRIVER_NAME, replicaDB.GetName())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		replicaOrganizationId := cypressutils.ReplicaOrganizationId(organizationId, index)
+		err = cypressutils.SetupDSNWithPool(replicaOrganizationId, &cypressutils.DBConDSN{
+			OrganizationId: replicaOrganizationId,
+			DatabaseServer: server,
+			DatabaseName:   replicaDB.GetName(),
+			DatabaseHost:   DRIVER_NAME,
+		}, dbPool)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		replicaDBs = append(replicaDBs, replicaDB)
+		replicas = append(replicas, &cypressutils.DBConDSN{DatabaseName: replicaDB.GetName()})
+	}
+
+	fakeDB := NewFakeDB()
+
+	dbPool, err := sql.Open(DRIVER_NAME, fakeDB.GetName())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conDSN := &cypressutils.DBConDSN{
+		OrganizationId: organizationId,
+		DatabaseServer: server,
+		DatabaseName:   fakeDB.GetName(),
+		DatabaseHost:   DRIVER_NAME,
+		Replicas:       replicas,
+	}
+
+	err = cypressutils.SetupDSNWithPool(organizationId, conDSN, dbPool)
+	if err != nil {
+		dbPool.Close()
+		return nil, nil, err
+	}
+	return fakeDB, replicaDBs, nil
+}
+
+func UnregisterOrganization(organizationId string, fakeDB *FakeDB) error {
+	registryMutex.Lock()
+	delete(fakeDBs, fakeDB.GetName())
+	registryMutex.Unlock()
+
+	return cypressutils.CloseConnection(organizationId)
+}
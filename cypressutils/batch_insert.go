@@ -0,0 +1,118 @@
+package cypressutils
+
+import (
+	"fmt"
+	cErrors "github.com/pkg/errors"
+	"strings"
+)
+
+type BatchMode uint
+
+const (
+	// BATCH_ALL_OR_NOTHING inserts every chunk or, as soon as one fails, none of them
+	BATCH_ALL_OR_NOTHING BatchMode = iota
+	// BATCH_BEST_EFFORT keeps the chunks that went in and goes on past the ones that failed
+	BATCH_BEST_EFFORT
+)
+
+const batchInsertSavepoint = "batch_insert_chunk"
+
+// BatchInsertStatement is a multi-row INSERT. Rows holds the named variables of every row in the order of Columns
+type BatchInsertStatement struct {
+	TableName string
+	Columns   []string
+	Rows      [][]string
+	Returning string
+}
+
+// batchInsertQueries lines every record up with columns, those of all the records when none are given, and
+// splits the records in chunks that stay within the parameter limit of the dialect. A column a record does not
+// have is inserted as NULL, a column of the record that is not in columns is refused
+func batchInsertQueries(dialect Dialect, tableName string, columns []string, records *CypressArrayList, returning string) ([]*QueryBuilder, []*CypressHashMap, error) {
+	if err := validateTableName(tableName, "BATCH INSERT: Table name is empty", "BATCH INSERT: "); err != nil {
+		return nil, nil, err
+	}
+
+	if records == nil || records.Size() == 0 {
+		err := cErrors.New("BATCH INSERT: No records provided")
+		ThrowException(err)
+		return nil, nil, err
+	}
+
+	if len(columns) == 0 {
+		columns = recordsColumns(records)
+	}
+	for _, column := range columns {
+		if err := validateIdentifier(column, "BATCH INSERT: "); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	chunkSize := dialect.MaxParameters() / len(columns)
+	if chunkSize == 0 {
+		err := cErrors.New(fmt.Sprintf("BATCH INSERT: %d columns exceed the %d parameters %s takes", len(columns),
+			dialect.MaxParameters(), dialect.GetDatabaseServer()))
+		ThrowException(err)
+		return nil, nil, err
+	}
+
+	var queryBuilders []*QueryBuilder
+	var queryArgumentsList []*CypressHashMap
+
+	for start := 0; start < records.Size(); start += chunkSize {
+		batchInsert := &BatchInsertStatement{TableName: quoteIdentifier(dialect, tableName), Columns: quoteIdentifiers(dialect, columns),
+			Returning: returning}
+		queryArguments := NewMap()
+		queryArguments.SetTableName(tableName)
+
+		for index := start; index < records.Size() && index < start+chunkSize; index++ {
+			values := recordValues(records.GetRecord(index))
+
+			namedVariables := make([]string, len(columns))
+			for columnIndex, column := range columns {
+				namedVariables[columnIndex] = fmt.Sprintf(":%s__%d", column, index)
+				queryArguments.PutValue(namedVariables[columnIndex], values[strings.ToLower(column)])
+				delete(values, strings.ToLower(column))
+			}
+			for column := range values {
+				err := cErrors.New(fmt.Sprintf("BATCH INSERT: Record %d has %s which is not one of the columns inserted", index, column))
+				ThrowException(err)
+				return nil, nil, err
+			}
+			batchInsert.Rows = append(batchInsert.Rows, namedVariables)
+		}
+
+		query, err := dialect.BatchInsert(batchInsert)
+		if err != nil {
+			ThrowException(err)
+			return nil, nil, err
+		}
+		queryBuilders = append(queryBuilders, NewQueryBuilder(dialect).RawQuery(query))
+		queryArgumentsList = append(queryArgumentsList, queryArguments)
+	}
+	return queryBuilders, queryArgumentsList, nil
+}
+
+// recordValues keys the values of the record by their column in lower case, without the colon
+func recordValues(record *CypressHashMap) map[string]interface{} {
+	values := make(map[string]interface{})
+	for pair := record.GetData().Oldest(); pair != nil; pair = pair.Next() {
+		values[strings.ToLower(strings.TrimPrefix(fmt.Sprintf("%v", pair.Key), ":"))] = pair.Value
+	}
+	return values
+}
+
+// recordsColumns is every column found in the records, in the order they first appear
+func recordsColumns(records *CypressArrayList) []string {
+	var columns []string
+	seen := make(map[string]bool)
+	for index := 0; index < records.Size(); index++ {
+		for _, column := range records.GetRecord(index).GetKeysNoStartColon() {
+			if !seen[strings.ToLower(column)] {
+				seen[strings.ToLower(column)] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+	return columns
+}
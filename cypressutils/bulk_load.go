@@ -0,0 +1,137 @@
+package cypressutils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	cErrors "github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+// RowSource streams the rows of a BulkLoad, e.g. as a file is read, so they need not all be held in memory.
+// Next gives the values of the next row in the order of the columns, and io.EOF once there is none left
+type RowSource interface {
+	Next() ([]interface{}, error)
+}
+
+type listRowSource struct {
+	columns []string
+	records *CypressArrayList
+	index   int
+}
+
+// NewListRowSource hands out the records of a list, a column a record does not have as NULL
+func NewListRowSource(columns []string, records *CypressArrayList) RowSource {
+	return &listRowSource{columns: columns, records: records}
+}
+
+func (source *listRowSource) Next() ([]interface{}, error) {
+	if source.records == nil || source.index >= source.records.Size() {
+		return nil, io.EOF
+	}
+
+	values := recordValues(source.records.GetRecord(source.index))
+	source.index++
+
+	row := make([]interface{}, len(source.columns))
+	for index, column := range source.columns {
+		row[index] = values[strings.ToLower(column)]
+	}
+	return row, nil
+}
+
+// txBulkLoad loads the rows with COPY on PostgreSQL and with batch inserts of as many rows as the parameter limit
+// allows on the other servers. The data is the number of rows loaded
+func txBulkLoad(ctx context.Context, organizationId string, tx *sql.Tx, tableName string, columns []string, source RowSource) (twrapper *TransactionWrapper, err error) {
+	twrapper = NewTransactionWrapper()
+
+	if err = validateTableName(tableName, "BULK LOAD: Table name is empty", "BULK LOAD: "); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	if len(columns) == 0 {
+		err = cErrors.New("BULK LOAD: columns cannot be empty")
+		ThrowException(err)
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+	for _, column := range columns {
+		if err = validateIdentifier(column, "BULK LOAD: "); err != nil {
+			twrapper.SetHasErrors(true)
+			twrapper.AddError(err.Error())
+			return twrapper, err
+		}
+	}
+
+	if GetOrganizationDialect(organizationId).GetDatabaseServer() == PostgreSQL {
+		return txCopyIn(ctx, organizationId, tx, tableName, columns, source)
+	}
+	return txLoadInBatches(ctx, organizationId, tx, tableName, columns, source)
+}
+
+// txLoadInBatches reads the rows a chunk at a time, there is never more than one chunk in memory
+func txLoadInBatches(ctx context.Context, organizationId string, tx *sql.Tx, tableName string, columns []string, source RowSource) (twrapper *TransactionWrapper, err error) {
+	twrapper = NewTransactionWrapper()
+	dialect := GetOrganizationDialect(organizationId)
+	chunkSize := dialect.MaxParameters() / len(columns)
+
+	var rowsLoaded int64
+	for {
+		records := NewList()
+		for records.Size() < chunkSize {
+			row, nextErr := source.Next()
+			if nextErr == io.EOF {
+				break
+			}
+			if nextErr == nil && len(row) != len(columns) {
+				nextErr = cErrors.New(fmt.Sprintf("BULK LOAD: Row %d has %d values for %d columns", rowsLoaded+int64(records.Size()), len(row), len(columns)))
+			}
+			if nextErr != nil {
+				ThrowException(nextErr)
+				twrapper.SetHasErrors(true)
+				twrapper.AddError(nextErr.Error())
+				twrapper.SetData(rowsLoaded)
+				return twrapper, nextErr
+			}
+
+			hashMap := NewMap()
+			for index, column := range columns {
+				hashMap.PutValue(column, row[index])
+			}
+			records.AddNewRecord(hashMap)
+		}
+		if records.Size() == 0 {
+			break
+		}
+
+		queryBuilders, queryArgumentsList, err := batchInsertQueries(dialect, tableName, columns, records, "")
+		if err != nil {
+			twrapper.SetHasErrors(true)
+			twrapper.AddError(err.Error())
+			twrapper.SetData(rowsLoaded)
+			return twrapper, err
+		}
+
+		chunkWrapper, err := txBatchInsert(ctx, organizationId, tx, queryBuilders, queryArgumentsList, BATCH_ALL_OR_NOTHING, false)
+		twrapper.Errors = append(twrapper.Errors, chunkWrapper.Errors...)
+		twrapper.QueryExecutedList = append(twrapper.QueryExecutedList, chunkWrapper.QueryExecutedList...)
+		twrapper.HasTimedOut = twrapper.HasTimedOut || chunkWrapper.HasTimedOut
+		if err != nil {
+			twrapper.SetHasErrors(true)
+			twrapper.SetData(rowsLoaded)
+			return twrapper, err
+		}
+
+		for _, recordsInserted := range chunkWrapper.GetData().([]int64) {
+			rowsLoaded += recordsInserted
+		}
+	}
+
+	twrapper.AddMessage(fmt.Sprintf("%d record(s) loaded", rowsLoaded))
+	twrapper.SetData(rowsLoaded)
+	return twrapper, nil
+}
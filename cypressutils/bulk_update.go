@@ -0,0 +1,109 @@
+package cypressutils
+
+import (
+	"fmt"
+	cErrors "github.com/pkg/errors"
+	"strings"
+)
+
+// BulkUpdateStatement is what a dialect needs to update many rows, each to values of its own, in one statement.
+// Rows holds the named variables of every row in the order of Columns, the key columns first
+type BulkUpdateStatement struct {
+	TableName     string
+	Columns       []string
+	Rows          [][]string
+	KeyColumns    []string
+	UpdateColumns []string
+}
+
+// bulkUpdateQueries splits the records in chunks that stay within the parameter limit of the dialect and gives
+// the statement and the arguments of every chunk. The records all need the columns of the first one
+func bulkUpdateQueries(dialect Dialect, tableName string, keyColumns []string, records *CypressArrayList) ([]*QueryBuilder, []*CypressHashMap, error) {
+	if err := validateTableName(tableName, "BULK UPDATE: Table name is empty", "BULK UPDATE: "); err != nil {
+		return nil, nil, err
+	}
+
+	var err error
+	switch {
+	case records == nil || records.Size() == 0:
+		err = cErrors.New("BULK UPDATE: No records provided")
+	case len(keyColumns) == 0:
+		err = cErrors.New("BULK UPDATE: key columns cannot be empty")
+	}
+	if err != nil {
+		ThrowException(err)
+		return nil, nil, err
+	}
+
+	var updateColumns []string
+	for _, column := range records.GetRecord(0).GetKeysNoStartColon() {
+		if !containsFold(keyColumns, column) {
+			updateColumns = append(updateColumns, column)
+		}
+	}
+	columns := append(append([]string{}, keyColumns...), updateColumns...)
+
+	for _, column := range columns {
+		if err := validateIdentifier(column, "BULK UPDATE: "); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(updateColumns) == 0 {
+		err := cErrors.New("BULK UPDATE: records have no column to update besides the key columns")
+		ThrowException(err)
+		return nil, nil, err
+	}
+
+	chunkSize := dialect.MaxParameters() / len(columns)
+	if chunkSize == 0 {
+		err := cErrors.New(fmt.Sprintf("BULK UPDATE: %d columns exceed the %d parameters %s takes", len(columns),
+			dialect.MaxParameters(), dialect.GetDatabaseServer()))
+		ThrowException(err)
+		return nil, nil, err
+	}
+
+	var queryBuilders []*QueryBuilder
+	var queryArgumentsList []*CypressHashMap
+
+	for start := 0; start < records.Size(); start += chunkSize {
+		bulkUpdate := &BulkUpdateStatement{
+			TableName:     quoteIdentifier(dialect, tableName),
+			Columns:       quoteIdentifiers(dialect, columns),
+			KeyColumns:    quoteIdentifiers(dialect, keyColumns),
+			UpdateColumns: quoteIdentifiers(dialect, updateColumns),
+		}
+		queryArguments := NewMap()
+		queryArguments.SetTableName(tableName)
+
+		for index := start; index < records.Size() && index < start+chunkSize; index++ {
+			values := recordValues(records.GetRecord(index))
+			if len(values) != len(columns) {
+				err := cErrors.New(fmt.Sprintf("BULK UPDATE: Record %d does not have the %d columns of the first record", index, len(columns)))
+				ThrowException(err)
+				return nil, nil, err
+			}
+
+			namedVariables := make([]string, len(columns))
+			for columnIndex, column := range columns {
+				value, exists := values[strings.ToLower(column)]
+				if !exists {
+					err := cErrors.New(fmt.Sprintf("BULK UPDATE: Record %d has no %s", index, column))
+					ThrowException(err)
+					return nil, nil, err
+				}
+				namedVariables[columnIndex] = fmt.Sprintf(":%s__%d", column, index)
+				queryArguments.PutValue(namedVariables[columnIndex], value)
+			}
+			bulkUpdate.Rows = append(bulkUpdate.Rows, namedVariables)
+		}
+
+		query, err := dialect.BulkUpdate(bulkUpdate)
+		if err != nil {
+			ThrowException(err)
+			return nil, nil, err
+		}
+		queryBuilders = append(queryBuilders, NewQueryBuilder(dialect).RawQuery(query))
+		queryArgumentsList = append(queryArgumentsList, queryArguments)
+	}
+	return queryBuilders, queryArgumentsList, nil
+}
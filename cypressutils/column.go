@@ -17,6 +17,14 @@ const (
 	MIN
 	AVG
 	SUM
+	ROW_NUMBER
+	RANK
+	DENSE_RANK
+	NTILE
+	LAG
+	LEAD
+	FIRST_VALUE
+	LAST_VALUE
 )
 
 var orderByStrings = []string{
@@ -45,6 +53,14 @@ var aggregates = []string{
 	"MIN",
 	"AVG",
 	"SUM",
+	"ROW_NUMBER",
+	"RANK",
+	"DENSE_RANK",
+	"NTILE",
+	"LAG",
+	"LEAD",
+	"FIRST_VALUE",
+	"LAST_VALUE",
 }
 
 func (aggregate Aggregates) name() string {
@@ -62,6 +78,32 @@ func (aggregate Aggregates) values() *[]string {
 type Column struct {
 	ColumnName, ColumnAlias string
 	Aggregate               Aggregates
+	Arguments               []string
+	Window                  *Window
+}
+
+func NewColumn(columnName string, aggregate ...Aggregates) *Column {
+	column := &Column{ColumnName: columnName}
+	if aggregate != nil {
+		column.Aggregate = aggregate[0]
+	}
+	return column
+}
+
+func (column *Column) As(alias string) *Column {
+	column.ColumnAlias = alias
+	return column
+}
+
+// WithArguments adds what the function takes after the column, e.g. the offset and default of LAG
+func (column *Column) WithArguments(arguments ...string) *Column {
+	column.Arguments = append(column.Arguments, arguments...)
+	return column
+}
+
+func (column *Column) Over(window *Window) *Column {
+	column.Window = window
+	return column
 }
 
 func (column *Column) GetColumnName() string {
@@ -75,3 +117,11 @@ func (column *Column) GetColumnAlias() string {
 func (column *Column) GetAggregate() Aggregates {
 	return column.Aggregate
 }
+
+func (column *Column) GetArguments() []string {
+	return column.Arguments
+}
+
+func (column *Column) GetWindow() *Window {
+	return column.Window
+}
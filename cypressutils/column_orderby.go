@@ -4,3 +4,11 @@ type ColumnOrderBy struct {
 	Column
 	OrderBy_ OrderBy
 }
+
+func NewColumnOrderBy(columnName string, orderBy OrderBy, aggregate ...Aggregates) *ColumnOrderBy {
+	return &ColumnOrderBy{Column: *NewColumn(columnName, aggregate...), OrderBy_: orderBy}
+}
+
+func (columnOrderBy *ColumnOrderBy) GetOrderBy() OrderBy {
+	return columnOrderBy.OrderBy_
+}
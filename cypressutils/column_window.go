@@ -0,0 +1,178 @@
+package cypressutils
+
+import (
+	"bytes"
+	cErrors "github.com/pkg/errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	FRAME_ROWS  = "ROWS"
+	FRAME_RANGE = "RANGE"
+
+	UNBOUNDED_PRECEDING = "UNBOUNDED PRECEDING"
+	CURRENT_ROW         = "CURRENT ROW"
+	UNBOUNDED_FOLLOWING = "UNBOUNDED FOLLOWING"
+)
+
+// WindowFrame is the ROWS or RANGE BETWEEN Start AND End of a window. End may be left blank
+type WindowFrame struct {
+	Unit, Start, End string
+}
+
+// Window is what goes in OVER (...): PARTITION BY, ORDER BY and the frame
+type Window struct {
+	PartitionColumns []string
+	OrderColumns     []*ColumnOrderBy
+	Frame            *WindowFrame
+}
+
+func NewWindow() *Window {
+	return &Window{}
+}
+
+func (window *Window) PartitionBy(columns ...string) *Window {
+	window.PartitionColumns = append(window.PartitionColumns, columns...)
+	return window
+}
+
+func (window *Window) OrderBy(columns ...*ColumnOrderBy) *Window {
+	window.OrderColumns = append(window.OrderColumns, columns...)
+	return window
+}
+
+// Rows frames the window by physical rows, e.g. Rows(UNBOUNDED_PRECEDING, CURRENT_ROW) for a running total
+func (window *Window) Rows(start, end string) *Window {
+	window.Frame = &WindowFrame{Unit: FRAME_ROWS, Start: start, End: end}
+	return window
+}
+
+func (window *Window) Range(start, end string) *Window {
+	window.Frame = &WindowFrame{Unit: FRAME_RANGE, Start: start, End: end}
+	return window
+}
+
+func Preceding(offset int) string {
+	return strconv.Itoa(offset) + " PRECEDING"
+}
+
+func Following(offset int) string {
+	return strconv.Itoa(offset) + " FOLLOWING"
+}
+
+func (window *Window) render(dialect Dialect) string {
+	var pieces []string
+	if len(window.PartitionColumns) > 0 {
+		pieces = append(pieces, "PARTITION BY "+strings.Join(quoteIdentifiers(dialect, window.PartitionColumns), ", "))
+	}
+	if len(window.OrderColumns) > 0 {
+		pieces = append(pieces, "ORDER BY "+concatenateOrderByColumnNames(dialect, window.OrderColumns))
+	}
+	if window.Frame != nil {
+		if window.Frame.End == "" {
+			pieces = append(pieces, window.Frame.Unit+" "+window.Frame.Start)
+		} else {
+			pieces = append(pieces, window.Frame.Unit+" BETWEEN "+window.Frame.Start+" AND "+window.Frame.End)
+		}
+	}
+	return strings.Join(pieces, " ")
+}
+
+// expression is the column as it is selected, without its alias. The names are quoted for dialect, a nil
+// dialect leaves them as they were given
+func (column *Column) expression(dialect Dialect) string {
+	if column.Aggregate == NO_AGGREGATE && column.Window == nil {
+		return quoteList(dialect, column.ColumnName, selectItemRegex)
+	}
+
+	var buf bytes.Buffer
+	if column.Aggregate == NO_AGGREGATE {
+		buf.WriteString(quoteIdentifier(dialect, column.ColumnName))
+	} else {
+		var arguments []string
+		if column.ColumnName != "" {
+			arguments = append(arguments, quoteIdentifier(dialect, column.ColumnName))
+		}
+		arguments = append(arguments, column.Arguments...)
+		if column.Aggregate == COUNT && len(arguments) == 0 {
+			arguments = append(arguments, "*")
+		}
+
+		buf.WriteString(column.Aggregate.name())
+		buf.WriteString("(")
+		buf.WriteString(strings.Join(arguments, ", "))
+		buf.WriteString(")")
+	}
+
+	if column.Window != nil {
+		buf.WriteString(" OVER (")
+		buf.WriteString(column.Window.render(dialect))
+		buf.WriteString(")")
+	}
+	return buf.String()
+}
+
+func (column *Column) isWindowFunction() bool {
+	return column.Aggregate >= ROW_NUMBER
+}
+
+func validateColumn(column *Column) error {
+	var err error
+	switch {
+	case column == nil:
+		err = cErrors.New("SELECT: column is nil")
+	case column.ColumnName == "" && column.Aggregate != COUNT && !column.isWindowFunction():
+		err = cErrors.New("SELECT: column name is empty")
+	case column.ColumnName != "" && column.ColumnName != "*" && !identifierRegex.MatchString(strings.TrimSuffix(column.ColumnName, ".*")):
+		err = cErrors.New("SELECT: '" + column.ColumnName + "' is not a valid identifier, use SelectExpression for expressions")
+	case column.ColumnAlias != "" && !identifierRegex.MatchString(column.ColumnAlias):
+		err = cErrors.New("SELECT: alias '" + column.ColumnAlias + "' is not a valid identifier")
+	case column.isWindowFunction() && column.Window == nil:
+		err = cErrors.New("SELECT: " + column.Aggregate.name() + " needs a window, set it with Over")
+	case column.isWindowFunction() && column.Aggregate >= NTILE && column.ColumnName == "":
+		err = cErrors.New("SELECT: " + column.Aggregate.name() + " needs a column")
+	case column.Window != nil && column.Aggregate == DISTINCT:
+		err = cErrors.New("SELECT: DISTINCT cannot be used over a window")
+	case column.Window != nil && column.Window.Frame != nil && column.Window.Frame.Start == "":
+		err = cErrors.New("SELECT: window frame of " + column.expression(nil) + " has no start")
+	}
+
+	if err != nil {
+		ThrowException(err)
+	}
+	return err
+}
+
+/**************** ALIASES ****************/
+
+// resolveAliases swaps the select aliases in a HAVING clause for the aggregates they stand for, only MySQL
+// resolves them itself. Window functions are evaluated after HAVING so their aliases are refused
+func (statement *queryStatement) resolveAliases(dialect Dialect, clause string) (string, error) {
+	var resolveErr error
+
+	for _, item := range statement.selectItems {
+		column := item.column
+		if column == nil || column.ColumnAlias == "" || column.Aggregate == NO_AGGREGATE {
+			continue
+		}
+
+		aliasRegex := regexp.MustCompile(`(^|[^\w.:'"])` + regexp.QuoteMeta(column.ColumnAlias) + `($|[^\w.(])`)
+		if !aliasRegex.MatchString(clause) {
+			continue
+		}
+
+		if column.Window != nil {
+			resolveErr = cErrors.New("HAVING: '" + column.ColumnAlias + "' is a window function, filter on it in an outer query")
+			break
+		}
+
+		expression := column.expression(dialect)
+		clause = aliasRegex.ReplaceAllStringFunc(clause, func(match string) string {
+			submatches := aliasRegex.FindStringSubmatch(match)
+			return submatches[1] + expression + submatches[2]
+		})
+	}
+	return clause, resolveErr
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func GetConfFilePath() string {
@@ -65,8 +66,20 @@ const (
 	SLING_RING_FIND_FREE_CONN_AFTER_TIME_UNIT       = "/API/DB/SLING_RING/FIND_FREE_CONN_AFTER/@TIME_UNIT"
 	SLING_RING_DOWNSIZE_AFTER                       = "/API/DB/SLING_RING/DOWNSIZE_AFTER/@VALUE"
 	SLING_RING_DOWNSIZE_AFTER_TIME_UNIT             = "/API/DB/SLING_RING/DOWNSIZE_AFTER/@TIME_UNIT"
-	SLING_RING_PING_AFTER                           = "/API/DB/SLING_RING/DOWNSIZE_AFTER/@VALUE"
-	SLING_RING_PING_AFTER_TIME_UNIT                 = "/API/DB/SLING_RING/DOWNSIZE_AFTER/@TIME_UNIT"
+	SLING_RING_PING_AFTER                           = "/API/DB/SLING_RING/PING_AFTER/@VALUE"
+	SLING_RING_PING_AFTER_TIME_UNIT                 = "/API/DB/SLING_RING/PING_AFTER/@TIME_UNIT"
+	SLING_RING_MAX_PING_FAILURES                    = "/API/DB/SLING_RING/MAX_PING_FAILURES/@VALUE"
+	SLING_RING_PING_TIMEOUT                         = "/API/DB/SLING_RING/PING_TIMEOUT/@VALUE"
+	SLING_RING_PING_TIMEOUT_TIME_UNIT               = "/API/DB/SLING_RING/PING_TIMEOUT/@TIME_UNIT"
+	SLING_RING_MAX_LIFETIME                         = "/API/DB/SLING_RING/MAX_LIFETIME/@VALUE"
+	SLING_RING_MAX_LIFETIME_TIME_UNIT               = "/API/DB/SLING_RING/MAX_LIFETIME/@TIME_UNIT"
+	SLING_RING_MAX_IDLE_TIME                        = "/API/DB/SLING_RING/MAX_IDLE_TIME/@VALUE"
+	SLING_RING_MAX_IDLE_TIME_TIME_UNIT              = "/API/DB/SLING_RING/MAX_IDLE_TIME/@TIME_UNIT"
+	SLING_RING_QUERY_TIMEOUT                        = "/API/DB/SLING_RING/QUERY_TIMEOUT/@VALUE"
+	SLING_RING_QUERY_TIMEOUT_TIME_UNIT              = "/API/DB/SLING_RING/QUERY_TIMEOUT/@TIME_UNIT"
+	XML_PATH_TO_TENANTS_TABLE                       = "/API/DB/TENANTS/@TABLE"
+	XML_PATH_TO_TENANTS_REFRESH_AFTER               = "/API/DB/TENANTS/REFRESH_AFTER/@VALUE"
+	XML_PATH_TO_TENANTS_REFRESH_AFTER_TIME_UNIT     = "/API/DB/TENANTS/REFRESH_AFTER/@TIME_UNIT"
 	XML_PATH_TO_LOGGING_FORMAT                      = "/API/LOGGING/@FORMAT"
 )
 
@@ -296,6 +309,10 @@ func ConfGetDBHost() (string, error) {
 	return ConfGetTagValue(XML_PATH_TO_DB_HOST)
 }
 
+func ConfGetDBConnMetadata() (string, error) {
+	return ConfGetTagValue(XML_PATH_TO_CONN_METADATA)
+}
+
 func ConfGetLoggingFormat() (string, error) {
 	if value, exists := confCacheableParameters[XML_PATH_TO_LOGGING_FORMAT]; exists {
 		return fmt.Sprintf("%v", value), nil
@@ -364,6 +381,66 @@ func ConfSlingRingPingAfterTimeUnit() (string, error) {
 	return ConfGetTagValue(SLING_RING_PING_AFTER_TIME_UNIT)
 }
 
+func ConfSlingRingPingInterval() (time.Duration, error) {
+	return ConfReadDuration(SLING_RING_PING_AFTER, SLING_RING_PING_AFTER_TIME_UNIT)
+}
+
+func ConfSlingRingFindFreeConnInterval() (time.Duration, error) {
+	return ConfReadDuration(SLING_RING_FIND_FREE_CONN_AFTER, SLING_RING_FIND_FREE_CONN_AFTER_TIME_UNIT)
+}
+
+func ConfSlingRingDownSizeInterval() (time.Duration, error) {
+	return ConfReadDuration(SLING_RING_DOWNSIZE_AFTER, SLING_RING_DOWNSIZE_AFTER_TIME_UNIT)
+}
+
+func ConfSlingRingPingTimeout() (time.Duration, error) {
+	return ConfReadDuration(SLING_RING_PING_TIMEOUT, SLING_RING_PING_TIMEOUT_TIME_UNIT)
+}
+
+func ConfSlingRingMaxPingFailures() (int, error) {
+	return ConfReadInt(SLING_RING_MAX_PING_FAILURES)
+}
+
+func ConfSlingRingMaxLifetime() (time.Duration, error) {
+	return ConfReadDuration(SLING_RING_MAX_LIFETIME, SLING_RING_MAX_LIFETIME_TIME_UNIT)
+}
+
+func ConfSlingRingMaxIdleTime() (time.Duration, error) {
+	return ConfReadDuration(SLING_RING_MAX_IDLE_TIME, SLING_RING_MAX_IDLE_TIME_TIME_UNIT)
+}
+
+func ConfSlingRingQueryTimeout() (time.Duration, error) {
+	return ConfReadDuration(SLING_RING_QUERY_TIMEOUT, SLING_RING_QUERY_TIMEOUT_TIME_UNIT)
+}
+
+func ConfGetTenantsTable() (string, error) {
+	return ConfGetTagValue(XML_PATH_TO_TENANTS_TABLE)
+}
+
+func ConfGetTenantsRefreshAfter() (time.Duration, error) {
+	return ConfReadDuration(XML_PATH_TO_TENANTS_REFRESH_AFTER, XML_PATH_TO_TENANTS_REFRESH_AFTER_TIME_UNIT)
+}
+
+// ConfReadDuration takes the TIME_UNIT ConvertToSeconds does and MILLISECOND, for the settings that are
+// commonly under a second such as QUERY_TIMEOUT
+func ConfReadDuration(strValueXpath, strTimeUnitXpath string) (time.Duration, error) {
+	value, err := ConfReadInt(strValueXpath)
+	if err != nil {
+		return 0, err
+	}
+
+	timeUnit, err := ConfGetTagValue(strTimeUnitXpath)
+	if err != nil {
+		return 0, err
+	}
+
+	timeUnit = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(timeUnit)), "S")
+	if timeUnit == "MILLISECOND" {
+		return time.Duration(value) * time.Millisecond, nil
+	}
+	return time.Duration(ConvertToSeconds(value, timeUnit)) * time.Second, nil
+}
+
 func ConfReadInt(strXpath string) (int, error) {
 	strResult, err := ConfGetTagValue(strXpath)
 	if err != nil {
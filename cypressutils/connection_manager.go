@@ -9,6 +9,8 @@ import (
 	cErrors "github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"strings"
+	"sync"
+	"time"
 )
 
 type DbTypes string
@@ -27,14 +29,25 @@ type DBConDSN struct {
 	DatabaseName, DatabaseHost, UserName, Password, ConnectionParameters string
 	Port                                                                 int
 	MaxIdleConnections, MaxOpenConnections                               int
+	ConnMaxLifetime, ConnMaxIdleTime                                     time.Duration
+	QueryTimeout                                                         time.Duration
+	// FullScanThreshold makes Explain warn about the full scans of tables of at least as many rows, zero leaves them be
+	FullScanThreshold int64
+
+	// Replicas serve the reads of the Select, Count, Exists and JoinSelectQuery functions. Blank fields are
+	// taken from the primary
+	Replicas []*DBConDSN
 }
 
 type ConnectionsDSNs struct {
-	conDSNs map[string]*DBConDSN
+	mutex    sync.RWMutex
+	conDSNs  map[string]*DBConDSN
+	conPools map[string]*sql.DB
 }
 
 var connectionsDSNs *ConnectionsDSNs = &ConnectionsDSNs{
-	conDSNs: make(map[string]*DBConDSN),
+	conDSNs:  make(map[string]*DBConDSN),
+	conPools: make(map[string]*sql.DB),
 }
 
 func SetupDSNs() {
@@ -44,108 +57,134 @@ func SetupDSNs() {
 	masterDatabaseName, _ := ConfGetDatabaseName()
 	masterDatabaseHost, _ := ConfGetDBHost()
 	masterPort, _ := ConfGetDBPort()
+	masterConnMetadata, _ := ConfGetDBConnMetadata()
 	maxIdleConns, _ := ConfSlingRingInitialPoolSize()
 	maxOpenConns, _ := ConfSlingRingMaxPoolSize()
-
-	masterDSNURL := fmt.Sprintf("host=%s Port=%d user=%s Password=%s dbname=%s sslmode=disable",
-		masterDatabaseHost, masterPort, masterUserName, masterPassword, masterDatabaseName)
-	//masterDSNURLMasked := masterUserName + ":***@tcp(" + masterDatabaseHost + ":" + strconv.Itoa(masterPort) + ")/" + masterDatabaseName
-
-	fmt.Println("\n ---------------------<", "database", ">---------------------")
-	fmt.Println("", PadStringToPrintInConsole(strings.ToUpper(masterDatabaseName), 54, " "))
-	fmt.Println("", PadStringToPrintInConsole("------[ Creating connection pool... ]------", 54, " "))
-	fmt.Println(" Database Server   : ", databaseServer)
-	fmt.Println(" Database Username : ", masterUserName)
-	//fmt.Println(" Connection URL:", masterDSNURLMasked)
-
-	masterDb, err := sql.Open("postgres", masterDSNURL)
-	defer masterDb.Close()
-
-	if err != nil {
-		logrus.Fatal(err)
-	}
-
-	err = masterDb.Ping()
-	if err != nil {
-		logrus.Fatal(err)
-	}
-
-	//masterDb.SetConnMaxLifetime(time.Minute * 3)
-	masterDb.SetMaxIdleConns(maxIdleConns)
-	masterDb.SetMaxOpenConns(maxOpenConns)
+	connMaxLifetime, _ := ConfSlingRingMaxLifetime()
+	connMaxIdleTime, _ := ConfSlingRingMaxIdleTime()
+	queryTimeout, _ := ConfSlingRingQueryTimeout()
 
 	masterConDSN := &DBConDSN{
-		OrganizationId:       "-1L",
+		OrganizationId:       MASTER_ORGANIZATION_ID,
 		DatabaseServer:       databaseServer,
 		DatabaseName:         masterDatabaseName,
 		DatabaseHost:         masterDatabaseHost,
 		UserName:             masterUserName,
 		Password:             masterPassword,
-		ConnectionParameters: "",
+		ConnectionParameters: masterConnMetadata,
 		Port:                 masterPort,
+		MaxIdleConnections:   maxIdleConns,
+		MaxOpenConnections:   maxOpenConns,
+		ConnMaxLifetime:      connMaxLifetime,
+		ConnMaxIdleTime:      connMaxIdleTime,
+		QueryTimeout:         queryTimeout,
 	}
 
-	connectionsDSNs.conDSNs["-1L"] = masterConDSN
-
-	//TODO: FETCH CONNECTIONS AND POPULATE HERE
-}
-
-func SetupDSN(organizationId string, conDSN *DBConDSN) error {
+	err := SetupDSN(MASTER_ORGANIZATION_ID, masterConDSN)
+	if err != nil {
+		logrus.Fatal(err)
+	}
 
-	var masterDSNURL = ""
+	StartHealthSupervisor()
 
-	switch conDSN.DatabaseServer {
-	case PostgreSQL:
-		masterDSNURL = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-			conDSN.DatabaseHost, conDSN.Port, conDSN.UserName, conDSN.Password, conDSN.DatabaseName)
-	case MicrosoftSQL:
-		masterDSNURL = fmt.Sprintf("server=%s;user id=%s;Password=%s;Port=%d;database=%s",
-			conDSN.DatabaseHost, conDSN.UserName, conDSN.Password, conDSN.Port, conDSN.DatabaseName)
-	case MySQL:
-		masterDSNURL = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", conDSN.UserName, conDSN.Password, conDSN.DatabaseHost, conDSN.Port, conDSN.DatabaseName)
-	case Oracle:
-		masterDSNURL = fmt.Sprintf("%s/%s@//%s:%d/%s", conDSN.UserName, conDSN.Password, conDSN.DatabaseHost, conDSN.Port, conDSN.DatabaseName)
+	err = StartTenantRegistry()
+	if err != nil {
+		logrus.Error("Failed to load tenant connections: " + err.Error())
 	}
+}
 
+func SetupDSN(organizationId string, conDSN *DBConDSN) error {
 	fmt.Println("\n ---------------------<", "database", ">---------------------")
 	fmt.Println("", PadStringToPrintInConsole(strings.ToUpper(conDSN.DatabaseName), 54, " "))
 	fmt.Println("", PadStringToPrintInConsole("------[ Creating connection pool... ]------", 54, " "))
 	fmt.Println(" Database Server   : ", conDSN.DatabaseServer)
 	fmt.Println(" Database Host : ", conDSN.DatabaseHost, conDSN.Port)
-	//fmt.Println(" Connection URL:", masterDSNURLMasked)
+	fmt.Println(" Connection URL:", MaskedDSN(conDSN))
 
-	masterDb, err := sql.Open(string(conDSN.DatabaseServer), masterDSNURL)
+	dbPool, err := openConnectionPool(conDSN)
 	if err != nil {
 		ThrowException(err)
 		return err
 	}
 
-	defer masterDb.Close()
+	return SetupDSNWithPool(organizationId, conDSN, dbPool)
+}
 
-	err = masterDb.Ping()
+// SetupDSNWithPool registers a pool that was opened elsewhere, e.g. against a fake driver in tests.
+// The pool is owned by the connection manager from here on
+func SetupDSNWithPool(organizationId string, conDSN *DBConDSN, dbPool *sql.DB) error {
+	err := dbPool.Ping()
 	if err != nil {
+		dbPool.Close()
 		ThrowException(err)
 		return err
 	}
 
-	//masterDb.SetConnMaxLifetime(time.Minute * 3)
-	masterDb.SetMaxIdleConns(conDSN.MaxIdleConnections)
-	masterDb.SetMaxOpenConns(conDSN.MaxOpenConnections)
-
+	connectionsDSNs.mutex.Lock()
+	oldPool := connectionsDSNs.conPools[organizationId]
+	oldConDSN := connectionsDSNs.conDSNs[organizationId]
 	connectionsDSNs.conDSNs[organizationId] = conDSN
+	connectionsDSNs.conPools[organizationId] = dbPool
+	connectionsDSNs.mutex.Unlock()
+
+	if supervisor := GetHealthSupervisor(); supervisor != nil {
+		supervisor.supervise(organizationId)
+	}
+
+	setupReplicas(organizationId, conDSN, oldConDSN)
+
+	//THE OLD POOL IS ONLY CLOSED AFTER THE SWAP SO THAT CALLERS ALREADY HOLDING IT CAN FINISH
+	if oldPool != nil {
+		oldPool.Close()
+	}
 	return nil
 }
 
-func connectToDatabase(databaseType DbTypes, databaseName, databaseHost, userName, password, connectionMetadata string, port int) (*sql.DB, error) {
+func openConnectionPool(conDSN *DBConDSN) (*sql.DB, error) {
+	dsn, err := BuildDSN(conDSN)
+	if err != nil {
+		return nil, err
+	}
 
-	masterDSNURL := fmt.Sprintf("host=%s Port=%d user=%s Password=%s dbname=%s sslmode=disable",
-		databaseHost, port, userName, password, databaseName)
+	dbPool, err := sql.Open(string(conDSN.DatabaseServer), dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	configureConnectionPool(dbPool, conDSN)
+	return dbPool, nil
+}
+
+func configureConnectionPool(dbPool *sql.DB, conDSN *DBConDSN) {
+	dbPool.SetMaxIdleConns(conDSN.MaxIdleConnections)
+	dbPool.SetMaxOpenConns(conDSN.MaxOpenConnections)
+	dbPool.SetConnMaxLifetime(conDSN.ConnMaxLifetime)
+	dbPool.SetConnMaxIdleTime(conDSN.ConnMaxIdleTime)
+}
+
+func connectToDatabase(databaseType DbTypes, databaseName, databaseHost, userName, password, connectionMetadata string, port int) (*sql.DB, error) {
+	dsn, err := BuildDSN(&DBConDSN{
+		DatabaseServer:       databaseType,
+		DatabaseName:         databaseName,
+		DatabaseHost:         databaseHost,
+		UserName:             userName,
+		Password:             password,
+		ConnectionParameters: connectionMetadata,
+		Port:                 port,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return sql.Open(string(databaseType), masterDSNURL)
+	return sql.Open(string(databaseType), dsn)
 }
 
+// GetConnection returns the shared connection pool of the organization. The pool is owned by the
+// connection manager, callers must NOT close it. Use CloseConnection or CloseAll on shutdown
 func GetConnection(organizationId string) (*sql.DB, error) {
-	conDSN, exists := connectionsDSNs.conDSNs[organizationId]
+	connectionsDSNs.mutex.RLock()
+	dbPool, exists := connectionsDSNs.conPools[organizationId]
+	connectionsDSNs.mutex.RUnlock()
 
 	if !exists {
 		err := cErrors.New("No Connection Found where Organization Id = '" + organizationId + "'")
@@ -154,26 +193,48 @@ func GetConnection(organizationId string) (*sql.DB, error) {
 		return nil, err
 	}
 
-	var masterDSNURL = ""
+	if !IsHealthy(organizationId) {
+		status, _ := GetHealthSupervisor().GetStatus(organizationId)
+		err := cErrors.New(fmt.Sprintf("Organization Id = '%s' is unhealthy after %d failed pings: %v",
+			organizationId, status.ConsecutiveFailures, status.LastError))
+		return nil, err
+	}
+
+	return dbPool, nil
+}
+
+func lookupConnectionPool(organizationId string) (*sql.DB, bool) {
+	connectionsDSNs.mutex.RLock()
+	defer connectionsDSNs.mutex.RUnlock()
 
-	switch conDSN.DatabaseServer {
-	case PostgreSQL:
-		masterDSNURL = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-			conDSN.DatabaseHost, conDSN.Port, conDSN.UserName, conDSN.Password, conDSN.DatabaseName)
-	case MicrosoftSQL:
-		masterDSNURL = fmt.Sprintf("server=%s;user id=%s;Password=%s;Port=%d;database=%s",
-			conDSN.DatabaseHost, conDSN.UserName, conDSN.Password, conDSN.Port, conDSN.DatabaseName)
-	case MySQL:
-		masterDSNURL = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", conDSN.UserName, conDSN.Password, conDSN.DatabaseHost, conDSN.Port, conDSN.DatabaseName)
-	case Oracle:
-		masterDSNURL = fmt.Sprintf("%s/%s@//%s:%d/%s", conDSN.UserName, conDSN.Password, conDSN.DatabaseHost, conDSN.Port, conDSN.DatabaseName)
+	dbPool, exists := connectionsDSNs.conPools[organizationId]
+	return dbPool, exists
+}
+
+func registeredOrganizations() []string {
+	connectionsDSNs.mutex.RLock()
+	defer connectionsDSNs.mutex.RUnlock()
+
+	organizationIds := make([]string, 0, len(connectionsDSNs.conPools))
+	for organizationId := range connectionsDSNs.conPools {
+		organizationIds = append(organizationIds, organizationId)
 	}
+	return organizationIds
+}
+
+func lookupConDSN(organizationId string) (*DBConDSN, bool) {
+	connectionsDSNs.mutex.RLock()
+	defer connectionsDSNs.mutex.RUnlock()
 
-	return sql.Open(string(conDSN.DatabaseServer), masterDSNURL)
+	conDSN, exists := connectionsDSNs.conDSNs[organizationId]
+	return conDSN, exists
 }
 
 func GetConDSN(organizationId string) *DBConDSN {
+	connectionsDSNs.mutex.RLock()
 	conDSN, exists := connectionsDSNs.conDSNs[organizationId]
+	connectionsDSNs.mutex.RUnlock()
+
 	if !exists {
 		err := cErrors.New("No Connection DSN Found where Organization Id = '" + organizationId + "'")
 		logrus.Error(err.Error())
@@ -182,6 +243,82 @@ func GetConDSN(organizationId string) *DBConDSN {
 	return conDSN
 }
 
+func CloseConnection(organizationId string) error {
+	connectionsDSNs.mutex.Lock()
+	dbPool, exists := connectionsDSNs.conPools[organizationId]
+	conDSN := connectionsDSNs.conDSNs[organizationId]
+	delete(connectionsDSNs.conPools, organizationId)
+	delete(connectionsDSNs.conDSNs, organizationId)
+	connectionsDSNs.mutex.Unlock()
+
+	if supervisor := GetHealthSupervisor(); supervisor != nil {
+		supervisor.unsupervise(organizationId)
+	}
+
+	if conDSN != nil {
+		closeReplicas(organizationId, conDSN, 0)
+	}
+
+	if !exists {
+		err := cErrors.New("No Connection Found where Organization Id = '" + organizationId + "'")
+		logrus.Error(err.Error())
+		return err
+	}
+
+	return dbPool.Close()
+}
+
+func CloseAll() error {
+	StopHealthSupervisor()
+
+	connectionsDSNs.mutex.Lock()
+	dbPools := connectionsDSNs.conPools
+	connectionsDSNs.conPools = make(map[string]*sql.DB)
+	connectionsDSNs.conDSNs = make(map[string]*DBConDSN)
+	connectionsDSNs.mutex.Unlock()
+
+	replicaCursors.Range(func(organizationId, _ interface{}) bool {
+		replicaCursors.Delete(organizationId)
+		return true
+	})
+
+	var lastErr error
+	for organizationId, dbPool := range dbPools {
+		if err := dbPool.Close(); err != nil {
+			logrus.Error("Failed to close connection pool where Organization Id = '" + organizationId + "': " + err.Error())
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func Stats(organizationId string) (sql.DBStats, error) {
+	dbPool, exists := lookupConnectionPool(organizationId)
+	if !exists {
+		return sql.DBStats{}, cErrors.New("No Connection Found where Organization Id = '" + organizationId + "'")
+	}
+	return dbPool.Stats(), nil
+}
+
+func (conDSN *DBConDSN) sameAs(other *DBConDSN) bool {
+	return other != nil &&
+		conDSN.OrganizationId == other.OrganizationId &&
+		conDSN.DatabaseServer == other.DatabaseServer &&
+		conDSN.DatabaseName == other.DatabaseName &&
+		conDSN.DatabaseHost == other.DatabaseHost &&
+		conDSN.UserName == other.UserName &&
+		conDSN.Password == other.Password &&
+		conDSN.ConnectionParameters == other.ConnectionParameters &&
+		conDSN.Port == other.Port &&
+		conDSN.MaxIdleConnections == other.MaxIdleConnections &&
+		conDSN.MaxOpenConnections == other.MaxOpenConnections &&
+		conDSN.ConnMaxLifetime == other.ConnMaxLifetime &&
+		conDSN.ConnMaxIdleTime == other.ConnMaxIdleTime &&
+		conDSN.QueryTimeout == other.QueryTimeout &&
+		conDSN.FullScanThreshold == other.FullScanThreshold &&
+		replicasSameAs(conDSN.Replicas, other.Replicas)
+}
+
 func (conDSN *DBConDSN) GetOrganizationId() string {
 	return conDSN.OrganizationId
 }
@@ -213,3 +350,27 @@ func (conDSN *DBConDSN) GetDatabaseServer() DbTypes {
 func (conDSN *DBConDSN) GetPort() int {
 	return conDSN.Port
 }
+
+func (conDSN *DBConDSN) GetMaxIdleConnections() int {
+	return conDSN.MaxIdleConnections
+}
+
+func (conDSN *DBConDSN) GetMaxOpenConnections() int {
+	return conDSN.MaxOpenConnections
+}
+
+func (conDSN *DBConDSN) GetConnMaxLifetime() time.Duration {
+	return conDSN.ConnMaxLifetime
+}
+
+func (conDSN *DBConDSN) GetConnMaxIdleTime() time.Duration {
+	return conDSN.ConnMaxIdleTime
+}
+
+func (conDSN *DBConDSN) GetQueryTimeout() time.Duration {
+	return conDSN.QueryTimeout
+}
+
+func (conDSN *DBConDSN) GetReplicas() []*DBConDSN {
+	return conDSN.Replicas
+}
@@ -127,7 +127,7 @@ func (list *CypressArrayList) PrintRecordsVerticallyLabelled() {
 				fmt.Println()
 			}
 			if listSize > 1 {
-				fmt.Println("--------------------------------------------------------\n")
+				fmt.Print("--------------------------------------------------------\n\n")
 			}
 		}
 
@@ -0,0 +1,875 @@
+package cypressutils
+
+import (
+	"fmt"
+	cErrors "github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type StatementType uint
+type ReturningStyle uint
+
+const (
+	STATEMENT_UNKNOWN StatementType = iota
+	STATEMENT_SELECT
+	STATEMENT_INSERT
+	STATEMENT_UPDATE
+	STATEMENT_DELETE
+)
+
+const (
+	RETURNING_NOT_SUPPORTED ReturningStyle = iota
+	RETURNING_AFTER_STATEMENT
+	RETURNING_OUTPUT_CLAUSE
+)
+
+// Dialect hides the SQL differences between the database servers listed in DbTypes
+type Dialect interface {
+	GetDatabaseServer() DbTypes
+
+	// Placeholder returns the positional bind parameter for the 1-based position
+	Placeholder(position int) string
+	// MaxParameters is the most bind parameters the server takes in one statement
+	MaxParameters() int
+	// QuoteIdentifier quotes every part of a table or column name. Unquoted parts are folded to the case the
+	// server gives unquoted names, so that quoting never changes which table or column is meant
+	QuoteIdentifier(identifier string) string
+	BooleanLiteral(value bool) string
+
+	// LimitOffset renders the pagination clause. Some servers can only paginate an ordered result set,
+	// hasOrderBy tells the dialect whether it has to supply an ORDER BY of its own
+	LimitOffset(numOfRecordsVariable, offsetVariable string, hasOrderBy bool) string
+	OnDuplicateKeyUpdate(conflictColumns, updateColumns []string) (string, error)
+	Upsert(upsert *UpsertStatement) (string, error)
+	BulkUpdate(bulkUpdate *BulkUpdateStatement) (string, error)
+	BatchInsert(batchInsert *BatchInsertStatement) (string, error)
+	// Savepoint and RollbackToSavepoint undo part of a transaction, the statements that failed in it.
+	// ReleaseSavepoint is empty on the servers that keep a savepoint until the transaction ends
+	Savepoint(name string) string
+	RollbackToSavepoint(name string) string
+	ReleaseSavepoint(name string) string
+
+	ReturningStyle() ReturningStyle
+	ReturningClause(statementType StatementType, columns string) string
+
+	// RowLock renders the lock of a SELECT either as a hint that follows the FROM table or as a clause that
+	// ends the statement
+	RowLock(rowLock *RowLock) (tableHint, clause string, err error)
+
+	// Explain gives the statements that capture the plan of query, ParsePlan reads it from the rows they return
+	Explain(query string, analyze bool) (*ExplainStatement, error)
+	ParsePlan(records *CypressArrayList) (*PlanNode, error)
+
+	// PrimaryKeyColumnsQuery lists the primary key columns of :schema_name.:table_name in :database_name.
+	// Every row must at least carry a column_name
+	PrimaryKeyColumnsQuery() string
+	IsAutoIncrementColumn(hashMap *CypressHashMap) bool
+}
+
+var dialectsMutex sync.RWMutex
+
+var dialects = map[DbTypes]Dialect{
+	PostgreSQL:   &PostgreSQLDialect{},
+	MySQL:        &MySQLDialect{},
+	MicrosoftSQL: &MicrosoftSQLDialect{},
+	Oracle:       &OracleDialect{},
+}
+
+// GetDialect defaults to PostgreSQL which is what the repository has always generated
+func GetDialect(databaseServer DbTypes) Dialect {
+	dialectsMutex.RLock()
+	defer dialectsMutex.RUnlock()
+
+	if dialect, exists := dialects[databaseServer]; exists {
+		return dialect
+	}
+	return dialects[PostgreSQL]
+}
+
+func GetOrganizationDialect(organizationId string) Dialect {
+	conDSN, exists := lookupConDSN(organizationId)
+	if !exists {
+		return GetDialect(PostgreSQL)
+	}
+	return GetDialect(conDSN.DatabaseServer)
+}
+
+func RegisterDialect(databaseServer DbTypes, dialect Dialect) {
+	dialectsMutex.Lock()
+	defer dialectsMutex.Unlock()
+	dialects[databaseServer] = dialect
+}
+
+/**************** POSTGRESQL ****************/
+
+type PostgreSQLDialect struct{}
+
+func (dialect *PostgreSQLDialect) GetDatabaseServer() DbTypes {
+	return PostgreSQL
+}
+
+func (dialect *PostgreSQLDialect) Placeholder(position int) string {
+	return "$" + strconv.Itoa(position)
+}
+
+func (dialect *PostgreSQLDialect) MaxParameters() int {
+	return 65535
+}
+
+func (dialect *PostgreSQLDialect) QuoteIdentifier(identifier string) string {
+	return quoteIdentifierParts(identifier, "\"", "\"", strings.ToLower)
+}
+
+func (dialect *PostgreSQLDialect) BooleanLiteral(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (dialect *PostgreSQLDialect) LimitOffset(numOfRecordsVariable, offsetVariable string, hasOrderBy bool) string {
+	return " LIMIT " + numOfRecordsVariable + " OFFSET " + offsetVariable + " "
+}
+
+func (dialect *PostgreSQLDialect) OnDuplicateKeyUpdate(conflictColumns, updateColumns []string) (string, error) {
+	if len(conflictColumns) == 0 {
+		return "", cErrors.New("INSERT: PostgreSQL requires the conflict columns for ON CONFLICT. Set them with AddPrimaryKeyColumns")
+	}
+
+	var buf strings.Builder
+	buf.WriteString(" ON CONFLICT ")
+	buf.WriteString(concatenateColumnNames(quoteIdentifiers(dialect, conflictColumns)))
+	buf.WriteString(" DO UPDATE SET ")
+	writeUpdateAssignments(&buf, dialect, updateColumns)
+	return buf.String(), nil
+}
+
+func (dialect *PostgreSQLDialect) Upsert(upsert *UpsertStatement) (string, error) {
+	if len(upsert.ConflictColumns) == 0 {
+		return "", cErrors.New("UPSERT: PostgreSQL requires the conflict columns for ON CONFLICT")
+	}
+
+	var buf strings.Builder
+	buf.WriteString("INSERT INTO " + upsert.TableName + " AS " + UPSERT_EXISTING_ROW + " ")
+	buf.WriteString(concatenateColumnNames(upsert.Columns))
+	buf.WriteString(" VALUES ")
+	writeValueRows(&buf, upsert.Rows)
+	buf.WriteString(" ON CONFLICT " + concatenateColumnNames(upsert.ConflictColumns))
+
+	if upsert.DoNothing {
+		buf.WriteString(" DO NOTHING")
+	} else {
+		buf.WriteString(" DO UPDATE SET ")
+		for index, column := range upsert.UpdateColumns {
+			if index > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(column + " = EXCLUDED." + column)
+		}
+		if upsert.UpdateCondition != "" {
+			buf.WriteString(" WHERE " + upsert.UpdateCondition)
+		}
+	}
+
+	if upsert.Returning != "" {
+		buf.WriteString(dialect.ReturningClause(STATEMENT_INSERT, upsert.Returning))
+	}
+	return buf.String(), nil
+}
+
+// BulkUpdate joins the rows to a VALUES list. The list follows an empty SELECT of the table in a UNION ALL so that
+// its placeholders take the types of the columns, alone they would be text and fail to compare with the keys
+func (dialect *PostgreSQLDialect) BulkUpdate(bulkUpdate *BulkUpdateStatement) (string, error) {
+	var buf strings.Builder
+	buf.WriteString("UPDATE " + bulkUpdate.TableName + " AS " + UPSERT_EXISTING_ROW + " SET ")
+	for index, column := range bulkUpdate.UpdateColumns {
+		if index > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(column + " = " + UPSERT_INCOMING_ROW + "." + column)
+	}
+	buf.WriteString(" FROM (SELECT " + strings.Join(bulkUpdate.Columns, ", ") + " FROM " + bulkUpdate.TableName + " WHERE 1 = 0")
+	buf.WriteString(" UNION ALL VALUES ")
+	writeValueRows(&buf, bulkUpdate.Rows)
+	buf.WriteString(") AS " + UPSERT_INCOMING_ROW)
+	buf.WriteString(" WHERE " + mergeOnCondition(bulkUpdate.KeyColumns))
+	return buf.String(), nil
+}
+
+func (dialect *PostgreSQLDialect) BatchInsert(batchInsert *BatchInsertStatement) (string, error) {
+	var buf strings.Builder
+	writeInsertInto(&buf, batchInsert)
+	buf.WriteString(" VALUES ")
+	writeValueRows(&buf, batchInsert.Rows)
+	if batchInsert.Returning != "" {
+		buf.WriteString(dialect.ReturningClause(STATEMENT_INSERT, batchInsert.Returning))
+	}
+	return buf.String(), nil
+}
+
+func (dialect *PostgreSQLDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+
+func (dialect *PostgreSQLDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func (dialect *PostgreSQLDialect) ReleaseSavepoint(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+func (dialect *PostgreSQLDialect) ReturningStyle() ReturningStyle {
+	return RETURNING_AFTER_STATEMENT
+}
+
+func (dialect *PostgreSQLDialect) ReturningClause(statementType StatementType, columns string) string {
+	return " RETURNING " + columns
+}
+
+func (dialect *PostgreSQLDialect) RowLock(rowLock *RowLock) (tableHint, clause string, err error) {
+	return "", lockClause(rowLock), nil
+}
+
+func (dialect *PostgreSQLDialect) Explain(query string, analyze bool) (*ExplainStatement, error) {
+	if analyze {
+		return &ExplainStatement{Explain: "EXPLAIN (ANALYZE, FORMAT JSON) " + query}, nil
+	}
+	return &ExplainStatement{Explain: "EXPLAIN (FORMAT JSON) " + query}, nil
+}
+
+func (dialect *PostgreSQLDialect) ParsePlan(records *CypressArrayList) (*PlanNode, error) {
+	return parsePostgreSQLPlan(records)
+}
+
+func (dialect *PostgreSQLDialect) PrimaryKeyColumnsQuery() string {
+	return "SELECT kcu.column_name AS column_name, c.column_default, c.is_identity\n" +
+		"   FROM information_schema.table_constraints tco\n" +
+		"         JOIN information_schema.key_column_usage kcu\n" +
+		"              ON kcu.constraint_name = tco.constraint_name\n" +
+		"                  AND kcu.constraint_schema = tco.constraint_schema\n" +
+		"         JOIN information_schema.columns c\n" +
+		"              ON kcu.column_name = c.column_name\n" +
+		"                  AND kcu.table_schema = c.table_schema\n" +
+		"                  AND kcu.table_name = c.table_name\n" +
+		"\n" +
+		"   WHERE tco.constraint_type = 'PRIMARY KEY'\n" +
+		"       AND kcu.table_catalog = :database_name\n" +
+		"       AND kcu.table_schema = :schema_name\n" +
+		"       AND kcu.table_name = :table_name"
+}
+
+func (dialect *PostgreSQLDialect) IsAutoIncrementColumn(hashMap *CypressHashMap) bool {
+	return strings.Contains(hashMap.GetStringValueOrIfNull("column_default", ""), "nextval") ||
+		strings.EqualFold(hashMap.GetStringValueOrIfNull("is_identity", ""), "YES")
+}
+
+/**************** MYSQL ****************/
+
+type MySQLDialect struct{}
+
+func (dialect *MySQLDialect) GetDatabaseServer() DbTypes {
+	return MySQL
+}
+
+func (dialect *MySQLDialect) Placeholder(position int) string {
+	return "?"
+}
+
+func (dialect *MySQLDialect) MaxParameters() int {
+	return 65535
+}
+
+func (dialect *MySQLDialect) QuoteIdentifier(identifier string) string {
+	return quoteIdentifierParts(identifier, "`", "`", nil)
+}
+
+func (dialect *MySQLDialect) BooleanLiteral(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (dialect *MySQLDialect) LimitOffset(numOfRecordsVariable, offsetVariable string, hasOrderBy bool) string {
+	return " LIMIT " + numOfRecordsVariable + " OFFSET " + offsetVariable + " "
+}
+
+func (dialect *MySQLDialect) OnDuplicateKeyUpdate(conflictColumns, updateColumns []string) (string, error) {
+	var buf strings.Builder
+	buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+	writeUpdateAssignments(&buf, dialect, updateColumns)
+	return buf.String(), nil
+}
+
+func (dialect *MySQLDialect) Upsert(upsert *UpsertStatement) (string, error) {
+	if upsert.Returning != "" {
+		return "", cErrors.New("RETURNING STATEMENT: not supported by " + string(MySQL))
+	}
+
+	var buf strings.Builder
+	buf.WriteString("INSERT INTO " + upsert.TableName + " ")
+	buf.WriteString(concatenateColumnNames(upsert.Columns))
+	buf.WriteString(" VALUES ")
+	writeValueRows(&buf, upsert.Rows)
+	buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+
+	//WITHOUT AN UPDATE A DUPLICATE WOULD FAIL, SETTING A COLUMN TO ITSELF LEAVES THE ROW AS IT IS
+	if upsert.DoNothing {
+		buf.WriteString(upsert.Columns[0] + " = " + upsert.Columns[0])
+		return buf.String(), nil
+	}
+
+	condition := ""
+	if upsert.UpdateCondition != "" {
+		condition = existingRowRegex.ReplaceAllString(upsert.UpdateCondition, upsert.TableName+".$1")
+		condition = incomingRowRegex.ReplaceAllString(condition, "VALUES($1)")
+	}
+
+	for index, column := range conditionColumnsLast(upsert.UpdateColumns, upsert.UpdateCondition) {
+		if index > 0 {
+			buf.WriteString(", ")
+		}
+		if condition == "" {
+			buf.WriteString(column + " = VALUES(" + column + ")")
+		} else {
+			buf.WriteString(column + " = IF(" + condition + ", VALUES(" + column + "), " + column + ")")
+		}
+	}
+	return buf.String(), nil
+}
+
+func (dialect *MySQLDialect) BulkUpdate(bulkUpdate *BulkUpdateStatement) (string, error) {
+	var buf strings.Builder
+	buf.WriteString("UPDATE " + bulkUpdate.TableName + " AS " + UPSERT_EXISTING_ROW + " INNER JOIN (")
+	writeSelectRows(&buf, bulkUpdate.Columns, bulkUpdate.Rows, "")
+	buf.WriteString(") AS " + UPSERT_INCOMING_ROW + " ON " + mergeOnCondition(bulkUpdate.KeyColumns))
+	buf.WriteString(" SET " + mergeUpdateAssignments(bulkUpdate.UpdateColumns))
+	return buf.String(), nil
+}
+
+func (dialect *MySQLDialect) BatchInsert(batchInsert *BatchInsertStatement) (string, error) {
+	if batchInsert.Returning != "" {
+		return "", cErrors.New("RETURNING STATEMENT: not supported by " + string(MySQL))
+	}
+
+	var buf strings.Builder
+	writeInsertInto(&buf, batchInsert)
+	buf.WriteString(" VALUES ")
+	writeValueRows(&buf, batchInsert.Rows)
+	return buf.String(), nil
+}
+
+func (dialect *MySQLDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+
+func (dialect *MySQLDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func (dialect *MySQLDialect) ReleaseSavepoint(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+func (dialect *MySQLDialect) ReturningStyle() ReturningStyle {
+	return RETURNING_NOT_SUPPORTED
+}
+
+func (dialect *MySQLDialect) ReturningClause(statementType StatementType, columns string) string {
+	return ""
+}
+
+func (dialect *MySQLDialect) RowLock(rowLock *RowLock) (tableHint, clause string, err error) {
+	return "", lockClause(rowLock), nil
+}
+
+func (dialect *MySQLDialect) Explain(query string, analyze bool) (*ExplainStatement, error) {
+	if analyze {
+		return nil, cErrors.New("EXPLAIN: " + string(MySQL) + " only gives an analyzed plan as text, explain without analyze")
+	}
+	return &ExplainStatement{Explain: "EXPLAIN FORMAT=JSON " + query}, nil
+}
+
+func (dialect *MySQLDialect) ParsePlan(records *CypressArrayList) (*PlanNode, error) {
+	return parseMySQLPlan(records)
+}
+
+func (dialect *MySQLDialect) PrimaryKeyColumnsQuery() string {
+	//IN MYSQL THE SCHEMA IS THE DATABASE
+	return "SELECT kcu.COLUMN_NAME AS column_name, c.COLUMN_DEFAULT AS column_default, c.EXTRA AS column_extra\n" +
+		"   FROM information_schema.KEY_COLUMN_USAGE kcu\n" +
+		"         JOIN information_schema.COLUMNS c\n" +
+		"              ON kcu.COLUMN_NAME = c.COLUMN_NAME\n" +
+		"                  AND kcu.TABLE_SCHEMA = c.TABLE_SCHEMA\n" +
+		"                  AND kcu.TABLE_NAME = c.TABLE_NAME\n" +
+		"\n" +
+		"   WHERE kcu.CONSTRAINT_NAME = 'PRIMARY'\n" +
+		"       AND kcu.TABLE_SCHEMA = :schema_name\n" +
+		"       AND kcu.TABLE_NAME = :table_name\n" +
+		"   ORDER BY kcu.ORDINAL_POSITION"
+}
+
+func (dialect *MySQLDialect) IsAutoIncrementColumn(hashMap *CypressHashMap) bool {
+	return strings.Contains(strings.ToLower(hashMap.GetStringValueOrIfNull("column_extra", "")), "auto_increment")
+}
+
+/**************** MICROSOFT SQL SERVER ****************/
+
+type MicrosoftSQLDialect struct{}
+
+func (dialect *MicrosoftSQLDialect) GetDatabaseServer() DbTypes {
+	return MicrosoftSQL
+}
+
+func (dialect *MicrosoftSQLDialect) Placeholder(position int) string {
+	return "@p" + strconv.Itoa(position)
+}
+
+func (dialect *MicrosoftSQLDialect) MaxParameters() int {
+	return 2100
+}
+
+func (dialect *MicrosoftSQLDialect) QuoteIdentifier(identifier string) string {
+	return quoteIdentifierParts(identifier, "[", "]", nil)
+}
+
+func (dialect *MicrosoftSQLDialect) BooleanLiteral(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func (dialect *MicrosoftSQLDialect) LimitOffset(numOfRecordsVariable, offsetVariable string, hasOrderBy bool) string {
+	orderBy := ""
+	if !hasOrderBy {
+		orderBy = " ORDER BY (SELECT NULL)"
+	}
+	return orderBy + " OFFSET " + offsetVariable + " ROWS FETCH NEXT " + numOfRecordsVariable + " ROWS ONLY "
+}
+
+func (dialect *MicrosoftSQLDialect) OnDuplicateKeyUpdate(conflictColumns, updateColumns []string) (string, error) {
+	return "", cErrors.New("INSERT: SQL Server has no ON DUPLICATE KEY clause, a MERGE statement is required")
+}
+
+func (dialect *MicrosoftSQLDialect) Upsert(upsert *UpsertStatement) (string, error) {
+	if len(upsert.ConflictColumns) == 0 {
+		return "", cErrors.New("UPSERT: SQL Server requires the conflict columns to MERGE on")
+	}
+
+	//HOLDLOCK KEEPS A CONCURRENT MERGE FROM INSERTING THE SAME KEY IN BETWEEN
+	var buf strings.Builder
+	buf.WriteString("MERGE INTO " + upsert.TableName + " WITH (HOLDLOCK) AS " + UPSERT_EXISTING_ROW)
+	buf.WriteString(" USING (VALUES ")
+	writeValueRows(&buf, upsert.Rows)
+	buf.WriteString(") AS " + UPSERT_INCOMING_ROW + " (" + strings.Join(upsert.Columns, ", ") + ")")
+	buf.WriteString(" ON " + mergeOnCondition(upsert.ConflictColumns))
+
+	if !upsert.DoNothing {
+		buf.WriteString(" WHEN MATCHED")
+		if upsert.UpdateCondition != "" {
+			buf.WriteString(" AND (" + upsert.UpdateCondition + ")")
+		}
+		buf.WriteString(" THEN UPDATE SET " + mergeUpdateAssignments(upsert.UpdateColumns))
+	}
+
+	buf.WriteString(" WHEN NOT MATCHED THEN INSERT " + concatenateColumnNames(upsert.Columns))
+	buf.WriteString(" VALUES " + mergeInsertValues(upsert.Columns))
+
+	if upsert.Returning != "" {
+		buf.WriteString(strings.TrimRight(dialect.ReturningClause(STATEMENT_INSERT, upsert.Returning), " "))
+	}
+	buf.WriteString(";")
+	return buf.String(), nil
+}
+
+func (dialect *MicrosoftSQLDialect) BulkUpdate(bulkUpdate *BulkUpdateStatement) (string, error) {
+	var buf strings.Builder
+	buf.WriteString("UPDATE " + UPSERT_EXISTING_ROW + " SET " + mergeUpdateAssignments(bulkUpdate.UpdateColumns))
+	buf.WriteString(" FROM " + bulkUpdate.TableName + " AS " + UPSERT_EXISTING_ROW + " INNER JOIN (VALUES ")
+	writeValueRows(&buf, bulkUpdate.Rows)
+	buf.WriteString(") AS " + UPSERT_INCOMING_ROW + " (" + strings.Join(bulkUpdate.Columns, ", ") + ")")
+	buf.WriteString(" ON " + mergeOnCondition(bulkUpdate.KeyColumns))
+	return buf.String(), nil
+}
+
+// BatchInsert selects the rows from a VALUES table, an INSERT takes no more than 1000 rows in its own VALUES
+func (dialect *MicrosoftSQLDialect) BatchInsert(batchInsert *BatchInsertStatement) (string, error) {
+	columns := strings.Join(batchInsert.Columns, ", ")
+
+	var buf strings.Builder
+	writeInsertInto(&buf, batchInsert)
+	if batchInsert.Returning != "" {
+		buf.WriteString(strings.TrimRight(dialect.ReturningClause(STATEMENT_INSERT, batchInsert.Returning), " "))
+	}
+	buf.WriteString(" SELECT " + columns + " FROM (VALUES ")
+	writeValueRows(&buf, batchInsert.Rows)
+	buf.WriteString(") AS " + UPSERT_INCOMING_ROW + " (" + columns + ")")
+	return buf.String(), nil
+}
+
+func (dialect *MicrosoftSQLDialect) Savepoint(name string) string {
+	return "SAVE TRANSACTION " + name
+}
+
+func (dialect *MicrosoftSQLDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TRANSACTION " + name
+}
+
+func (dialect *MicrosoftSQLDialect) ReleaseSavepoint(name string) string {
+	//SQL SERVER HAS NO RELEASE, A SAVE TRANSACTION LASTS UNTIL THE COMMIT OR THE ROLLBACK
+	return ""
+}
+
+func (dialect *MicrosoftSQLDialect) ReturningStyle() ReturningStyle {
+	return RETURNING_OUTPUT_CLAUSE
+}
+
+func (dialect *MicrosoftSQLDialect) ReturningClause(statementType StatementType, columns string) string {
+	pseudoTable := "INSERTED."
+	if statementType == STATEMENT_DELETE {
+		pseudoTable = "DELETED."
+	}
+
+	var buf strings.Builder
+	buf.WriteString(" OUTPUT ")
+
+	for index, column := range strings.Split(columns, ",") {
+		if index > 0 {
+			buf.WriteString(", ")
+		}
+
+		column = strings.TrimSpace(column)
+		if strings.HasPrefix(strings.ToUpper(column), "INSERTED.") || strings.HasPrefix(strings.ToUpper(column), "DELETED.") {
+			buf.WriteString(column)
+		} else {
+			buf.WriteString(pseudoTable + column)
+		}
+	}
+	buf.WriteString(" ")
+	return buf.String()
+}
+
+// RowLock holds the locks with table hints, SQL Server has no FOR UPDATE on a SELECT
+func (dialect *MicrosoftSQLDialect) RowLock(rowLock *RowLock) (tableHint, clause string, err error) {
+	hints := []string{"UPDLOCK"}
+	if rowLock.Mode == LOCK_FOR_SHARE {
+		if rowLock.Wait == LOCK_SKIP_LOCKED {
+			return "", "", cErrors.New("LOCK: SQL Server cannot skip locked rows of a FOR SHARE, READPAST does not go with HOLDLOCK")
+		}
+		hints = []string{"HOLDLOCK"}
+	}
+
+	switch rowLock.Wait {
+	case LOCK_NOWAIT:
+		hints = append(hints, "NOWAIT")
+	case LOCK_SKIP_LOCKED:
+		hints = append(hints, "READPAST")
+	}
+	return "WITH (" + strings.Join(hints, ", ") + ")", "", nil
+}
+
+// Explain turns on the XML showplan of the session, SQL Server has no EXPLAIN statement. The SET must be alone
+// in its batch
+func (dialect *MicrosoftSQLDialect) Explain(query string, analyze bool) (*ExplainStatement, error) {
+	if analyze {
+		return &ExplainStatement{Setup: []string{"SET STATISTICS XML ON"}, Explain: query, Teardown: []string{"SET STATISTICS XML OFF"}}, nil
+	}
+	return &ExplainStatement{Setup: []string{"SET SHOWPLAN_XML ON"}, Explain: query, Teardown: []string{"SET SHOWPLAN_XML OFF"}}, nil
+}
+
+func (dialect *MicrosoftSQLDialect) ParsePlan(records *CypressArrayList) (*PlanNode, error) {
+	return parseMicrosoftSQLPlan(records)
+}
+
+func (dialect *MicrosoftSQLDialect) PrimaryKeyColumnsQuery() string {
+	return "SELECT kcu.COLUMN_NAME AS column_name, c.COLUMN_DEFAULT AS column_default,\n" +
+		"       COLUMNPROPERTY(OBJECT_ID(kcu.TABLE_SCHEMA + '.' + kcu.TABLE_NAME), kcu.COLUMN_NAME, 'IsIdentity') AS is_identity\n" +
+		"   FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tco\n" +
+		"         JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu\n" +
+		"              ON kcu.CONSTRAINT_NAME = tco.CONSTRAINT_NAME\n" +
+		"                  AND kcu.CONSTRAINT_SCHEMA = tco.CONSTRAINT_SCHEMA\n" +
+		"         JOIN INFORMATION_SCHEMA.COLUMNS c\n" +
+		"              ON kcu.COLUMN_NAME = c.COLUMN_NAME\n" +
+		"                  AND kcu.TABLE_SCHEMA = c.TABLE_SCHEMA\n" +
+		"                  AND kcu.TABLE_NAME = c.TABLE_NAME\n" +
+		"\n" +
+		"   WHERE tco.CONSTRAINT_TYPE = 'PRIMARY KEY'\n" +
+		"       AND kcu.TABLE_CATALOG = :database_name\n" +
+		"       AND kcu.TABLE_SCHEMA = :schema_name\n" +
+		"       AND kcu.TABLE_NAME = :table_name"
+}
+
+func (dialect *MicrosoftSQLDialect) IsAutoIncrementColumn(hashMap *CypressHashMap) bool {
+	return hashMap.GetStringValueOrIfNull("is_identity", "0") == "1"
+}
+
+/**************** ORACLE ****************/
+
+type OracleDialect struct{}
+
+func (dialect *OracleDialect) GetDatabaseServer() DbTypes {
+	return Oracle
+}
+
+func (dialect *OracleDialect) Placeholder(position int) string {
+	return ":" + strconv.Itoa(position)
+}
+
+func (dialect *OracleDialect) MaxParameters() int {
+	return 65535
+}
+
+func (dialect *OracleDialect) QuoteIdentifier(identifier string) string {
+	return quoteIdentifierParts(identifier, "\"", "\"", strings.ToUpper)
+}
+
+func (dialect *OracleDialect) BooleanLiteral(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+func (dialect *OracleDialect) LimitOffset(numOfRecordsVariable, offsetVariable string, hasOrderBy bool) string {
+	return " OFFSET " + offsetVariable + " ROWS FETCH NEXT " + numOfRecordsVariable + " ROWS ONLY "
+}
+
+func (dialect *OracleDialect) OnDuplicateKeyUpdate(conflictColumns, updateColumns []string) (string, error) {
+	return "", cErrors.New("INSERT: Oracle has no ON DUPLICATE KEY clause, a MERGE statement is required")
+}
+
+func (dialect *OracleDialect) Upsert(upsert *UpsertStatement) (string, error) {
+	if len(upsert.ConflictColumns) == 0 {
+		return "", cErrors.New("UPSERT: Oracle requires the conflict columns to MERGE on")
+	}
+	if upsert.Returning != "" {
+		return "", cErrors.New("RETURNING STATEMENT: not supported by " + string(Oracle))
+	}
+
+	var buf strings.Builder
+	buf.WriteString("MERGE INTO " + upsert.TableName + " " + UPSERT_EXISTING_ROW + " USING (")
+	writeSelectRows(&buf, upsert.Columns, upsert.Rows, " FROM dual")
+	buf.WriteString(") " + UPSERT_INCOMING_ROW)
+	buf.WriteString(" ON (" + mergeOnCondition(upsert.ConflictColumns) + ")")
+
+	if !upsert.DoNothing {
+		for _, column := range upsert.UpdateColumns {
+			if containsFold(upsert.ConflictColumns, column) {
+				return "", cErrors.New("UPSERT: Oracle cannot update " + column + ", it is a conflict column")
+			}
+		}
+
+		buf.WriteString(" WHEN MATCHED THEN UPDATE SET " + mergeUpdateAssignments(upsert.UpdateColumns))
+		if upsert.UpdateCondition != "" {
+			buf.WriteString(" WHERE " + upsert.UpdateCondition)
+		}
+	}
+
+	buf.WriteString(" WHEN NOT MATCHED THEN INSERT " + concatenateColumnNames(upsert.Columns))
+	buf.WriteString(" VALUES " + mergeInsertValues(upsert.Columns))
+	return buf.String(), nil
+}
+
+// BulkUpdate is a MERGE that only updates, Oracle has no UPDATE with a join
+func (dialect *OracleDialect) BulkUpdate(bulkUpdate *BulkUpdateStatement) (string, error) {
+	var buf strings.Builder
+	buf.WriteString("MERGE INTO " + bulkUpdate.TableName + " " + UPSERT_EXISTING_ROW + " USING (")
+	writeSelectRows(&buf, bulkUpdate.Columns, bulkUpdate.Rows, " FROM dual")
+	buf.WriteString(") " + UPSERT_INCOMING_ROW)
+	buf.WriteString(" ON (" + mergeOnCondition(bulkUpdate.KeyColumns) + ")")
+	buf.WriteString(" WHEN MATCHED THEN UPDATE SET " + mergeUpdateAssignments(bulkUpdate.UpdateColumns))
+	return buf.String(), nil
+}
+
+// BatchInsert selects the rows from dual, Oracle has no multi-row VALUES
+func (dialect *OracleDialect) BatchInsert(batchInsert *BatchInsertStatement) (string, error) {
+	if batchInsert.Returning != "" {
+		return "", cErrors.New("RETURNING STATEMENT: not supported by " + string(Oracle))
+	}
+
+	var buf strings.Builder
+	writeInsertInto(&buf, batchInsert)
+	buf.WriteString(" ")
+	writeSelectRows(&buf, batchInsert.Columns, batchInsert.Rows, " FROM dual")
+	return buf.String(), nil
+}
+
+func (dialect *OracleDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+
+func (dialect *OracleDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func (dialect *OracleDialect) ReleaseSavepoint(name string) string {
+	//ORACLE HAS NO RELEASE SAVEPOINT, THE SAVEPOINTS GO WITH THE COMMIT OR THE ROLLBACK
+	return ""
+}
+
+func (dialect *OracleDialect) ReturningStyle() ReturningStyle {
+	//ORACLE ONLY RETURNS INTO OUT BINDS WHICH THE EXECUTORS DO NOT USE
+	return RETURNING_NOT_SUPPORTED
+}
+
+func (dialect *OracleDialect) ReturningClause(statementType StatementType, columns string) string {
+	return ""
+}
+
+func (dialect *OracleDialect) RowLock(rowLock *RowLock) (tableHint, clause string, err error) {
+	if rowLock.Mode == LOCK_FOR_SHARE {
+		return "", "", cErrors.New("LOCK: Oracle has no FOR SHARE, rows are only locked FOR UPDATE")
+	}
+	return "", lockClause(rowLock), nil
+}
+
+// Explain writes the plan to plan_table, from where it is read back
+func (dialect *OracleDialect) Explain(query string, analyze bool) (*ExplainStatement, error) {
+	if analyze {
+		return nil, cErrors.New("EXPLAIN: " + string(Oracle) + " plans cannot be analyzed here, explain without analyze")
+	}
+	return &ExplainStatement{
+		Explain: "EXPLAIN PLAN SET STATEMENT_ID = '" + oracleExplainStatementId + "' FOR " + query,
+		Plan: "SELECT id AS \"id\", parent_id AS \"parent_id\", operation AS \"operation\", options AS \"options\", " +
+			"object_name AS \"object_name\", cost AS \"cost\", cardinality AS \"cardinality\" FROM plan_table " +
+			"WHERE statement_id = '" + oracleExplainStatementId + "' ORDER BY id",
+	}, nil
+}
+
+func (dialect *OracleDialect) ParsePlan(records *CypressArrayList) (*PlanNode, error) {
+	return parseOraclePlan(records)
+}
+
+func (dialect *OracleDialect) PrimaryKeyColumnsQuery() string {
+	return "SELECT cols.column_name AS \"column_name\", tc.data_default AS \"column_default\", tc.identity_column AS \"is_identity\"\n" +
+		"   FROM all_constraints cons\n" +
+		"         JOIN all_cons_columns cols\n" +
+		"              ON cons.constraint_name = cols.constraint_name\n" +
+		"                  AND cons.owner = cols.owner\n" +
+		"         JOIN all_tab_columns tc\n" +
+		"              ON tc.owner = cols.owner\n" +
+		"                  AND tc.table_name = cols.table_name\n" +
+		"                  AND tc.column_name = cols.column_name\n" +
+		"\n" +
+		"   WHERE cons.constraint_type = 'P'\n" +
+		"       AND cons.owner = UPPER(:schema_name)\n" +
+		"       AND cols.table_name = UPPER(:table_name)"
+}
+
+func (dialect *OracleDialect) IsAutoIncrementColumn(hashMap *CypressHashMap) bool {
+	return strings.EqualFold(hashMap.GetStringValueOrIfNull("is_identity", ""), "YES") ||
+		strings.Contains(strings.ToUpper(hashMap.GetStringValueOrIfNull("column_default", "")), "NEXTVAL")
+}
+
+/**************** UTILITY FUNCTIONS ****************/
+
+// quoteIdentifierParts re-quotes the parts that are already quoted, for any dialect, as they are and folds the
+// others with fold, nil for the servers whose unquoted names keep their case. Anything that is not an
+// identifier is quoted as a whole
+func quoteIdentifierParts(identifier, openQuote, closeQuote string, fold func(string) string) string {
+	quote := func(part string) string {
+		return openQuote + strings.ReplaceAll(part, closeQuote, closeQuote+closeQuote) + closeQuote
+	}
+
+	parts := identifierPartRegex.FindAllString(identifier, -1)
+	if strings.Join(parts, ".") != identifier {
+		return quote(identifier)
+	}
+
+	for index, part := range parts {
+		switch {
+		case part == "*":
+		case strings.ContainsAny(part[:1], "\"[`"):
+			parts[index] = quote(part[1 : len(part)-1])
+		case fold != nil:
+			parts[index] = quote(fold(part))
+		default:
+			parts[index] = quote(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func writeValueRows(buf *strings.Builder, rows [][]string) {
+	for index, row := range rows {
+		if index > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(concatenateColumnNames(row))
+	}
+}
+
+func writeInsertInto(buf *strings.Builder, batchInsert *BatchInsertStatement) {
+	buf.WriteString("INSERT INTO " + batchInsert.TableName + " (" + strings.Join(batchInsert.Columns, ", ") + ")")
+}
+
+// writeSelectRows writes the rows as SELECTs joined by UNION ALL, for the servers without a VALUES table
+func writeSelectRows(buf *strings.Builder, columns []string, rows [][]string, from string) {
+	for rowIndex, row := range rows {
+		if rowIndex > 0 {
+			buf.WriteString(" UNION ALL ")
+		}
+		buf.WriteString("SELECT ")
+		for index, value := range row {
+			if index > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(value + " AS " + columns[index])
+		}
+		buf.WriteString(from)
+	}
+}
+
+func mergeOnCondition(conflictColumns []string) string {
+	conditions := make([]string, len(conflictColumns))
+	for index, column := range conflictColumns {
+		conditions[index] = UPSERT_EXISTING_ROW + "." + column + " = " + UPSERT_INCOMING_ROW + "." + column
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+func mergeUpdateAssignments(updateColumns []string) string {
+	assignments := make([]string, len(updateColumns))
+	for index, column := range updateColumns {
+		assignments[index] = UPSERT_EXISTING_ROW + "." + column + " = " + UPSERT_INCOMING_ROW + "." + column
+	}
+	return strings.Join(assignments, ", ")
+}
+
+func mergeInsertValues(columns []string) string {
+	values := make([]string, len(columns))
+	for index, column := range columns {
+		values[index] = UPSERT_INCOMING_ROW + "." + column
+	}
+	return "(" + strings.Join(values, ", ") + ")"
+}
+
+// lockClause is the FOR UPDATE or FOR SHARE clause of the dialects that end the statement with it
+func lockClause(rowLock *RowLock) string {
+	clause := "FOR UPDATE"
+	if rowLock.Mode == LOCK_FOR_SHARE {
+		clause = "FOR SHARE"
+	}
+
+	switch rowLock.Wait {
+	case LOCK_NOWAIT:
+		clause += " NOWAIT"
+	case LOCK_SKIP_LOCKED:
+		clause += " SKIP LOCKED"
+	}
+	return clause
+}
+
+// writeUpdateAssignments sets every column to the named variable of the same name
+func writeUpdateAssignments(buf *strings.Builder, dialect Dialect, columns []string) {
+	for index, column := range columns {
+		if index > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(fmt.Sprintf("%s = :%s", quoteIdentifier(dialect, column), column))
+	}
+}
@@ -0,0 +1,71 @@
+package cypressutils_test
+
+import (
+	"testing"
+
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+func TestDialectsRenderThePagedSelect(t *testing.T) {
+	tests := []struct {
+		databaseServer cypressutils.DbTypes
+		want           string
+	}{
+		{cypressutils.PostgreSQL, `SELECT "id", "name" FROM "people" WHERE status = $1 ORDER BY "name" LIMIT $2 OFFSET $3`},
+		{cypressutils.MySQL, "SELECT `id`, `name` FROM `people` WHERE status = ? ORDER BY `name` LIMIT ? OFFSET ?"},
+		{cypressutils.MicrosoftSQL, "SELECT [id], [name] FROM [people] WHERE status = @p1 ORDER BY [name] OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY"},
+		{cypressutils.Oracle, `SELECT "ID", "NAME" FROM "PEOPLE" WHERE status = :1 ORDER BY "NAME" OFFSET :2 ROWS FETCH NEXT :3 ROWS ONLY`},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.databaseServer), func(t *testing.T) {
+			organizationId, fakeDB := registerFakeOrganization(t, test.databaseServer)
+			fakeDB.Expect("COUNT(*)").WithColumns("count").AddRow(4)
+
+			queryArguments := cypressutils.NewMap()
+			queryArguments.PutValue(":status", "active")
+
+			twrapper := cypressutils.SelectWhereOrderBy(organizationId, "people", "id, name", cypressutils.NewFilterPredicate("status = :status"),
+				"name", queryArguments, []int{2, 2})
+			if twrapper.HasErrors {
+				t.Fatalf("SelectWhereOrderBy failed: %s", twrapper.GetErrors())
+			}
+
+			page := fakeDB.GetStatements()[0]
+			if page.Query != test.want {
+				t.Errorf("query = %s, want %s", page.Query, test.want)
+			}
+			if len(page.Args) != 3 || page.Args[0] != "active" || page.Args[1] != int64(2) || page.Args[2] != int64(2) {
+				t.Errorf("args = %v, want the status, 2 records and an offset of 2", page.Args)
+			}
+		})
+	}
+}
+
+func TestDialectsRenderTheInsertReturning(t *testing.T) {
+	tests := []struct {
+		databaseServer cypressutils.DbTypes
+		want           string
+	}{
+		{cypressutils.PostgreSQL, `INSERT INTO "people" ("name") VALUES ($1) RETURNING *`},
+		{cypressutils.MySQL, "INSERT INTO `people` (`name`) VALUES (?)"},
+		{cypressutils.MicrosoftSQL, "INSERT INTO [people] ([name]) OUTPUT INSERTED.* VALUES (@p1)"},
+		{cypressutils.Oracle, `INSERT INTO "PEOPLE" ("NAME") VALUES (:1)`},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.databaseServer), func(t *testing.T) {
+			organizationId, fakeDB := registerFakeOrganization(t, test.databaseServer)
+
+			record := cypressutils.NewMap()
+			record.PutValue("name", "Jane")
+
+			if twrapper := cypressutils.Insert(organizationId, "people", record); twrapper.HasErrors {
+				t.Fatalf("Insert failed: %s", twrapper.GetErrors())
+			}
+			if query := fakeDB.GetStatements()[0].Query; query != test.want {
+				t.Errorf("query = %s, want %s", query, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package cypressutils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	cErrors "github.com/pkg/errors"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SSL_MODE_DISABLE     = "disable"
+	SSL_MODE_REQUIRE     = "require"
+	SSL_MODE_VERIFY_CA   = "verify-ca"
+	SSL_MODE_VERIFY_FULL = "verify-full"
+
+	DSN_PASSWORD_MASK = "********"
+)
+
+// ConnectionParameters is DBConDSN.ConnectionParameters parsed. The string is a list of key=value pairs separated
+// by & or ; with URL escaped values, e.g. "sslmode=verify-full&sslrootcert=ca.pem&application_name=portal".
+// sslmode, sslrootcert, sslcert, sslkey, application_name and connect_timeout (seconds) mean the same thing on
+// every database server, any other key is handed to the driver as it is
+type ConnectionParameters struct {
+	SSLMode         string
+	SSLRootCert     string
+	SSLCert         string
+	SSLKey          string
+	ApplicationName string
+	ConnectTimeout  time.Duration
+	Extra           map[string]string
+}
+
+// DSNBuilder renders a DBConDSN in the data source name format of its driver. A masked DSN carries
+// DSN_PASSWORD_MASK instead of the password and is only meant for logging
+type DSNBuilder interface {
+	BuildDSN(conDSN *DBConDSN, parameters *ConnectionParameters, masked bool) (string, error)
+}
+
+var dsnBuilders = map[DbTypes]DSNBuilder{
+	PostgreSQL:   &PostgreSQLDSNBuilder{},
+	MySQL:        &MySQLDSNBuilder{},
+	MicrosoftSQL: &MicrosoftSQLDSNBuilder{},
+	Oracle:       &OracleDSNBuilder{},
+}
+
+func GetDSNBuilder(databaseServer DbTypes) (DSNBuilder, error) {
+	dsnBuilder, exists := dsnBuilders[databaseServer]
+	if !exists {
+		return nil, cErrors.New(fmt.Sprintf("DSN BUILDER: No DSN builder for database server '%s'", databaseServer))
+	}
+	return dsnBuilder, nil
+}
+
+func RegisterDSNBuilder(databaseServer DbTypes, dsnBuilder DSNBuilder) {
+	dsnBuilders[databaseServer] = dsnBuilder
+}
+
+func BuildDSN(conDSN *DBConDSN) (string, error) {
+	return buildDSN(conDSN, false)
+}
+
+// MaskedDSN is the DSN safe to print. It is empty when the DSN cannot be built
+func MaskedDSN(conDSN *DBConDSN) string {
+	dsn, err := buildDSN(conDSN, true)
+	if err != nil {
+		return ""
+	}
+	return dsn
+}
+
+func buildDSN(conDSN *DBConDSN, masked bool) (string, error) {
+	dsnBuilder, err := GetDSNBuilder(conDSN.DatabaseServer)
+	if err != nil {
+		return "", err
+	}
+
+	parameters, err := ParseConnectionParameters(conDSN.ConnectionParameters)
+	if err != nil {
+		return "", err
+	}
+
+	return dsnBuilder.BuildDSN(conDSN, parameters, masked)
+}
+
+func ParseConnectionParameters(connectionParameters string) (*ConnectionParameters, error) {
+	parameters := &ConnectionParameters{Extra: make(map[string]string)}
+
+	pairs := strings.FieldsFunc(connectionParameters, func(r rune) bool {
+		return r == '&' || r == ';'
+	})
+
+	for _, pair := range pairs {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, cErrors.New(fmt.Sprintf("DSN BUILDER: Connection parameter '%s' has no value", pair))
+		}
+
+		key = strings.TrimSpace(key)
+		value, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, cErrors.Wrap(err, fmt.Sprintf("DSN BUILDER: Connection parameter '%s'", key))
+		}
+
+		switch strings.ToLower(key) {
+		case "sslmode":
+			parameters.SSLMode = strings.ToLower(value)
+		case "sslrootcert":
+			parameters.SSLRootCert = value
+		case "sslcert":
+			parameters.SSLCert = value
+		case "sslkey":
+			parameters.SSLKey = value
+		case "application_name":
+			parameters.ApplicationName = value
+		case "connect_timeout":
+			seconds, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, cErrors.New(fmt.Sprintf("DSN BUILDER: connect_timeout '%s' is not a number of seconds", value))
+			}
+			parameters.ConnectTimeout = time.Duration(seconds) * time.Second
+		default:
+			parameters.Extra[key] = value
+		}
+	}
+
+	switch parameters.SSLMode {
+	case "", SSL_MODE_DISABLE, SSL_MODE_REQUIRE, SSL_MODE_VERIFY_CA, SSL_MODE_VERIFY_FULL:
+	default:
+		return nil, cErrors.New(fmt.Sprintf("DSN BUILDER: Unsupported sslmode '%s'", parameters.SSLMode))
+	}
+
+	//CERTIFICATES ARE LOOKED UP IN THE SYSTEM FOLDERS UNLESS THE PATH IS ABSOLUTE
+	parameters.SSLRootCert = certificatePath(parameters.SSLRootCert)
+	parameters.SSLCert = certificatePath(parameters.SSLCert)
+	parameters.SSLKey = certificatePath(parameters.SSLKey)
+
+	return parameters, nil
+}
+
+// ExtraKeys lists the driver specific keys in a stable order
+func (parameters *ConnectionParameters) ExtraKeys() []string {
+	keys := make([]string, 0, len(parameters.Extra))
+	for key := range parameters.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+/**************** POSTGRESQL ****************/
+
+type PostgreSQLDSNBuilder struct{}
+
+func (dsnBuilder *PostgreSQLDSNBuilder) BuildDSN(conDSN *DBConDSN, parameters *ConnectionParameters, masked bool) (string, error) {
+	password := conDSN.Password
+	if masked {
+		password = DSN_PASSWORD_MASK
+	}
+
+	//sslmode HAS ALWAYS BEEN disable, lib/pq WOULD OTHERWISE DEFAULT TO require
+	sslMode := parameters.SSLMode
+	if sslMode == "" {
+		sslMode = SSL_MODE_DISABLE
+	}
+
+	var dsn strings.Builder
+	writePair := func(key, value string) {
+		if dsn.Len() > 0 {
+			dsn.WriteString(" ")
+		}
+		dsn.WriteString(key)
+		dsn.WriteString("=")
+		dsn.WriteString(quotePostgreSQLValue(value))
+	}
+
+	writePair("host", conDSN.DatabaseHost)
+	writePair("port", strconv.Itoa(conDSN.Port))
+	writePair("user", conDSN.UserName)
+	writePair("password", password)
+	writePair("dbname", conDSN.DatabaseName)
+	writePair("sslmode", sslMode)
+
+	if parameters.SSLRootCert != "" {
+		writePair("sslrootcert", parameters.SSLRootCert)
+	}
+	if parameters.SSLCert != "" {
+		writePair("sslcert", parameters.SSLCert)
+	}
+	if parameters.SSLKey != "" {
+		writePair("sslkey", parameters.SSLKey)
+	}
+	if parameters.ApplicationName != "" {
+		writePair("application_name", parameters.ApplicationName)
+	}
+	if parameters.ConnectTimeout > 0 {
+		writePair("connect_timeout", strconv.Itoa(int(parameters.ConnectTimeout/time.Second)))
+	}
+
+	for _, key := range parameters.ExtraKeys() {
+		writePair(key, parameters.Extra[key])
+	}
+
+	return dsn.String(), nil
+}
+
+// quotePostgreSQLValue quotes values lib/pq would otherwise split or misread, e.g. passwords with spaces or quotes
+func quotePostgreSQLValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\r'\\=") {
+		return value
+	}
+
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+/**************** MYSQL ****************/
+
+type MySQLDSNBuilder struct{}
+
+func (dsnBuilder *MySQLDSNBuilder) BuildDSN(conDSN *DBConDSN, parameters *ConnectionParameters, masked bool) (string, error) {
+	config := mysql.NewConfig()
+	config.User = conDSN.UserName
+	config.Passwd = conDSN.Password
+	config.Net = "tcp"
+	config.Addr = net.JoinHostPort(conDSN.DatabaseHost, strconv.Itoa(conDSN.Port))
+	config.DBName = conDSN.DatabaseName
+	config.Timeout = parameters.ConnectTimeout
+
+	if masked {
+		config.Passwd = DSN_PASSWORD_MASK
+	}
+
+	if parameters.ApplicationName != "" {
+		config.ConnectionAttributes = "program_name:" + parameters.ApplicationName
+	}
+
+	if len(parameters.Extra) > 0 {
+		config.Params = make(map[string]string, len(parameters.Extra))
+		for key, value := range parameters.Extra {
+			config.Params[key] = value
+		}
+	}
+
+	switch parameters.SSLMode {
+	case SSL_MODE_DISABLE:
+		config.TLSConfig = "false"
+	case SSL_MODE_REQUIRE:
+		config.TLSConfig = "skip-verify"
+	case SSL_MODE_VERIFY_CA, SSL_MODE_VERIFY_FULL:
+		//THE DRIVER ONLY TAKES CERTIFICATES THROUGH A NAMED TLS CONFIG
+		config.TLSConfig = "cypress_" + conDSN.OrganizationId
+
+		if !masked {
+			tlsConfig, err := buildTLSConfig(conDSN.DatabaseHost, parameters)
+			if err != nil {
+				return "", err
+			}
+
+			if err = mysql.RegisterTLSConfig(config.TLSConfig, tlsConfig); err != nil {
+				return "", cErrors.Wrap(err, "DSN BUILDER")
+			}
+		}
+	}
+
+	return config.FormatDSN(), nil
+}
+
+/**************** MICROSOFT SQL ****************/
+
+type MicrosoftSQLDSNBuilder struct{}
+
+func (dsnBuilder *MicrosoftSQLDSNBuilder) BuildDSN(conDSN *DBConDSN, parameters *ConnectionParameters, masked bool) (string, error) {
+	password := conDSN.Password
+	if masked {
+		password = DSN_PASSWORD_MASK
+	}
+
+	if parameters.SSLCert != "" || parameters.SSLKey != "" {
+		return "", cErrors.New("DSN BUILDER: Client certificates are not supported by SQL Server")
+	}
+
+	query := url.Values{}
+	query.Set("database", conDSN.DatabaseName)
+
+	if parameters.ApplicationName != "" {
+		query.Set("app name", parameters.ApplicationName)
+	}
+	if parameters.ConnectTimeout > 0 {
+		query.Set("connection timeout", strconv.Itoa(int(parameters.ConnectTimeout/time.Second)))
+	}
+
+	//go-mssqldb ALWAYS CHECKS THE HOST NAME, verify-ca BEHAVES LIKE verify-full
+	switch parameters.SSLMode {
+	case SSL_MODE_DISABLE:
+		query.Set("encrypt", "disable")
+	case SSL_MODE_REQUIRE:
+		query.Set("encrypt", "true")
+		query.Set("TrustServerCertificate", "true")
+	case SSL_MODE_VERIFY_CA, SSL_MODE_VERIFY_FULL:
+		query.Set("encrypt", "true")
+		query.Set("TrustServerCertificate", "false")
+		if parameters.SSLRootCert != "" {
+			query.Set("certificate", parameters.SSLRootCert)
+		}
+	}
+
+	for _, key := range parameters.ExtraKeys() {
+		query.Set(key, parameters.Extra[key])
+	}
+
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(conDSN.UserName, password),
+		Host:     net.JoinHostPort(conDSN.DatabaseHost, strconv.Itoa(conDSN.Port)),
+		RawQuery: query.Encode(),
+	}
+	return dsn.String(), nil
+}
+
+/**************** ORACLE ****************/
+
+type OracleDSNBuilder struct{}
+
+func (dsnBuilder *OracleDSNBuilder) BuildDSN(conDSN *DBConDSN, parameters *ConnectionParameters, masked bool) (string, error) {
+	password := conDSN.Password
+	if masked {
+		password = DSN_PASSWORD_MASK
+	}
+
+	if parameters.SSLMode != "" && parameters.SSLMode != SSL_MODE_DISABLE {
+		return "", cErrors.New("DSN BUILDER: sslmode '" + parameters.SSLMode + "' is not supported for Oracle, use a TCPS wallet")
+	}
+
+	if strings.Contains(password, `"`) {
+		return "", cErrors.New("DSN BUILDER: Oracle passwords cannot contain double quotes")
+	}
+
+	//QUOTED PASSWORDS MAY HOLD THE / AND @ THAT SEPARATE THE CONNECT STRING
+	dsn := fmt.Sprintf("%s/\"%s\"@//%s:%d/%s", conDSN.UserName, password, conDSN.DatabaseHost, conDSN.Port, conDSN.DatabaseName)
+
+	query := url.Values{}
+	if parameters.ConnectTimeout > 0 {
+		query.Set("connect_timeout", strconv.Itoa(int(parameters.ConnectTimeout/time.Second)))
+	}
+	for _, key := range parameters.ExtraKeys() {
+		query.Set(key, parameters.Extra[key])
+	}
+
+	if len(query) > 0 {
+		dsn += "?" + query.Encode()
+	}
+	return dsn, nil
+}
+
+/**************** UTILITY FUNCTIONS ****************/
+
+func certificatePath(fileName string) string {
+	if fileName == "" || filepath.IsAbs(fileName) {
+		return fileName
+	}
+	return filepath.Join(GetSystemFoldersCertificatesPath(), fileName)
+}
+
+// buildTLSConfig trusts sslrootcert, presents sslcert/sslkey when given and only checks the host name for verify-full
+func buildTLSConfig(serverName string, parameters *ConnectionParameters) (*tls.Config, error) {
+	tlsConfig := &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS12}
+
+	if parameters.SSLRootCert != "" {
+		pem, err := os.ReadFile(parameters.SSLRootCert)
+		if err != nil {
+			return nil, cErrors.Wrap(err, "DSN BUILDER: Failed to read sslrootcert")
+		}
+
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, cErrors.New("DSN BUILDER: No certificate found in sslrootcert '" + parameters.SSLRootCert + "'")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if parameters.SSLCert != "" || parameters.SSLKey != "" {
+		certificate, err := tls.LoadX509KeyPair(parameters.SSLCert, parameters.SSLKey)
+		if err != nil {
+			return nil, cErrors.Wrap(err, "DSN BUILDER: Failed to load sslcert/sslkey")
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if parameters.SSLMode == SSL_MODE_VERIFY_CA {
+		rootCAs := tlsConfig.RootCAs
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyCertificateChain(rawCerts, rootCAs)
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+func verifyCertificateChain(rawCerts [][]byte, rootCAs *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return cErrors.New("DSN BUILDER: The server presented no certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	var leaf *x509.Certificate
+
+	for index, rawCert := range rawCerts {
+		certificate, err := x509.ParseCertificate(rawCert)
+		if err != nil {
+			return err
+		}
+		if index == 0 {
+			leaf = certificate
+		} else {
+			intermediates.AddCert(certificate)
+		}
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{Roots: rootCAs, Intermediates: intermediates})
+	return err
+}
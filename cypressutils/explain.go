@@ -0,0 +1,529 @@
+package cypressutils
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	cErrors "github.com/pkg/errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type fullScanThresholdKey struct{}
+
+// WithFullScanThreshold overrides the organization's FullScanThreshold for the plans explained with the returned
+// context. A threshold of zero or less leaves the full scans be
+func WithFullScanThreshold(ctx context.Context, minRows int64) context.Context {
+	return context.WithValue(ctx, fullScanThresholdKey{}, minRows)
+}
+
+// fullScanThreshold is the call's override, otherwise the organization's FullScanThreshold
+func fullScanThreshold(ctx context.Context, organizationId string) int64 {
+	if override, exists := ctx.Value(fullScanThresholdKey{}).(int64); exists {
+		return override
+	}
+	if conDSN, exists := lookupConDSN(organizationId); exists {
+		return conDSN.FullScanThreshold
+	}
+	return 0
+}
+
+// ExplainStatement is how a dialect captures a plan. Setup and Teardown run around Explain, which runs the query
+// with its arguments. The plan is in the last result set of Explain, or of Plan when there is one
+type ExplainStatement struct {
+	Setup    []string
+	Explain  string
+	Plan     string
+	Teardown []string
+}
+
+// PlanNode is one step of a plan. ActualRows is only known when the plan was analyzed
+type PlanNode struct {
+	NodeType      string
+	TableName     string
+	IndexName     string
+	EstimatedRows float64
+	ActualRows    float64
+	Cost          float64
+	// FullScan is set on the nodes that read every row of the table
+	FullScan bool
+	Children []*PlanNode
+}
+
+type QueryPlan struct {
+	Root     *PlanNode
+	Analyzed bool
+}
+
+// FullScans lists the nodes that read every row of a table of at least minRows rows, actual rows when the plan
+// was analyzed and estimated rows otherwise
+func (plan *QueryPlan) FullScans(minRows float64) []*PlanNode {
+	var fullScans []*PlanNode
+	var walk func(node *PlanNode)
+	walk = func(node *PlanNode) {
+		if node == nil {
+			return
+		}
+		if node.FullScan && plan.rows(node) >= minRows {
+			fullScans = append(fullScans, node)
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(plan.Root)
+	return fullScans
+}
+
+func (plan *QueryPlan) rows(node *PlanNode) float64 {
+	if plan.Analyzed {
+		return node.ActualRows
+	}
+	return node.EstimatedRows
+}
+
+// explain runs the dialect's EXPLAIN of the builder's statement in a transaction that is always rolled back, an
+// analyzed plan executes the statement. The full scans over the threshold of WithFullScanThreshold, or else the
+// organization's FullScanThreshold, are warnings
+func explain(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, analyze bool) (twrapper *TransactionWrapper) {
+	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "explain", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer markQueryTimeout(ctx, organizationId, twrapper, nil)
+
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
+
+	query := queryBuilder.ToString()
+	if _, err := validateQueryArguments(query, queryArguments); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	dialect := GetOrganizationDialect(organizationId)
+	explainStatement, err := dialect.Explain(query, analyze)
+	if err != nil {
+		ThrowException(err)
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	dbConn, err := GetConnection(organizationId)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
+
+	trx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
+	defer trx.Rollback()
+
+	records, err := capturePlan(ctx, trx, explainStatement, queryArguments, dialect, twrapper)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
+
+	root, err := dialect.ParsePlan(records)
+	if err != nil {
+		ThrowException(err)
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	plan := &QueryPlan{Root: root, Analyzed: analyze}
+	if threshold := fullScanThreshold(ctx, organizationId); threshold > 0 {
+		for _, node := range plan.FullScans(float64(threshold)) {
+			twrapper.SetHasWarnings(true)
+			twrapper.AddWarning(fmt.Sprintf("EXPLAIN: %s reads all %.0f rows of %s", node.NodeType, plan.rows(node), node.TableName))
+		}
+	}
+
+	twrapper.SetData(plan)
+	return twrapper
+}
+
+func capturePlan(ctx context.Context, tx *sql.Tx, explainStatement *ExplainStatement, queryArguments *CypressHashMap, dialect Dialect,
+	twrapper *TransactionWrapper) (*CypressArrayList, error) {
+	for _, statement := range explainStatement.Setup {
+		twrapper.AddQueryExecuted(statement)
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return nil, err
+		}
+	}
+
+	//THE SESSION GOES BACK TO THE POOL, THE SETTINGS OF Setup MUST NOT GO WITH IT. ONLY A Setup THAT WENT
+	//THROUGH IS UNDONE, A Teardown THAT FAILS LEAVES A WARNING RATHER THAN HIDING THE PLAN
+	defer func() {
+		for _, statement := range explainStatement.Teardown {
+			twrapper.AddQueryExecuted(statement)
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				twrapper.SetHasWarnings(true)
+				twrapper.AddWarning("EXPLAIN: " + statement + " failed, " + err.Error())
+			}
+		}
+	}()
+
+	namedParameter := NewNamedParameterQuery(explainStatement.Explain, queryArguments, dialect)
+	twrapper.AddQueryExecuted(explainStatement.Explain)
+
+	if explainStatement.Plan != "" {
+		if _, err := tx.ExecContext(ctx, namedParameter.GetParsedQuery(), namedParameter.GetParsedParameters()...); err != nil {
+			return nil, err
+		}
+
+		twrapper.AddQueryExecuted(explainStatement.Plan)
+		resRows, err := tx.QueryContext(ctx, explainStatement.Plan)
+		if err != nil {
+			return nil, err
+		}
+		defer resRows.Close()
+		return readLastResultSet(resRows)
+	}
+
+	resRows, err := tx.QueryContext(ctx, namedParameter.GetParsedQuery(), namedParameter.GetParsedParameters()...)
+	if err != nil {
+		return nil, err
+	}
+	defer resRows.Close()
+	return readLastResultSet(resRows)
+}
+
+// readLastResultSet skips to the last result set, SQL Server returns the rows of an analyzed statement before its plan
+func readLastResultSet(resRows *sql.Rows) (*CypressArrayList, error) {
+	var cypressList *CypressArrayList
+	for {
+		columns, err := resRows.Columns()
+		if err != nil {
+			return nil, err
+		}
+		columnTypes, err := resRows.ColumnTypes()
+		if err != nil {
+			return nil, err
+		}
+
+		values := make([]sql.RawBytes, len(columns))
+		scanArgs := make([]interface{}, len(columns))
+		for i := range values {
+			scanArgs[i] = &values[i]
+		}
+
+		resultSet := NewList()
+		for resRows.Next() {
+			if err = resRows.Scan(scanArgs...); err != nil {
+				return nil, err
+			}
+
+			hashMap := NewMap()
+			for i, value := range values {
+				ParseSQLRawBytesToType(value, columns[i], columnTypes[i], hashMap)
+			}
+			resultSet.AddNewRecord(hashMap)
+		}
+		if err = resRows.Err(); err != nil {
+			return nil, err
+		}
+
+		if resultSet.Size() > 0 || cypressList == nil {
+			cypressList = resultSet
+		}
+		if !resRows.NextResultSet() {
+			return cypressList, nil
+		}
+	}
+}
+
+// planDocument is the plan of the servers that return it whole in the first column of a single row
+func planDocument(records *CypressArrayList) (string, error) {
+	if records == nil || records.Size() == 0 || records.GetRecord(0).GetData().Oldest() == nil {
+		return "", cErrors.New("EXPLAIN: no plan returned")
+	}
+	return fmt.Sprintf("%v", records.GetRecord(0).GetData().Oldest().Value), nil
+}
+
+func planNumber(value interface{}) float64 {
+	switch number := value.(type) {
+	case float64:
+		return number
+	case int64:
+		return float64(number)
+	case string:
+		parsed, _ := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		return parsed
+	}
+	return 0
+}
+
+func planString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+/**************** POSTGRESQL ****************/
+
+func parsePostgreSQLPlan(records *CypressArrayList) (*PlanNode, error) {
+	document, err := planDocument(records)
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []struct {
+		Plan map[string]interface{} `json:"Plan"`
+	}
+	if err = json.Unmarshal([]byte(document), &plans); err != nil {
+		return nil, cErrors.New("EXPLAIN: unreadable plan, " + err.Error())
+	}
+	if len(plans) == 0 || plans[0].Plan == nil {
+		return nil, cErrors.New("EXPLAIN: no plan returned")
+	}
+	return postgreSQLPlanNode(plans[0].Plan), nil
+}
+
+func postgreSQLPlanNode(plan map[string]interface{}) *PlanNode {
+	node := &PlanNode{
+		NodeType:      planString(plan["Node Type"]),
+		TableName:     planString(plan["Relation Name"]),
+		IndexName:     planString(plan["Index Name"]),
+		EstimatedRows: planNumber(plan["Plan Rows"]),
+		Cost:          planNumber(plan["Total Cost"]),
+	}
+	node.FullScan = node.NodeType == "Seq Scan"
+
+	//ACTUAL ROWS ARE AN AVERAGE PER LOOP
+	if loops, exists := plan["Actual Loops"]; exists {
+		node.ActualRows = planNumber(plan["Actual Rows"]) * planNumber(loops)
+	}
+
+	children, _ := plan["Plans"].([]interface{})
+	for _, child := range children {
+		if childPlan, ok := child.(map[string]interface{}); ok {
+			node.Children = append(node.Children, postgreSQLPlanNode(childPlan))
+		}
+	}
+	return node
+}
+
+/**************** MYSQL ****************/
+
+func parseMySQLPlan(records *CypressArrayList) (*PlanNode, error) {
+	document, err := planDocument(records)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan map[string]interface{}
+	if err = json.Unmarshal([]byte(document), &plan); err != nil {
+		return nil, cErrors.New("EXPLAIN: unreadable plan, " + err.Error())
+	}
+
+	queryBlock, ok := plan["query_block"].(map[string]interface{})
+	if !ok {
+		return nil, cErrors.New("EXPLAIN: no query_block in the plan")
+	}
+	return mySQLPlanNode("query_block", queryBlock), nil
+}
+
+// mySQLPlanNode turns an operation of the plan, e.g. ordering_operation or nested_loop, into a node whose
+// children are the tables and operations it holds
+func mySQLPlanNode(nodeType string, operation map[string]interface{}) *PlanNode {
+	node := &PlanNode{NodeType: nodeType}
+	if costInfo, ok := operation["cost_info"].(map[string]interface{}); ok {
+		node.Cost = planNumber(costInfo["query_cost"])
+	}
+
+	keys := make([]string, 0, len(operation))
+	for key := range operation {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch value := operation[key].(type) {
+		case map[string]interface{}:
+			if key == "table" {
+				node.Children = append(node.Children, mySQLTableNode(value))
+			} else if key != "cost_info" {
+				node.Children = append(node.Children, mySQLPlanNode(key, value))
+			}
+		case []interface{}:
+			for _, element := range value {
+				if elementOperation, ok := element.(map[string]interface{}); ok {
+					if table, ok := elementOperation["table"].(map[string]interface{}); ok && len(elementOperation) == 1 {
+						node.Children = append(node.Children, mySQLTableNode(table))
+					} else {
+						node.Children = append(node.Children, mySQLPlanNode(key, elementOperation))
+					}
+				}
+			}
+		}
+	}
+	return node
+}
+
+func mySQLTableNode(table map[string]interface{}) *PlanNode {
+	node := &PlanNode{
+		NodeType:      planString(table["access_type"]),
+		TableName:     planString(table["table_name"]),
+		IndexName:     planString(table["key"]),
+		EstimatedRows: planNumber(table["rows_examined_per_scan"]),
+	}
+	node.FullScan = node.NodeType == "ALL"
+	if costInfo, ok := table["cost_info"].(map[string]interface{}); ok {
+		node.Cost = planNumber(costInfo["prefix_cost"])
+	}
+
+	if subQuery, ok := table["materialized_from_subquery"].(map[string]interface{}); ok {
+		if queryBlock, ok := subQuery["query_block"].(map[string]interface{}); ok {
+			node.Children = append(node.Children, mySQLPlanNode("materialized_from_subquery", queryBlock))
+		}
+	}
+	return node
+}
+
+/**************** MICROSOFT SQL ****************/
+
+type showPlanElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr        `xml:",any,attr"`
+	Children []showPlanElement `xml:",any"`
+}
+
+func (element *showPlanElement) attr(name string) string {
+	for _, attr := range element.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func parseMicrosoftSQLPlan(records *CypressArrayList) (*PlanNode, error) {
+	document, err := planDocument(records)
+	if err != nil {
+		return nil, err
+	}
+
+	var showPlan showPlanElement
+	if err = xml.Unmarshal([]byte(document), &showPlan); err != nil {
+		return nil, cErrors.New("EXPLAIN: unreadable plan, " + err.Error())
+	}
+
+	relOps := showPlanRelOps(&showPlan)
+	if len(relOps) == 0 {
+		return nil, cErrors.New("EXPLAIN: no RelOp in the plan")
+	}
+	return microsoftSQLPlanNode(relOps[0]), nil
+}
+
+// showPlanRelOps finds the operators nearest to element, those nested in them are theirs
+func showPlanRelOps(element *showPlanElement) []*showPlanElement {
+	var relOps []*showPlanElement
+	for index := range element.Children {
+		child := &element.Children[index]
+		if child.XMLName.Local == "RelOp" {
+			relOps = append(relOps, child)
+		} else {
+			relOps = append(relOps, showPlanRelOps(child)...)
+		}
+	}
+	return relOps
+}
+
+func microsoftSQLPlanNode(relOp *showPlanElement) *PlanNode {
+	node := &PlanNode{
+		NodeType:      relOp.attr("PhysicalOp"),
+		EstimatedRows: planNumber(relOp.attr("EstimateRows")),
+		Cost:          planNumber(relOp.attr("EstimatedTotalSubtreeCost")),
+	}
+	node.FullScan = node.NodeType == "Table Scan" || node.NodeType == "Clustered Index Scan"
+
+	var walk func(element *showPlanElement)
+	walk = func(element *showPlanElement) {
+		for index := range element.Children {
+			child := &element.Children[index]
+			switch child.XMLName.Local {
+			case "RelOp":
+				continue
+			case "Object":
+				if node.TableName == "" {
+					node.TableName = strings.Trim(child.attr("Table"), "[]")
+					node.IndexName = strings.Trim(child.attr("Index"), "[]")
+				}
+			case "RunTimeCountersPerThread":
+				node.ActualRows += planNumber(child.attr("ActualRows"))
+			}
+			walk(child)
+		}
+	}
+	walk(relOp)
+
+	for _, child := range showPlanRelOps(relOp) {
+		node.Children = append(node.Children, microsoftSQLPlanNode(child))
+	}
+	return node
+}
+
+/**************** ORACLE ****************/
+
+const oracleExplainStatementId = "cypress_explain"
+
+// parseOraclePlan puts the rows of plan_table back into a tree by their parent_id
+func parseOraclePlan(records *CypressArrayList) (*PlanNode, error) {
+	if records == nil || records.Size() == 0 {
+		return nil, cErrors.New("EXPLAIN: no plan returned")
+	}
+
+	nodes := make(map[string]*PlanNode)
+	var root *PlanNode
+	for index := 0; index < records.Size(); index++ {
+		record := records.GetRecord(index)
+		operation := record.GetStringValue("operation")
+		options := record.GetStringValue("options")
+
+		node := &PlanNode{
+			NodeType:      strings.TrimSpace(operation + " " + options),
+			EstimatedRows: planNumber(record.GetStringValue("cardinality")),
+			Cost:          planNumber(record.GetStringValue("cost")),
+			FullScan:      operation == "TABLE ACCESS" && options == "FULL",
+		}
+		if strings.HasPrefix(operation, "INDEX") {
+			node.IndexName = record.GetStringValue("object_name")
+		} else {
+			node.TableName = record.GetStringValue("object_name")
+		}
+		nodes[record.GetStringValue("id")] = node
+
+		parent, exists := nodes[record.GetStringValue("parent_id")]
+		switch {
+		case exists:
+			parent.Children = append(parent.Children, node)
+		case root == nil:
+			root = node
+		}
+	}
+	return root, nil
+}
@@ -0,0 +1,172 @@
+package cypressutils_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+const postgreSQLPlan = `[{"Plan": {"Node Type": "Hash Join", "Total Cost": 42.5, "Plan Rows": 120, "Actual Rows": 60, "Actual Loops": 1,
+	"Plans": [
+		{"Node Type": "Seq Scan", "Relation Name": "orders", "Total Cost": 30, "Plan Rows": 5000, "Actual Rows": 2500, "Actual Loops": 2},
+		{"Node Type": "Index Scan", "Relation Name": "people", "Index Name": "people_pkey", "Total Cost": 8.3, "Plan Rows": 1}
+	]}}]`
+
+func planRecords(columns ...interface{}) *cypressutils.CypressArrayList {
+	records := cypressutils.NewList()
+	record := cypressutils.NewMap()
+	for index := 0; index < len(columns); index += 2 {
+		record.PutValue(columns[index].(string), columns[index+1])
+	}
+	records.AddNewRecord(record)
+	return records
+}
+
+func TestParsePostgreSQLPlan(t *testing.T) {
+	root, err := cypressutils.GetDialect(cypressutils.PostgreSQL).ParsePlan(planRecords("QUERY PLAN", postgreSQLPlan))
+	if err != nil {
+		t.Fatalf("ParsePlan: %v", err)
+	}
+
+	if root.NodeType != "Hash Join" || root.Cost != 42.5 || root.EstimatedRows != 120 || len(root.Children) != 2 {
+		t.Fatalf("root = %+v", root)
+	}
+	seqScan, indexScan := root.Children[0], root.Children[1]
+	if !seqScan.FullScan || seqScan.TableName != "orders" || seqScan.ActualRows != 5000 {
+		t.Errorf("seq scan = %+v, want a full scan of orders with 2500 rows on each of 2 loops", seqScan)
+	}
+	if indexScan.FullScan || indexScan.IndexName != "people_pkey" {
+		t.Errorf("index scan = %+v", indexScan)
+	}
+}
+
+func TestParseMySQLPlan(t *testing.T) {
+	plan := `{"query_block": {"select_id": 1, "cost_info": {"query_cost": "1012.25"}, "nested_loop": [
+		{"table": {"table_name": "orders", "access_type": "ALL", "rows_examined_per_scan": 9800, "cost_info": {"prefix_cost": "990.0"}}},
+		{"table": {"table_name": "people", "access_type": "eq_ref", "key": "PRIMARY", "rows_examined_per_scan": 1}}
+	]}}`
+
+	root, err := cypressutils.GetDialect(cypressutils.MySQL).ParsePlan(planRecords("EXPLAIN", plan))
+	if err != nil {
+		t.Fatalf("ParsePlan: %v", err)
+	}
+
+	if root.NodeType != "query_block" || root.Cost != 1012.25 || len(root.Children) != 2 {
+		t.Fatalf("root = %+v", root)
+	}
+	if orders := root.Children[0]; !orders.FullScan || orders.TableName != "orders" || orders.EstimatedRows != 9800 || orders.Cost != 990 {
+		t.Errorf("orders = %+v, want a full scan of 9800 rows", orders)
+	}
+	if people := root.Children[1]; people.FullScan || people.IndexName != "PRIMARY" {
+		t.Errorf("people = %+v", people)
+	}
+}
+
+func TestParseMicrosoftSQLPlan(t *testing.T) {
+	plan := `<ShowPlanXML><BatchSequence><Batch><Statements><StmtSimple><QueryPlan>
+		<RelOp PhysicalOp="Nested Loops" EstimateRows="10" EstimatedTotalSubtreeCost="0.5">
+			<NestedLoops>
+				<RelOp PhysicalOp="Clustered Index Scan" EstimateRows="7000" EstimatedTotalSubtreeCost="0.4">
+					<RunTimeInformation><RunTimeCountersPerThread ActualRows="6900"/></RunTimeInformation>
+					<IndexScan><Object Table="[orders]" Index="[PK_orders]"/></IndexScan>
+				</RelOp>
+				<RelOp PhysicalOp="Index Seek" EstimateRows="1" EstimatedTotalSubtreeCost="0.1">
+					<IndexScan><Object Table="[people]" Index="[IX_people_name]"/></IndexScan>
+				</RelOp>
+			</NestedLoops>
+		</RelOp>
+	</QueryPlan></StmtSimple></Statements></Batch></BatchSequence></ShowPlanXML>`
+
+	root, err := cypressutils.GetDialect(cypressutils.MicrosoftSQL).ParsePlan(planRecords("Microsoft SQL Server 2005 XML Showplan", plan))
+	if err != nil {
+		t.Fatalf("ParsePlan: %v", err)
+	}
+
+	if root.NodeType != "Nested Loops" || root.TableName != "" || len(root.Children) != 2 {
+		t.Fatalf("root = %+v, the tables of its children are not its own", root)
+	}
+	if scan := root.Children[0]; !scan.FullScan || scan.TableName != "orders" || scan.IndexName != "PK_orders" || scan.ActualRows != 6900 {
+		t.Errorf("scan = %+v", scan)
+	}
+	if seek := root.Children[1]; seek.FullScan || seek.TableName != "people" {
+		t.Errorf("seek = %+v", seek)
+	}
+}
+
+func TestParseOraclePlan(t *testing.T) {
+	records := cypressutils.NewList()
+	for _, row := range [][]string{
+		{"0", "", "SELECT STATEMENT", "", "", "12", "40"},
+		{"1", "0", "NESTED LOOPS", "", "", "12", "40"},
+		{"2", "1", "TABLE ACCESS", "FULL", "ORDERS", "8000", "35"},
+		{"3", "1", "INDEX", "UNIQUE SCAN", "PEOPLE_PK", "1", "0"},
+	} {
+		record := cypressutils.NewMap()
+		for index, column := range []string{"id", "parent_id", "operation", "options", "object_name", "cardinality", "cost"} {
+			record.PutValue(column, row[index])
+		}
+		records.AddNewRecord(record)
+	}
+
+	root, err := cypressutils.GetDialect(cypressutils.Oracle).ParsePlan(records)
+	if err != nil {
+		t.Fatalf("ParsePlan: %v", err)
+	}
+
+	if root.NodeType != "SELECT STATEMENT" || len(root.Children) != 1 || len(root.Children[0].Children) != 2 {
+		t.Fatalf("root = %+v", root)
+	}
+	nestedLoops := root.Children[0]
+	if scan := nestedLoops.Children[0]; !scan.FullScan || scan.NodeType != "TABLE ACCESS FULL" || scan.TableName != "ORDERS" || scan.EstimatedRows != 8000 {
+		t.Errorf("scan = %+v", scan)
+	}
+	if index := nestedLoops.Children[1]; index.FullScan || index.IndexName != "PEOPLE_PK" || index.TableName != "" {
+		t.Errorf("index = %+v", index)
+	}
+}
+
+func TestParsePlanRefusesAnUnreadablePlan(t *testing.T) {
+	for _, databaseServer := range []cypressutils.DbTypes{cypressutils.PostgreSQL, cypressutils.MySQL, cypressutils.MicrosoftSQL} {
+		if _, err := cypressutils.GetDialect(databaseServer).ParsePlan(planRecords("plan", "not a plan")); err == nil {
+			t.Errorf("%s parsed an unreadable plan", databaseServer)
+		}
+	}
+	if _, err := cypressutils.GetDialect(cypressutils.Oracle).ParsePlan(cypressutils.NewList()); err == nil {
+		t.Errorf("%s parsed an empty plan", cypressutils.Oracle)
+	}
+}
+
+func TestExplainWarnsAboutFullScansOverTheThreshold(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+	fakeDB.Expect("EXPLAIN").WithColumns("QUERY PLAN").AddRow(postgreSQLPlan).Times(2)
+
+	queryBuilder := func() *cypressutils.QueryBuilder {
+		return cypressutils.NewQueryBuilder().Select().SelectColumn("id").FromTable("orders")
+	}
+
+	ctx := cypressutils.WithFullScanThreshold(context.Background(), 4000)
+	twrapper := cypressutils.ExplainContext(ctx, organizationId, queryBuilder(), nil, true)
+	if twrapper.HasErrors {
+		t.Fatalf("ExplainContext failed: %s", twrapper.GetErrors())
+	}
+
+	plan := twrapper.GetData().(*cypressutils.QueryPlan)
+	if fullScans := plan.FullScans(4000); len(fullScans) != 1 || fullScans[0].TableName != "orders" {
+		t.Errorf("full scans = %v, want the scan of orders", fullScans)
+	}
+	if !twrapper.HasWarnings || len(twrapper.Warnings) != 1 || !strings.Contains(twrapper.Warnings[0], "Seq Scan reads all 5000 rows of orders") {
+		t.Errorf("warnings = %v", twrapper.Warnings)
+	}
+	if !strings.HasPrefix(fakeDB.GetLastStatement().Query, "EXPLAIN") || fakeDB.GetRollbacks() != 1 || fakeDB.GetCommits() != 0 {
+		t.Errorf("last statement = %s, rollbacks = %d, commits = %d, want the analyzed statement rolled back",
+			fakeDB.GetLastStatement().Query, fakeDB.GetRollbacks(), fakeDB.GetCommits())
+	}
+
+	//OVER THE ROWS OF EVERY SCAN THERE IS NOTHING TO WARN ABOUT
+	ctx = cypressutils.WithFullScanThreshold(context.Background(), 6000)
+	if twrapper = cypressutils.ExplainContext(ctx, organizationId, queryBuilder(), nil, true); twrapper.HasWarnings {
+		t.Errorf("warnings = %v above every scan", twrapper.Warnings)
+	}
+}
@@ -1,12 +1,18 @@
 package cypressutils
 
 import (
+	"fmt"
 	cErrors "github.com/pkg/errors"
+	"reflect"
+	"regexp"
 	"strings"
 )
 
 type FilterPredicate struct {
 	predicateClause string
+	arguments       *CypressHashMap
+	allowFullTable  bool
+	Err             error
 }
 
 func NewFilterPredicate(predicateClause ...string) *FilterPredicate {
@@ -15,11 +21,11 @@ func NewFilterPredicate(predicateClause ...string) *FilterPredicate {
 		pred = predicateClause[0]
 	}
 
-	return &FilterPredicate{predicateClause: pred}
+	return &FilterPredicate{predicateClause: pred, arguments: NewMap()}
 }
 
 func NewFilterPredicateWithInit(predicateClause string) *FilterPredicate {
-	return &FilterPredicate{predicateClause: predicateClause}
+	return &FilterPredicate{predicateClause: predicateClause, arguments: NewMap()}
 }
 
 func (predicate *FilterPredicate) OpenPredicate() *FilterPredicate {
@@ -121,6 +127,7 @@ func (predicate *FilterPredicate) NotIn(column, query string) *FilterPredicate {
 func (predicate *FilterPredicate) CopyFilterFrom(filterPredicate *FilterPredicate) *FilterPredicate {
 	if filterPredicate != nil {
 		predicate.predicateClause += " " + filterPredicate.predicateClause
+		predicate.SetArguments(filterPredicate.GetArguments())
 	}
 	return predicate
 }
@@ -375,6 +382,160 @@ func (predicate *FilterPredicate) GetClause() string {
 	return predicate.predicateClause
 }
 
+// SetArgument binds a value to one of the predicate's :named variables. The builder the predicate is
+// handed to (WherePred, HavingPred, On) takes the value along
+func (predicate *FilterPredicate) SetArgument(namedVariable string, value interface{}) *FilterPredicate {
+	if predicate.arguments == nil {
+		predicate.arguments = NewMap()
+	}
+	if !strings.HasPrefix(namedVariable, ":") {
+		namedVariable = ":" + namedVariable
+	}
+	predicate.arguments.PutValue(namedVariable, value)
+	return predicate
+}
+
+func (predicate *FilterPredicate) SetArguments(queryArguments *CypressHashMap) *FilterPredicate {
+	if queryArguments == nil {
+		return predicate
+	}
+	for pair := queryArguments.GetData().Oldest(); pair != nil; pair = pair.Next() {
+		predicate.SetArgument(fmt.Sprintf("%v", pair.Key), pair.Value)
+	}
+	return predicate
+}
+
+func (predicate *FilterPredicate) GetArguments() *CypressHashMap {
+	if predicate.arguments == nil {
+		predicate.arguments = NewMap()
+	}
+	return predicate.arguments
+}
+
+/**************** SUB-QUERIES ****************/
+
+// InQuery is In for a builder. Its bound values go along with the predicate, renamed where the predicate
+// already uses the name, so they need not be merged by hand. The sub-query is rendered for its own dialect
+func (predicate *FilterPredicate) InQuery(column string, subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery(column, "IN", subQuery)
+}
+
+func (predicate *FilterPredicate) NotInQuery(column string, subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery(column, "NOT IN", subQuery)
+}
+
+func (predicate *FilterPredicate) ExistsQuery(subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery("", "EXISTS", subQuery)
+}
+
+func (predicate *FilterPredicate) NotExistsQuery(subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery("", "NOT EXISTS", subQuery)
+}
+
+// AnyQuery follows a comparison written with CustomFilter, e.g. CustomFilter("price >").AnyQuery(subQuery)
+func (predicate *FilterPredicate) AnyQuery(subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery("", "ANY", subQuery)
+}
+
+func (predicate *FilterPredicate) AllQuery(subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery("", "ALL", subQuery)
+}
+
+func (predicate *FilterPredicate) SomeQuery(subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery("", "SOME", subQuery)
+}
+
+func (predicate *FilterPredicate) LessThanQuery(column string, subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery(column, "<", subQuery)
+}
+
+func (predicate *FilterPredicate) LessThanOrEqualToQuery(column string, subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery(column, "<=", subQuery)
+}
+
+func (predicate *FilterPredicate) GreaterThanQuery(column string, subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery(column, ">", subQuery)
+}
+
+func (predicate *FilterPredicate) GreaterThanOrEqualToQuery(column string, subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery(column, ">=", subQuery)
+}
+
+func (predicate *FilterPredicate) EqualToQuery(column string, subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery(column, "=", subQuery)
+}
+
+func (predicate *FilterPredicate) NotEqualToQuery(column string, subQuery *QueryBuilder) *FilterPredicate {
+	return predicate.subQuery(column, "<>", subQuery)
+}
+
+func (predicate *FilterPredicate) subQuery(column, operator string, subQuery *QueryBuilder) *FilterPredicate {
+	var err error
+	switch {
+	case predicate.Err != nil:
+		return predicate
+	case subQuery == nil:
+		err = cErrors.New(operator + ": Sub-query is nil")
+	case subQuery.Err != nil:
+		err = subQuery.Err
+	case column == "" && operator != "EXISTS" && operator != "NOT EXISTS" && operator != "ANY" && operator != "ALL" && operator != "SOME":
+		err = cErrors.New(operator + ": Column name is empty")
+	}
+	if err != nil {
+		ThrowException(err)
+		predicate.Err = err
+		return predicate
+	}
+
+	query := subQuery.ToString()
+	if subQuery.Err != nil {
+		predicate.Err = subQuery.Err
+		return predicate
+	}
+
+	//A NAME THE CLAUSE USES BUT DOES NOT BIND IS LEFT TO THE CALLER, SO IT IS TAKEN AS WELL
+	query, arguments := renameTakenArguments(query, subQuery.withArguments(nil), func(namedVariable string, value interface{}) bool {
+		if predicate.GetArguments().Contains(namedVariable) {
+			return !reflect.DeepEqual(predicate.arguments.GetValue(namedVariable), value)
+		}
+		return namedVariableRegex(namedVariable).MatchString(predicate.predicateClause)
+	})
+
+	if column != "" {
+		predicate.predicateClause += " " + column
+	}
+	predicate.predicateClause += " " + operator + " (" + query + ") "
+	return predicate.SetArguments(arguments)
+}
+
+// renameTakenArguments gives the :named variables of arguments for which taken holds a name of their own,
+// e.g. :status becomes :status_1, in text as well
+func renameTakenArguments(text string, arguments *CypressHashMap, taken func(namedVariable string, value interface{}) bool) (string, *CypressHashMap) {
+	renamed := NewMap()
+	for pair := arguments.GetData().Oldest(); pair != nil; pair = pair.Next() {
+		namedVariable := fmt.Sprintf("%v", pair.Key)
+		if !strings.HasPrefix(namedVariable, ":") {
+			namedVariable = ":" + namedVariable
+		}
+
+		if taken(namedVariable, pair.Value) {
+			candidate := namedVariable
+			for suffix := 1; taken(candidate, pair.Value) || arguments.Contains(candidate) || renamed.Contains(candidate); suffix++ {
+				candidate = fmt.Sprintf("%s_%d", namedVariable, suffix)
+			}
+			text = namedVariableRegex(namedVariable).ReplaceAllString(text, "${1}"+candidate)
+			namedVariable = candidate
+		}
+		renamed.PutValue(namedVariable, pair.Value)
+	}
+	return text, renamed
+}
+
+// namedVariableRegex finds namedVariable on its own, not as a part of a longer name or of a :: cast
+func namedVariableRegex(namedVariable string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^:\w])` + regexp.QuoteMeta(namedVariable) + `\b`)
+}
+
 func validateColumnArgument(namedVariable, errorLocation string) {
 	if len(namedVariable) < 2 {
 		panic(errorLocation + "namedVariable is empty")
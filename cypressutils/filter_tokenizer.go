@@ -304,6 +304,10 @@ func (fPredicate *formPredicate) evaluateSingleClause(singleClause string) error
 	columnName := strings.TrimSpace(components[0])
 	relationName := strings.TrimSpace(components[1])
 
+	if !plainIdentifierRegex.MatchString(columnName) {
+		return cErrors.New("Invalid column name '" + columnName + "'. Offender: " + singleClause)
+	}
+
 	fPredicate.filterPredicate.WriteString(columnName)
 	fPredicate.filterPredicate.WriteString(" ")
 	fPredicate.filterColumns.Add(columnName)
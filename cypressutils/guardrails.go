@@ -0,0 +1,190 @@
+package cypressutils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	cErrors "github.com/pkg/errors"
+	"strings"
+)
+
+type maxAffectedRowsKey struct{}
+
+const affectedRowsSavepoint = "affected_rows_limit"
+
+// AffectedRowsLimitError is reported when an UPDATE or DELETE touched more rows than WithMaxAffectedRows allows.
+// The statement has been undone
+type AffectedRowsLimitError struct {
+	Statement    string
+	TableName    string
+	Limit        int64
+	RowsAffected int64
+}
+
+func (err *AffectedRowsLimitError) Error() string {
+	return fmt.Sprintf("%s: %d rows of %s affected where at most %d are allowed, the %s was rolled back", err.Statement,
+		err.RowsAffected, err.TableName, err.Limit, err.Statement)
+}
+
+func IsAffectedRowsLimit(err error) bool {
+	var limitErr *AffectedRowsLimitError
+	return errors.As(err, &limitErr)
+}
+
+// WithMaxAffectedRows rolls back the UPDATE and DELETE statements run with the returned context when they touch more
+// than limit rows. A limit of zero or less disables it
+func WithMaxAffectedRows(ctx context.Context, limit int64) context.Context {
+	return context.WithValue(ctx, maxAffectedRowsKey{}, limit)
+}
+
+func maxAffectedRows(ctx context.Context) int64 {
+	limit, _ := ctx.Value(maxAffectedRowsKey{}).(int64)
+	return limit
+}
+
+// AllowFullTable lets the UPDATE and DELETE statements of the builder run without a WHERE clause, on every row
+func (builder *QueryBuilder) AllowFullTable() *QueryBuilder {
+	builder.allowFullTable = true
+	return builder
+}
+
+// AllowFullTable lets Update and Delete run on every row of the table when the predicate is left empty
+func (predicate *FilterPredicate) AllowFullTable() *FilterPredicate {
+	predicate.allowFullTable = true
+	return predicate
+}
+
+func (predicate *FilterPredicate) allowsFullTable() bool {
+	return predicate != nil && predicate.allowFullTable
+}
+
+// checkBounded refuses an UPDATE or DELETE without a WHERE clause unless the builder allows the full table
+func (builder *QueryBuilder) checkBounded(statement *queryStatement) error {
+	if builder.allowFullTable || statement.where != "" ||
+		(statement.statementType != STATEMENT_UPDATE && statement.statementType != STATEMENT_DELETE) {
+		return nil
+	}
+
+	name := statementName(statement.statementType)
+	return cErrors.New(name + ": no WHERE clause, every row of " + statement.table + " would be affected. Call AllowFullTable to " +
+		strings.ToLower(name) + " the whole table")
+}
+
+// unboundedPredicateError is checkBounded for the repository functions, which build the WHERE clause out of the
+// predicate and may add conditions of their own
+func unboundedPredicateError(statementType StatementType, tableName string, filterPredicate *FilterPredicate) error {
+	if filterPredicate.allowsFullTable() || (filterPredicate != nil && strings.TrimSpace(filterPredicate.GetClause()) != "") {
+		return nil
+	}
+
+	name := statementName(statementType)
+	err := cErrors.New(name + ": filter predicate is empty, every row of " + tableName + " would be affected. Pass a predicate " +
+		"with AllowFullTable to " + strings.ToLower(name) + " the whole table")
+	ThrowException(err)
+	return err
+}
+
+func statementName(statementType StatementType) string {
+	if statementType == STATEMENT_DELETE {
+		return "DELETE"
+	}
+	return "UPDATE"
+}
+
+// limitAffectedRows runs the UPDATE or DELETE in a transaction of its own, committed only when it stays within
+// the limit of ctx
+func limitAffectedRows(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap,
+	statementType StatementType) (twrapper *TransactionWrapper) {
+	twrapper = NewTransactionWrapper()
+
+	dbConn, err := GetConnection(organizationId)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
+
+	trx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
+
+	twrapper, err = txLimitAffectedRows(ctx, organizationId, trx, queryBuilder, queryArguments, statementType)
+	if err != nil {
+		if err2 := trx.Rollback(); err2 != nil {
+			twrapper.AddError(err2.Error())
+		}
+		return twrapper
+	}
+
+	if err = trx.Commit(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+	}
+	return twrapper
+}
+
+// txLimitAffectedRows runs the UPDATE or DELETE after a savepoint and rolls back to it when the statement touched
+// more rows than the limit of ctx, the rest of the transaction is left as it was. The rows are counted from
+// those returned when the statement returns any, from the driver's count otherwise
+func txLimitAffectedRows(ctx context.Context, organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, queryArguments *CypressHashMap,
+	statementType StatementType) (twrapper *TransactionWrapper, err error) {
+	limit := maxAffectedRows(ctx)
+	ctx = WithMaxAffectedRows(ctx, 0)
+	dialect := GetOrganizationDialect(organizationId)
+
+	if _, err = tx.ExecContext(ctx, dialect.Savepoint(affectedRowsSavepoint)); err != nil {
+		twrapper = NewTransactionWrapper()
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper, err
+	}
+
+	var rowsAffected int64
+	switch {
+	case statementType == STATEMENT_DELETE && dialect.ReturningStyle() != RETURNING_NOT_SUPPORTED:
+		twrapper, err = txDelete(ctx, organizationId, tx, queryBuilder, queryArguments)
+	case statementType == STATEMENT_UPDATE && queryBuilder.GetReturning() != "":
+		twrapper, err = txUpdate(ctx, organizationId, tx, queryBuilder, queryArguments)
+	default:
+		twrapper, err = txExecute(ctx, organizationId, tx, queryBuilder, queryArguments)
+		if err == nil {
+			rowsAffected = twrapper.GetData().(int64)
+			twrapper.AddMessage(fmt.Sprintf("%d record(s) %sd", rowsAffected, strings.ToLower(statementName(statementType))))
+			twrapper.SetData(NewList())
+		}
+	}
+	if err != nil {
+		return twrapper, err
+	}
+
+	if cypressList, ok := twrapper.GetData().(*CypressArrayList); ok && cypressList.Size() > 0 {
+		rowsAffected = int64(cypressList.Size())
+	}
+	if rowsAffected <= limit {
+		return twrapper, nil
+	}
+
+	tableName := queryBuilder.statement().table
+	if queryArguments != nil && queryArguments.GetTableName() != "" {
+		tableName = queryArguments.GetTableName()
+	}
+	err = &AffectedRowsLimitError{Statement: statementName(statementType), TableName: tableName, Limit: limit, RowsAffected: rowsAffected}
+	if _, rollbackErr := tx.ExecContext(ctx, dialect.RollbackToSavepoint(affectedRowsSavepoint)); rollbackErr != nil {
+		twrapper.AddError(rollbackErr.Error())
+	}
+
+	ThrowException(err)
+	twrapper.SetHasErrors(true)
+	twrapper.AddError(err.Error())
+	twrapper.Messages = []string{}
+	twrapper.SetData(NewList())
+	return twrapper, err
+}
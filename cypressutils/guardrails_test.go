@@ -0,0 +1,92 @@
+package cypressutils_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+func TestDeleteRefusesAnEmptyPredicate(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+
+	for _, filterPredicate := range []*cypressutils.FilterPredicate{nil, cypressutils.NewFilterPredicate("")} {
+		twrapper := cypressutils.Delete(organizationId, "people", filterPredicate, nil)
+		if !twrapper.HasErrors || !strings.Contains(twrapper.GetErrors(), "AllowFullTable") {
+			t.Errorf("Delete without a predicate: %s", twrapper.GetErrors())
+		}
+	}
+	if len(fakeDB.GetStatements()) != 0 {
+		t.Errorf("%d statements run, the first is %s", len(fakeDB.GetStatements()), fakeDB.GetStatements()[0].Query)
+	}
+}
+
+func TestUpdateRefusesAnEmptyPredicate(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+
+	updateSet := cypressutils.NewMap()
+	updateSet.PutValue("name", "Jane")
+
+	if twrapper := cypressutils.Update(organizationId, "people", updateSet, nil, nil, false, nil); !twrapper.HasErrors {
+		t.Error("Update without a predicate succeeded")
+	}
+	if len(fakeDB.GetStatements()) != 0 {
+		t.Errorf("%d statements run, the first is %s", len(fakeDB.GetStatements()), fakeDB.GetStatements()[0].Query)
+	}
+}
+
+func TestAllowFullTableDeletesEveryRow(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t, cypressutils.MySQL)
+	fakeDB.Expect("DELETE").WithRowsAffected(4)
+
+	twrapper := cypressutils.Delete(organizationId, "people", cypressutils.NewFilterPredicate("").AllowFullTable(), nil)
+	if twrapper.HasErrors {
+		t.Fatalf("Delete with AllowFullTable failed: %s", twrapper.GetErrors())
+	}
+	if query := fakeDB.GetLastStatement().Query; query != "DELETE FROM `people`" {
+		t.Errorf("query = %s", query)
+	}
+}
+
+func TestMaxAffectedRowsRollsBackTheStatement(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t, cypressutils.MySQL)
+	fakeDB.Expect("DELETE").WithRowsAffected(5)
+
+	queryArguments := cypressutils.NewMap()
+	queryArguments.PutValue(":status", "inactive")
+
+	ctx := cypressutils.WithMaxAffectedRows(context.Background(), 2)
+	twrapper := cypressutils.DeleteContext(ctx, organizationId, "people", cypressutils.NewFilterPredicate("status = :status"), queryArguments)
+	if !twrapper.HasErrors || !strings.Contains(twrapper.GetErrors(), "5 rows of people affected where at most 2 are allowed") {
+		t.Fatalf("errors = %s, want the limit reported", twrapper.GetErrors())
+	}
+
+	var rolledBackToSavepoint bool
+	for _, statement := range fakeDB.GetStatements() {
+		rolledBackToSavepoint = rolledBackToSavepoint || strings.HasPrefix(statement.Query, "ROLLBACK TO SAVEPOINT")
+	}
+	if !rolledBackToSavepoint {
+		t.Errorf("statements = %v, want a rollback to the savepoint", fakeDB.GetStatements())
+	}
+	if fakeDB.GetRollbacks() != 1 || fakeDB.GetCommits() != 0 {
+		t.Errorf("rollbacks = %d, commits = %d, want 1 and 0", fakeDB.GetRollbacks(), fakeDB.GetCommits())
+	}
+}
+
+func TestMaxAffectedRowsCommitsWithinTheLimit(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t, cypressutils.MySQL)
+	fakeDB.Expect("DELETE").WithRowsAffected(2)
+
+	queryArguments := cypressutils.NewMap()
+	queryArguments.PutValue(":status", "inactive")
+
+	ctx := cypressutils.WithMaxAffectedRows(context.Background(), 2)
+	twrapper := cypressutils.DeleteContext(ctx, organizationId, "people", cypressutils.NewFilterPredicate("status = :status"), queryArguments)
+	if twrapper.HasErrors {
+		t.Fatalf("DeleteContext failed: %s", twrapper.GetErrors())
+	}
+	if fakeDB.GetCommits() != 1 || fakeDB.GetRollbacks() != 0 {
+		t.Errorf("commits = %d, rollbacks = %d, want 1 and 0", fakeDB.GetCommits(), fakeDB.GetRollbacks())
+	}
+}
@@ -0,0 +1,455 @@
+package cypressutils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_PING_INTERVAL         = 30 * time.Second
+	DEFAULT_PING_TIMEOUT          = 5 * time.Second
+	DEFAULT_MAX_PING_FAILURES     = 3
+	HEALTH_RECONNECT_MIN_BACKOFF  = time.Second
+	HEALTH_RECONNECT_MAX_BACKOFF  = 5 * time.Minute
+	HEALTH_RECONNECT_BACKOFF_RATE = 2
+	// DOWNSIZED_IDLE_CONNECTIONS are kept by a pool that has been quiet for DOWNSIZE_AFTER, enough for the pings
+	DOWNSIZED_IDLE_CONNECTIONS = 1
+)
+
+type HealthStatus struct {
+	OrganizationId      string
+	Healthy             bool
+	ConsecutiveFailures int
+	LastError           error
+	LastChecked         time.Time
+	LastHealthy         time.Time
+	NextReconnect       time.Time
+}
+
+// HealthChangeCallback is told every time an organization goes from healthy to unhealthy and back
+type HealthChangeCallback func(status HealthStatus)
+
+// HealthSupervisor pings every registered organization every PING_AFTER. After MAX_PING_FAILURES consecutive
+// failures the organization is unhealthy, GetConnection fails fast for it and the pool is rebuilt with
+// SetupDSN under an exponential backoff until it answers again. The pool of an organization that had no
+// connection in use for DOWNSIZE_AFTER keeps DOWNSIZED_IDLE_CONNECTIONS idle until it is used again. A ping waits
+// at most FIND_FREE_CONN_AFTER for a free connection, a pool that has every connection in use is busy, not failing
+type HealthSupervisor struct {
+	mutex             sync.RWMutex
+	pingInterval      time.Duration
+	pingTimeout       time.Duration
+	failureThreshold  int
+	downsizeAfter     time.Duration
+	findFreeConnAfter time.Duration
+	statuses          map[string]*HealthStatus
+	stopChannels      map[string]chan struct{}
+	callbacks         []HealthChangeCallback
+	waitGroup         sync.WaitGroup
+	stopped           bool
+}
+
+var healthSupervisorMutex sync.Mutex
+var healthSupervisor *HealthSupervisor
+
+func NewHealthSupervisor(pingInterval, pingTimeout time.Duration, failureThreshold int) *HealthSupervisor {
+	if pingInterval <= 0 {
+		pingInterval = DEFAULT_PING_INTERVAL
+	}
+	if pingTimeout <= 0 {
+		pingTimeout = DEFAULT_PING_TIMEOUT
+	}
+	if failureThreshold <= 0 {
+		failureThreshold = DEFAULT_MAX_PING_FAILURES
+	}
+
+	return &HealthSupervisor{
+		pingInterval:     pingInterval,
+		pingTimeout:      pingTimeout,
+		failureThreshold: failureThreshold,
+		statuses:         make(map[string]*HealthStatus),
+		stopChannels:     make(map[string]chan struct{}),
+	}
+}
+
+// StartHealthSupervisor supervises every organization registered now or later. Unless a supervisor is given it
+// is built from the SLING_RING PING_AFTER, PING_TIMEOUT, MAX_PING_FAILURES, DOWNSIZE_AFTER and FIND_FREE_CONN_AFTER
+// settings
+func StartHealthSupervisor(healthSupervisors ...*HealthSupervisor) *HealthSupervisor {
+	var supervisor *HealthSupervisor
+	if healthSupervisors != nil && healthSupervisors[0] != nil {
+		supervisor = healthSupervisors[0]
+	} else {
+		pingInterval, _ := ConfSlingRingPingInterval()
+		pingTimeout, _ := ConfSlingRingPingTimeout()
+		failureThreshold, _ := ConfSlingRingMaxPingFailures()
+		downsizeAfter, _ := ConfSlingRingDownSizeInterval()
+		findFreeConnAfter, _ := ConfSlingRingFindFreeConnInterval()
+
+		supervisor = NewHealthSupervisor(pingInterval, pingTimeout, failureThreshold).SetDownsizeAfter(downsizeAfter).
+			SetFindFreeConnAfter(findFreeConnAfter)
+	}
+
+	healthSupervisorMutex.Lock()
+	oldSupervisor := healthSupervisor
+	healthSupervisor = supervisor
+	healthSupervisorMutex.Unlock()
+
+	if oldSupervisor != nil {
+		oldSupervisor.Stop()
+	}
+
+	for _, organizationId := range registeredOrganizations() {
+		supervisor.supervise(organizationId)
+	}
+	return supervisor
+}
+
+func StopHealthSupervisor() {
+	healthSupervisorMutex.Lock()
+	supervisor := healthSupervisor
+	healthSupervisor = nil
+	healthSupervisorMutex.Unlock()
+
+	if supervisor != nil {
+		supervisor.Stop()
+	}
+}
+
+func GetHealthSupervisor() *HealthSupervisor {
+	healthSupervisorMutex.Lock()
+	defer healthSupervisorMutex.Unlock()
+	return healthSupervisor
+}
+
+// IsHealthy is true for organizations that are not (yet) known to be failing, including unsupervised ones
+func IsHealthy(organizationId string) bool {
+	supervisor := GetHealthSupervisor()
+	if supervisor == nil {
+		return true
+	}
+
+	status, exists := supervisor.GetStatus(organizationId)
+	return !exists || status.Healthy
+}
+
+// IsReady reports whether the master database is reachable, which is what readiness probes should ask
+func IsReady() bool {
+	_, exists := lookupConnectionPool(MASTER_ORGANIZATION_ID)
+	return exists && IsHealthy(MASTER_ORGANIZATION_ID)
+}
+
+// SetDownsizeAfter sets how long a pool has to go without a connection in use before its idle connections are
+// closed, zero never downsizes
+func (supervisor *HealthSupervisor) SetDownsizeAfter(downsizeAfter time.Duration) *HealthSupervisor {
+	supervisor.downsizeAfter = downsizeAfter
+	return supervisor
+}
+
+// SetFindFreeConnAfter sets how long a ping waits for a free connection of the pool, zero waits up to the ping timeout
+func (supervisor *HealthSupervisor) SetFindFreeConnAfter(findFreeConnAfter time.Duration) *HealthSupervisor {
+	supervisor.findFreeConnAfter = findFreeConnAfter
+	return supervisor
+}
+
+func (supervisor *HealthSupervisor) OnHealthChange(callback HealthChangeCallback) {
+	supervisor.mutex.Lock()
+	supervisor.callbacks = append(supervisor.callbacks, callback)
+	supervisor.mutex.Unlock()
+}
+
+func (supervisor *HealthSupervisor) GetStatus(organizationId string) (HealthStatus, bool) {
+	supervisor.mutex.RLock()
+	defer supervisor.mutex.RUnlock()
+
+	status, exists := supervisor.statuses[organizationId]
+	if !exists {
+		return HealthStatus{}, false
+	}
+	return *status, true
+}
+
+func (supervisor *HealthSupervisor) GetAllStatuses() []HealthStatus {
+	supervisor.mutex.RLock()
+	defer supervisor.mutex.RUnlock()
+
+	statuses := make([]HealthStatus, 0, len(supervisor.statuses))
+	for _, status := range supervisor.statuses {
+		statuses = append(statuses, *status)
+	}
+	return statuses
+}
+
+func (supervisor *HealthSupervisor) Stop() {
+	supervisor.mutex.Lock()
+	supervisor.stopped = true
+	for organizationId, stopChannel := range supervisor.stopChannels {
+		close(stopChannel)
+		delete(supervisor.stopChannels, organizationId)
+	}
+	supervisor.mutex.Unlock()
+
+	supervisor.waitGroup.Wait()
+}
+
+// supervise starts the organization's goroutine once. Calling it again, e.g. after a reconnect, only
+// resets the organization to healthy since its pool was just pinged
+func (supervisor *HealthSupervisor) supervise(organizationId string) {
+	supervisor.mutex.Lock()
+	defer supervisor.mutex.Unlock()
+
+	now := time.Now()
+	supervisor.statuses[organizationId] = &HealthStatus{
+		OrganizationId: organizationId,
+		Healthy:        true,
+		LastChecked:    now,
+		LastHealthy:    now,
+	}
+
+	if _, exists := supervisor.stopChannels[organizationId]; exists || supervisor.stopped {
+		return
+	}
+
+	stopChannel := make(chan struct{})
+	supervisor.stopChannels[organizationId] = stopChannel
+
+	supervisor.waitGroup.Add(1)
+	go supervisor.run(organizationId, stopChannel)
+}
+
+func (supervisor *HealthSupervisor) unsupervise(organizationId string) {
+	supervisor.mutex.Lock()
+	defer supervisor.mutex.Unlock()
+
+	if stopChannel, exists := supervisor.stopChannels[organizationId]; exists {
+		close(stopChannel)
+		delete(supervisor.stopChannels, organizationId)
+	}
+	delete(supervisor.statuses, organizationId)
+}
+
+func (supervisor *HealthSupervisor) run(organizationId string, stopChannel chan struct{}) {
+	defer supervisor.waitGroup.Done()
+
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Error(fmt.Sprintf("HEALTH SUPERVISOR: Panic where Organization Id = '%s': %v", organizationId, r))
+		}
+	}()
+
+	backoff := HEALTH_RECONNECT_MIN_BACKOFF
+	timer := time.NewTimer(supervisor.pingInterval)
+	defer timer.Stop()
+
+	usage := &poolUsage{}
+
+	for {
+		select {
+		case <-stopChannel:
+			return
+		case <-timer.C:
+		}
+
+		if supervisor.check(organizationId) {
+			supervisor.downsize(organizationId, usage)
+			backoff = HEALTH_RECONNECT_MIN_BACKOFF
+			timer.Reset(supervisor.pingInterval)
+			continue
+		}
+
+		status, _ := supervisor.GetStatus(organizationId)
+		if status.Healthy {
+			timer.Reset(supervisor.pingInterval)
+			continue
+		}
+
+		if supervisor.reconnect(organizationId, backoff) {
+			backoff = HEALTH_RECONNECT_MIN_BACKOFF
+			timer.Reset(supervisor.pingInterval)
+			continue
+		}
+
+		timer.Reset(backoff)
+		backoff *= HEALTH_RECONNECT_BACKOFF_RATE
+		if backoff > HEALTH_RECONNECT_MAX_BACKOFF {
+			backoff = HEALTH_RECONNECT_MAX_BACKOFF
+		}
+	}
+}
+
+func (supervisor *HealthSupervisor) check(organizationId string) bool {
+	dbPool, exists := lookupConnectionPool(organizationId)
+	if !exists {
+		supervisor.unsupervise(organizationId)
+		return true
+	}
+
+	findFreeConnAfter := supervisor.findFreeConnAfter
+	if findFreeConnAfter <= 0 {
+		findFreeConnAfter = supervisor.pingTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), findFreeConnAfter)
+	dbConn, err := dbPool.Conn(ctx)
+	cancel()
+
+	//EVERY CONNECTION IS IN USE, THE SERVER IS ANSWERING THE QUERIES THAT HOLD THEM
+	if stats := dbPool.Stats(); err != nil && stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+		logrus.Warn(fmt.Sprintf("HEALTH SUPERVISOR: No free connection after %v where Organization Id = '%s', %d in use",
+			findFreeConnAfter, organizationId, stats.InUse))
+		return true
+	}
+
+	if err == nil {
+		ctx, cancel = context.WithTimeout(context.Background(), supervisor.pingTimeout)
+		err = dbConn.PingContext(ctx)
+		cancel()
+		dbConn.Close()
+	}
+
+	if err != nil {
+		supervisor.recordFailure(organizationId, err, time.Time{})
+		return false
+	}
+
+	supervisor.recordSuccess(organizationId)
+	return true
+}
+
+// poolUsage is what the goroutine of an organization remembers of its pool between two pings
+type poolUsage struct {
+	dbPool    *sql.DB
+	lastBusy  time.Time
+	waitCount int64
+	downsized bool
+}
+
+// downsize closes the idle connections of a pool that has been quiet for downsizeAfter and gives the pool back
+// its MaxIdleConnections as soon as it is busy again. The pool is sampled at every ping, a pool that had a
+// connection in use or a caller waiting for one is busy
+func (supervisor *HealthSupervisor) downsize(organizationId string, usage *poolUsage) {
+	if supervisor.downsizeAfter <= 0 {
+		return
+	}
+
+	dbPool, exists := lookupConnectionPool(organizationId)
+	conDSN, _ := lookupConDSN(organizationId)
+	if !exists || conDSN == nil || conDSN.MaxIdleConnections <= DOWNSIZED_IDLE_CONNECTIONS {
+		return
+	}
+
+	now := time.Now()
+	stats := dbPool.Stats()
+
+	//A RECONNECT SWAPPED THE POOL, THE NEW ONE STARTS WITH THE CONFIGURED IDLE CONNECTIONS
+	if usage.dbPool != dbPool {
+		*usage = poolUsage{dbPool: dbPool, lastBusy: now, waitCount: stats.WaitCount}
+		return
+	}
+
+	busy := stats.InUse > 0 || stats.WaitCount != usage.waitCount
+	usage.waitCount = stats.WaitCount
+
+	switch {
+	case busy:
+		usage.lastBusy = now
+		if usage.downsized {
+			dbPool.SetMaxIdleConns(conDSN.MaxIdleConnections)
+			usage.downsized = false
+		}
+	case !usage.downsized && now.Sub(usage.lastBusy) >= supervisor.downsizeAfter:
+		logrus.Info(fmt.Sprintf("HEALTH SUPERVISOR: Downsizing idle connections where Organization Id = '%s' from %d to %d",
+			organizationId, stats.Idle, DOWNSIZED_IDLE_CONNECTIONS))
+		dbPool.SetMaxIdleConns(DOWNSIZED_IDLE_CONNECTIONS)
+		usage.downsized = true
+	}
+}
+
+func (supervisor *HealthSupervisor) reconnect(organizationId string, backoff time.Duration) bool {
+	conDSN, exists := lookupConDSN(organizationId)
+	if !exists {
+		supervisor.unsupervise(organizationId)
+		return true
+	}
+
+	logrus.Warn(fmt.Sprintf("HEALTH SUPERVISOR: Reconnecting where Organization Id = '%s'", organizationId))
+
+	//SetupDSN MARKS THE ORGANIZATION HEALTHY AGAIN ON SUCCESS
+	err := SetupDSN(organizationId, conDSN)
+	if err != nil {
+		supervisor.recordFailure(organizationId, err, time.Now().Add(backoff))
+		return false
+	}
+
+	supervisor.notify(organizationId)
+	return true
+}
+
+func (supervisor *HealthSupervisor) recordSuccess(organizationId string) {
+	supervisor.mutex.Lock()
+	status, exists := supervisor.statuses[organizationId]
+	if !exists {
+		supervisor.mutex.Unlock()
+		return
+	}
+
+	wasHealthy := status.Healthy
+	now := time.Now()
+	status.Healthy = true
+	status.ConsecutiveFailures = 0
+	status.LastError = nil
+	status.LastChecked = now
+	status.LastHealthy = now
+	status.NextReconnect = time.Time{}
+	supervisor.mutex.Unlock()
+
+	if !wasHealthy {
+		supervisor.notify(organizationId)
+	}
+}
+
+func (supervisor *HealthSupervisor) recordFailure(organizationId string, err error, nextReconnect time.Time) {
+	supervisor.mutex.Lock()
+	status, exists := supervisor.statuses[organizationId]
+	if !exists {
+		supervisor.mutex.Unlock()
+		return
+	}
+
+	wasHealthy := status.Healthy
+	status.ConsecutiveFailures++
+	status.LastError = err
+	status.LastChecked = time.Now()
+	status.NextReconnect = nextReconnect
+	if status.ConsecutiveFailures >= supervisor.failureThreshold {
+		status.Healthy = false
+	}
+	healthy := status.Healthy
+	failures := status.ConsecutiveFailures
+	supervisor.mutex.Unlock()
+
+	logrus.Error(fmt.Sprintf("HEALTH SUPERVISOR: Ping failed %d time(s) where Organization Id = '%s': %v", failures, organizationId, err))
+
+	if wasHealthy && !healthy {
+		supervisor.notify(organizationId)
+	}
+}
+
+func (supervisor *HealthSupervisor) notify(organizationId string) {
+	supervisor.mutex.RLock()
+	callbacks := make([]HealthChangeCallback, len(supervisor.callbacks))
+	copy(callbacks, supervisor.callbacks)
+	supervisor.mutex.RUnlock()
+
+	status, exists := supervisor.GetStatus(organizationId)
+	if !exists {
+		return
+	}
+
+	for _, callback := range callbacks {
+		callback(status)
+	}
+}
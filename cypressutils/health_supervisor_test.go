@@ -0,0 +1,35 @@
+package cypressutils_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+func TestHealthSupervisorTakesAPoolWithoutFreeConnectionsAsBusy(t *testing.T) {
+	organizationId, _ := registerFakeOrganization(t)
+
+	dbPool, err := cypressutils.GetConnection(organizationId)
+	if err != nil {
+		t.Fatalf("GetConnection: %v", err)
+	}
+	dbPool.SetMaxOpenConns(1)
+	dbConn, err := dbPool.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("taking the only connection: %v", err)
+	}
+	defer dbConn.Close()
+
+	supervisor := cypressutils.NewHealthSupervisor(10*time.Millisecond, time.Second, 1).SetFindFreeConnAfter(5 * time.Millisecond)
+	cypressutils.StartHealthSupervisor(supervisor)
+	t.Cleanup(cypressutils.StopHealthSupervisor)
+
+	time.Sleep(100 * time.Millisecond)
+
+	status, exists := supervisor.GetStatus(organizationId)
+	if !exists || !status.Healthy || status.ConsecutiveFailures != 0 {
+		t.Errorf("status = %+v, want a healthy organization without failures", status)
+	}
+}
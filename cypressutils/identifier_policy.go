@@ -0,0 +1,262 @@
+package cypressutils
+
+import (
+	cErrors "github.com/pkg/errors"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// identifierPartPattern is a plain name or one quoted by any of the dialects, see Dialect.QuoteIdentifier
+const identifierPartPattern = "(?:[A-Za-z_][A-Za-z0-9_$#]*|\"[^\"]+\"|\\[[^\\]]+\\]|`[^`]+`)"
+const identifierPattern = identifierPartPattern + "(?:\\." + identifierPartPattern + ")*"
+const aggregateCallPattern = "(?:COUNT|MAX|MIN|AVG|SUM)\\(\\s*(?:DISTINCT\\s+)?(?:" + identifierPattern + "|\\*)\\s*\\)"
+
+var identifierRegex = regexp.MustCompile("^" + identifierPattern + "$")
+var identifierPartRegex = regexp.MustCompile(identifierPartPattern + "|\\*")
+var identifierTokenRegex = regexp.MustCompile(identifierPattern)
+
+// plainIdentifierRegex is for names that come from API clients, e.g. the columns of a filter: no quoting and
+// at most database.schema.table or alias.column
+var plainIdentifierRegex = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_]*(?:\\.[A-Za-z_][A-Za-z0-9_]*){0,2}$")
+
+// selectItemRegex is a column, alias.*, *, a number or an aggregate of a column, with an optional alias.
+// The column it reads is the first submatch
+var selectItemRegex = regexp.MustCompile("(?i)^(?:DISTINCT\\s+)?(?:(" + identifierPattern + ")(\\.\\*)?|\\*|\\d+|" + aggregateCallPattern +
+	")(?:\\s+(?:AS\\s+)?" + identifierPattern + ")?$")
+var orderByItemRegex = regexp.MustCompile("(?i)^(?:" + identifierPattern + "|\\d+|" + aggregateCallPattern +
+	")(?:\\s+(?:ASC|DESC))?(?:\\s+NULLS\\s+(?:FIRST|LAST))?$")
+var groupByItemRegex = regexp.MustCompile("^(?:" + identifierPattern + "|\\d+)$")
+var aggregateColumnRegex = regexp.MustCompile("(?i)^" + "(?:DISTINCT\\s+)?(?:COUNT|MAX|MIN|AVG|SUM)\\(\\s*(?:DISTINCT\\s+)?(" + identifierPattern + ")?")
+
+// ColumnAllowlist restricts, per table, the columns the query builder accepts. Tables without a list are
+// not restricted
+type ColumnAllowlist struct {
+	mutex  sync.RWMutex
+	tables map[string]map[string]bool
+}
+
+var columnAllowlist = &ColumnAllowlist{tables: make(map[string]map[string]bool)}
+
+// AllowColumns limits tableName to columns, replacing the list it had. Columns outside of it are then
+// refused wherever the builder can tell which table they belong to
+func AllowColumns(tableName string, columns ...string) {
+	allowed := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		allowed[identifierKey(column)] = true
+	}
+
+	columnAllowlist.mutex.Lock()
+	defer columnAllowlist.mutex.Unlock()
+	columnAllowlist.tables[identifierKey(tableName)] = allowed
+}
+
+func RemoveAllowedColumns(tableName string) {
+	columnAllowlist.mutex.Lock()
+	defer columnAllowlist.mutex.Unlock()
+	delete(columnAllowlist.tables, identifierKey(tableName))
+}
+
+func IsColumnAllowed(tableName, column string) bool {
+	columnAllowlist.mutex.RLock()
+	defer columnAllowlist.mutex.RUnlock()
+
+	allowed, exists := columnAllowlist.tables[identifierKey(tableName)]
+	if !exists {
+		allowed, exists = columnAllowlist.tables[identifierKey(tableName[strings.LastIndex(tableName, ".")+1:])]
+	}
+	if !exists {
+		return true
+	}
+	return allowed[identifierKey(column[strings.LastIndex(column, ".")+1:])]
+}
+
+func (allowlist *ColumnAllowlist) isEmpty() bool {
+	allowlist.mutex.RLock()
+	defer allowlist.mutex.RUnlock()
+	return len(allowlist.tables) == 0
+}
+
+// ValidateIdentifier accepts table and column names, optionally qualified, e.g. schema.table or alias.column,
+// plain or quoted for the dialect. Anything else is refused
+func ValidateIdentifier(identifier string) error {
+	return validateIdentifier(identifier, "IDENTIFIER: ")
+}
+
+func validateIdentifier(identifier, errorLocation string) error {
+	if !identifierRegex.MatchString(identifier) {
+		err := cErrors.New(errorLocation + "'" + identifier + "' is not a valid identifier")
+		ThrowException(err)
+		return err
+	}
+	return nil
+}
+
+func validateTableReference(tableName, errorLocation string) error {
+	reference := parseTableReference(tableName)
+	if reference == nil {
+		err := cErrors.New(errorLocation + "'" + tableName + "' is not a valid table name")
+		ThrowException(err)
+		return err
+	}
+
+	if err := validateIdentifier(reference.table, errorLocation); err != nil {
+		return err
+	}
+	if reference.alias != "" {
+		return validateIdentifier(reference.alias, errorLocation)
+	}
+	return nil
+}
+
+// validateList checks every comma separated item of a SELECT, ORDER BY or GROUP BY list against itemRegex
+func validateList(list string, itemRegex *regexp.Regexp, errorLocation string) error {
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); !itemRegex.MatchString(item) {
+			err := cErrors.New(errorLocation + "'" + item + "' is not a column, use the typed columns or SelectExpression for expressions")
+			ThrowException(err)
+			return err
+		}
+	}
+	return nil
+}
+
+/**************** QUOTING ****************/
+
+// listKeywords are the words of a SELECT, ORDER BY or GROUP BY item that are not columns
+var listKeywords = map[string]bool{"AS": true, "ASC": true, "DESC": true, "NULLS": true, "FIRST": true, "LAST": true, "DISTINCT": true}
+
+// quoteIdentifier quotes a table or column name, optionally qualified or followed by .*, for the dialect.
+// Anything else, e.g. an expression, is left as it is
+func quoteIdentifier(dialect Dialect, identifier string) string {
+	if dialect == nil || !identifierRegex.MatchString(strings.TrimSuffix(identifier, ".*")) {
+		return identifier
+	}
+	return dialect.QuoteIdentifier(identifier)
+}
+
+func quoteIdentifiers(dialect Dialect, identifiers []string) []string {
+	if identifiers == nil {
+		return nil
+	}
+
+	quoted := make([]string, len(identifiers))
+	for index, identifier := range identifiers {
+		quoted[index] = quoteIdentifier(dialect, identifier)
+	}
+	return quoted
+}
+
+// quoteTableReference quotes the table and the alias of "table alias" and "table AS alias"
+func quoteTableReference(dialect Dialect, tableName string) string {
+	fields := strings.Fields(tableName)
+	if dialect == nil || parseTableReference(tableName) == nil {
+		return tableName
+	}
+
+	fields[0] = quoteIdentifier(dialect, fields[0])
+	if len(fields) > 1 {
+		fields[len(fields)-1] = quoteIdentifier(dialect, fields[len(fields)-1])
+	}
+	return strings.Join(fields, " ")
+}
+
+// quoteList quotes the columns and aliases of the items of a comma separated list that itemRegex accepts,
+// leaving the keywords, function names and positions alone. The items it does not accept are left as they are
+func quoteList(dialect Dialect, list string, itemRegex *regexp.Regexp) string {
+	if dialect == nil {
+		return list
+	}
+
+	items := strings.Split(list, ",")
+	for index, item := range items {
+		if !itemRegex.MatchString(strings.TrimSpace(item)) {
+			continue
+		}
+
+		var buf strings.Builder
+		previousEnd := 0
+		for _, bounds := range identifierTokenRegex.FindAllStringIndex(item, -1) {
+			token := item[bounds[0]:bounds[1]]
+			buf.WriteString(item[previousEnd:bounds[0]])
+			if listKeywords[strings.ToUpper(token)] || strings.HasPrefix(strings.TrimSpace(item[bounds[1]:]), "(") {
+				buf.WriteString(token)
+			} else {
+				buf.WriteString(dialect.QuoteIdentifier(token))
+			}
+			previousEnd = bounds[1]
+		}
+		buf.WriteString(item[previousEnd:])
+		items[index] = buf.String()
+	}
+	return strings.Join(items, ",")
+}
+
+/**************** ALLOWLIST ****************/
+
+func (statement *queryStatement) checkAllowedColumns() error {
+	if columnAllowlist.isEmpty() {
+		return nil
+	}
+
+	columns := append([]string(nil), statement.insertColumns...)
+	for _, assignment := range statement.assignments {
+		columns = append(columns, assignment.column)
+	}
+	for _, item := range statement.selectItems {
+		if item.column != nil {
+			columns = append(columns, selectedColumns(item.column.ColumnName)...)
+		}
+	}
+	columns = append(columns, statement.filterColumns...)
+
+	for _, column := range columns {
+		if column == "" || column == "*" {
+			continue
+		}
+		if tableName := statement.columnTable(column); tableName != "" && !IsColumnAllowed(tableName, column) {
+			return cErrors.New("COLUMN: '" + column + "' is not allowed on " + tableName)
+		}
+	}
+	return nil
+}
+
+// columnTable is the table a column belongs to, through its qualifier or the table the statement is on
+func (statement *queryStatement) columnTable(column string) string {
+	index := strings.LastIndex(column, ".")
+	if index <= 0 {
+		if statement.table != "" {
+			return statement.table
+		}
+		if len(statement.tables) > 0 {
+			return statement.tables[0].table
+		}
+		return ""
+	}
+
+	qualifier := column[:index]
+	for _, reference := range statement.tables {
+		if strings.EqualFold(reference.alias, qualifier) {
+			return reference.table
+		}
+	}
+	return qualifier
+}
+
+// selectedColumns picks the columns out of a SelectColumn list, leaving out numbers, * and the aliases
+func selectedColumns(list string) []string {
+	var columns []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if matches := aggregateColumnRegex.FindStringSubmatch(item); matches != nil {
+			columns = append(columns, matches[1])
+		} else if matches = selectItemRegex.FindStringSubmatch(item); matches != nil && matches[2] == "" {
+			columns = append(columns, matches[1])
+		}
+	}
+	return columns
+}
+
+func identifierKey(identifier string) string {
+	return strings.ToLower(strings.NewReplacer("\"", "", "`", "", "[", "", "]", "").Replace(strings.TrimSpace(identifier)))
+}
@@ -0,0 +1,79 @@
+package cypressutils_test
+
+import (
+	"testing"
+
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+func TestQuoteIdentifierFoldsLikeTheServer(t *testing.T) {
+	tests := []struct {
+		databaseServer cypressutils.DbTypes
+		identifier     string
+		want           string
+	}{
+		{cypressutils.PostgreSQL, "People", `"people"`},
+		{cypressutils.PostgreSQL, `public."People"`, `"public"."People"`},
+		{cypressutils.PostgreSQL, "p.*", `"p".*`},
+		{cypressutils.PostgreSQL, `a"b`, `"a""b"`},
+		{cypressutils.MySQL, "People", "`People`"},
+		{cypressutils.MySQL, `public."People"`, "`public`.`People`"},
+		{cypressutils.MicrosoftSQL, "dbo.[Order Lines]", "[dbo].[Order Lines]"},
+		{cypressutils.Oracle, "People", `"PEOPLE"`},
+		{cypressutils.Oracle, `hr."People"`, `"HR"."People"`},
+	}
+
+	for _, test := range tests {
+		if quoted := cypressutils.GetDialect(test.databaseServer).QuoteIdentifier(test.identifier); quoted != test.want {
+			t.Errorf("%s: QuoteIdentifier(%s) = %s, want %s", test.databaseServer, test.identifier, quoted, test.want)
+		}
+	}
+}
+
+func TestValidateIdentifier(t *testing.T) {
+	for _, identifier := range []string{"people", "public.people", "p.name", `"Order Lines"`, "[Order Lines]", "`order`"} {
+		if err := cypressutils.ValidateIdentifier(identifier); err != nil {
+			t.Errorf("%s refused: %v", identifier, err)
+		}
+	}
+	for _, identifier := range []string{"", "1people", "people p", "name; DROP TABLE people", "name--", "(SELECT 1)"} {
+		if err := cypressutils.ValidateIdentifier(identifier); err == nil {
+			t.Errorf("%s accepted", identifier)
+		}
+	}
+}
+
+func TestRepositoryRefusesAnOrderByThatIsNotAColumn(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+
+	twrapper := cypressutils.SelectWhereOrderBy(organizationId, "people", "id, name", nil, "name; DROP TABLE people",
+		cypressutils.NewMap(), []int{1, 10})
+	if !twrapper.HasErrors {
+		t.Fatal("SelectWhereOrderBy accepted an injected ORDER BY")
+	}
+	if len(fakeDB.GetStatements()) != 0 {
+		t.Errorf("%d statements run, the first is %s", len(fakeDB.GetStatements()), fakeDB.GetStatements()[0].Query)
+	}
+}
+
+func TestAllowlistRefusesOtherColumns(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+	cypressutils.AllowColumns("allowlisted_people", "id", "name")
+	t.Cleanup(func() {
+		cypressutils.RemoveAllowedColumns("allowlisted_people")
+	})
+
+	if twrapper := cypressutils.Select(organizationId, "allowlisted_people", "id, salary", nil); !twrapper.HasErrors {
+		t.Error("Select of a column outside the allowlist succeeded")
+	}
+	if len(fakeDB.GetStatements()) != 0 {
+		t.Errorf("%d statements run for a refused column", len(fakeDB.GetStatements()))
+	}
+
+	if twrapper := cypressutils.Select(organizationId, "allowlisted_people", "id, name", nil); twrapper.HasErrors {
+		t.Errorf("Select of allowed columns failed: %s", twrapper.GetErrors())
+	}
+	if !cypressutils.IsColumnAllowed("public.allowlisted_people", "NAME") || cypressutils.IsColumnAllowed("allowlisted_people", "salary") {
+		t.Error("IsColumnAllowed does not follow the allowlist")
+	}
+}
@@ -0,0 +1,279 @@
+package cypressutils
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const METRICS_NAMESPACE = "cypress_db"
+
+// DEFAULT_LATENCY_BUCKETS are the upper bounds, in seconds, of the query duration histogram
+var DEFAULT_LATENCY_BUCKETS = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type queryMetricsKey struct {
+	organizationId string
+	operation      string
+}
+
+type queryMetrics struct {
+	count        uint64
+	errors       uint64
+	timeouts     uint64
+	sum          float64
+	bucketCounts []uint64
+}
+
+// MetricsCollector counts what the executors run per organization and operation and renders it, together with
+// the sql.DBStats of every registered pool, in the Prometheus text exposition format
+type MetricsCollector struct {
+	mutex   sync.Mutex
+	buckets []float64
+	queries map[queryMetricsKey]*queryMetrics
+}
+
+var metricsCollectorMutex sync.RWMutex
+var metricsCollector = NewMetricsCollector()
+
+func NewMetricsCollector(buckets ...float64) *MetricsCollector {
+	if buckets == nil {
+		buckets = DEFAULT_LATENCY_BUCKETS
+	}
+
+	sortedBuckets := make([]float64, len(buckets))
+	copy(sortedBuckets, buckets)
+	sort.Float64s(sortedBuckets)
+
+	return &MetricsCollector{
+		buckets: sortedBuckets,
+		queries: make(map[queryMetricsKey]*queryMetrics),
+	}
+}
+
+func GetMetricsCollector() *MetricsCollector {
+	metricsCollectorMutex.RLock()
+	defer metricsCollectorMutex.RUnlock()
+	return metricsCollector
+}
+
+// SetMetricsCollector swaps the collector the executors record into, e.g. for one with other buckets
+func SetMetricsCollector(collector *MetricsCollector) {
+	metricsCollectorMutex.Lock()
+	metricsCollector = collector
+	metricsCollectorMutex.Unlock()
+}
+
+// MetricsHandler serves the metrics of the current collector, mount it on /metrics
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		GetMetricsCollector().ServeHTTP(writer, request)
+	})
+}
+
+func (collector *MetricsCollector) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if err := collector.WriteMetrics(writer); err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// RecordQuery adds one statement to the counters and the duration histogram
+func (collector *MetricsCollector) RecordQuery(organizationId, operation string, duration time.Duration, failed, timedOut bool) {
+	key := queryMetricsKey{organizationId: organizationId, operation: operation}
+	seconds := duration.Seconds()
+
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	metrics, exists := collector.queries[key]
+	if !exists {
+		metrics = &queryMetrics{bucketCounts: make([]uint64, len(collector.buckets))}
+		collector.queries[key] = metrics
+	}
+
+	metrics.count++
+	metrics.sum += seconds
+	if failed {
+		metrics.errors++
+	}
+	if timedOut {
+		metrics.timeouts++
+	}
+
+	for index, upperBound := range collector.buckets {
+		if seconds <= upperBound {
+			metrics.bucketCounts[index]++
+		}
+	}
+}
+
+func (collector *MetricsCollector) Reset() {
+	collector.mutex.Lock()
+	collector.queries = make(map[queryMetricsKey]*queryMetrics)
+	collector.mutex.Unlock()
+}
+
+func (collector *MetricsCollector) WriteMetrics(writer io.Writer) error {
+	bufferedWriter := bufio.NewWriter(writer)
+
+	collector.writePoolMetrics(bufferedWriter)
+	collector.writeQueryMetrics(bufferedWriter)
+
+	return bufferedWriter.Flush()
+}
+
+func (collector *MetricsCollector) writePoolMetrics(writer *bufio.Writer) {
+	organizationIds := registeredOrganizations()
+	sort.Strings(organizationIds)
+
+	stats := make(map[string]sql.DBStats, len(organizationIds))
+	for _, organizationId := range organizationIds {
+		if dbPool, exists := lookupConnectionPool(organizationId); exists {
+			stats[organizationId] = dbPool.Stats()
+		}
+	}
+
+	poolMetrics := []struct {
+		name, metricType, help string
+		value                  func(stats sql.DBStats) float64
+	}{
+		{"pool_max_open_connections", "gauge", "Maximum number of open connections allowed.",
+			func(stats sql.DBStats) float64 { return float64(stats.MaxOpenConnections) }},
+		{"pool_open_connections", "gauge", "Established connections, in use and idle.",
+			func(stats sql.DBStats) float64 { return float64(stats.OpenConnections) }},
+		{"pool_in_use_connections", "gauge", "Connections currently in use.",
+			func(stats sql.DBStats) float64 { return float64(stats.InUse) }},
+		{"pool_idle_connections", "gauge", "Idle connections.",
+			func(stats sql.DBStats) float64 { return float64(stats.Idle) }},
+		{"pool_wait_count_total", "counter", "Connections waited for.",
+			func(stats sql.DBStats) float64 { return float64(stats.WaitCount) }},
+		{"pool_wait_duration_seconds_total", "counter", "Time blocked waiting for a new connection.",
+			func(stats sql.DBStats) float64 { return stats.WaitDuration.Seconds() }},
+		{"pool_max_idle_closed_total", "counter", "Connections closed because of SetMaxIdleConns.",
+			func(stats sql.DBStats) float64 { return float64(stats.MaxIdleClosed) }},
+		{"pool_max_idle_time_closed_total", "counter", "Connections closed because of SetConnMaxIdleTime.",
+			func(stats sql.DBStats) float64 { return float64(stats.MaxIdleTimeClosed) }},
+		{"pool_max_lifetime_closed_total", "counter", "Connections closed because of SetConnMaxLifetime.",
+			func(stats sql.DBStats) float64 { return float64(stats.MaxLifetimeClosed) }},
+	}
+
+	for _, poolMetric := range poolMetrics {
+		name := METRICS_NAMESPACE + "_" + poolMetric.name
+		writeMetricHeader(writer, name, poolMetric.metricType, poolMetric.help)
+
+		for _, organizationId := range organizationIds {
+			organizationStats, exists := stats[organizationId]
+			if !exists {
+				continue
+			}
+			writeSample(writer, name, metricLabels("organization_id", organizationId), poolMetric.value(organizationStats))
+		}
+	}
+}
+
+func (collector *MetricsCollector) writeQueryMetrics(writer *bufio.Writer) {
+	collector.mutex.Lock()
+	keys := make([]queryMetricsKey, 0, len(collector.queries))
+	snapshot := make(map[queryMetricsKey]queryMetrics, len(collector.queries))
+	for key, metrics := range collector.queries {
+		keys = append(keys, key)
+
+		copied := *metrics
+		copied.bucketCounts = append([]uint64(nil), metrics.bucketCounts...)
+		snapshot[key] = copied
+	}
+	collector.mutex.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].organizationId != keys[j].organizationId {
+			return keys[i].organizationId < keys[j].organizationId
+		}
+		return keys[i].operation < keys[j].operation
+	})
+
+	counters := []struct {
+		name, help string
+		value      func(metrics queryMetrics) uint64
+	}{
+		{"queries_total", "Statements executed.", func(metrics queryMetrics) uint64 { return metrics.count }},
+		{"query_errors_total", "Statements that failed.", func(metrics queryMetrics) uint64 { return metrics.errors }},
+		{"query_timeouts_total", "Statements cancelled by the query timeout.", func(metrics queryMetrics) uint64 { return metrics.timeouts }},
+	}
+
+	for _, counter := range counters {
+		name := METRICS_NAMESPACE + "_" + counter.name
+		writeMetricHeader(writer, name, "counter", counter.help)
+
+		for _, key := range keys {
+			labels := metricLabels("organization_id", key.organizationId, "operation", key.operation)
+			writeSample(writer, name, labels, float64(counter.value(snapshot[key])))
+		}
+	}
+
+	name := METRICS_NAMESPACE + "_query_duration_seconds"
+	writeMetricHeader(writer, name, "histogram", "Statement latency.")
+
+	for _, key := range keys {
+		metrics := snapshot[key]
+
+		for index, upperBound := range collector.buckets {
+			labels := metricLabels("organization_id", key.organizationId, "operation", key.operation,
+				"le", strconv.FormatFloat(upperBound, 'g', -1, 64))
+			writeSample(writer, name+"_bucket", labels, float64(metrics.bucketCounts[index]))
+		}
+
+		labels := metricLabels("organization_id", key.organizationId, "operation", key.operation, "le", "+Inf")
+		writeSample(writer, name+"_bucket", labels, float64(metrics.count))
+
+		labels = metricLabels("organization_id", key.organizationId, "operation", key.operation)
+		writeSample(writer, name+"_sum", labels, metrics.sum)
+		writeSample(writer, name+"_count", labels, float64(metrics.count))
+	}
+}
+
+/**************** UTILITY FUNCTIONS ****************/
+
+// recordQueryMetrics is deferred by the executors right after the wrapper is created
+func recordQueryMetrics(organizationId, operation string, started time.Time, twrapper *TransactionWrapper) {
+	if twrapper == nil {
+		return
+	}
+	GetMetricsCollector().RecordQuery(organizationId, operation, time.Since(started), twrapper.HasErrors, twrapper.HasTimedOut)
+}
+
+func writeMetricHeader(writer *bufio.Writer, name, metricType, help string) {
+	fmt.Fprintf(writer, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(writer, "# TYPE %s %s\n", name, metricType)
+}
+
+func writeSample(writer *bufio.Writer, name, labels string, value float64) {
+	fmt.Fprintf(writer, "%s{%s} %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func metricLabels(namesAndValues ...string) string {
+	var labels strings.Builder
+	for index := 0; index+1 < len(namesAndValues); index += 2 {
+		if index > 0 {
+			labels.WriteString(",")
+		}
+		labels.WriteString(namesAndValues[index])
+		labels.WriteString(`="`)
+		labels.WriteString(escapeLabelValue(namesAndValues[index+1]))
+		labels.WriteString(`"`)
+	}
+	return labels.String()
+}
+
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
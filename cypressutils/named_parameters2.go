@@ -2,20 +2,27 @@ package cypressutils
 
 import (
 	"bytes"
-	"fmt"
+	"slices"
 )
 
 type NamedParameterQuery struct {
-	parameters     []interface{}
-	queryArguments *CypressHashMap
-	originalQuery  string
-	parsedQuery    string
+	parameters        []interface{}
+	missingParameters []string
+	queryArguments    *CypressHashMap
+	originalQuery     string
+	parsedQuery       string
+	dialect           Dialect
 }
 
-func NewNamedParameterQuery(sqlQuery string, queryArguments *CypressHashMap) *NamedParameterQuery {
+func NewNamedParameterQuery(sqlQuery string, queryArguments *CypressHashMap, dialect ...Dialect) *NamedParameterQuery {
 	temp := &NamedParameterQuery{
 		originalQuery:  sqlQuery,
 		queryArguments: queryArguments,
+		dialect:        GetDialect(PostgreSQL),
+	}
+
+	if dialect != nil && dialect[0] != nil {
+		temp.dialect = dialect[0]
 	}
 
 	temp.parseSQLQuery()
@@ -68,6 +75,9 @@ func (namedParameterQuery *NamedParameterQuery) parseSQLQuery() {
 			}
 			name := namedParameterQuery.originalQuery[i+1 : j]
 
+			if !namedParameterQuery.queryArguments.Contains(":"+name) && !slices.Contains(namedParameterQuery.missingParameters, ":"+name) {
+				namedParameterQuery.missingParameters = append(namedParameterQuery.missingParameters, ":"+name)
+			}
 			temp := namedParameterQuery.queryArguments.GetValue(":" + name)
 
 			namedParameterQuery.parameters = append(namedParameterQuery.parameters, temp)
@@ -75,7 +85,7 @@ func (namedParameterQuery *NamedParameterQuery) parseSQLQuery() {
 			i += len(name)
 		}
 		if c == '?' {
-			parsedQuery.WriteString("$" + fmt.Sprintf("%v", index))
+			parsedQuery.WriteString(namedParameterQuery.dialect.Placeholder(index))
 			index++
 		} else {
 			parsedQuery.WriteString(string(c))
@@ -93,6 +103,11 @@ func (namedParameterQuery *NamedParameterQuery) GetParsedParameters() []interfac
 	return namedParameterQuery.parameters
 }
 
+// GetMissingParameters lists the :named variables of the query that queryArguments has no value for
+func (namedParameterQuery *NamedParameterQuery) GetMissingParameters() []string {
+	return namedParameterQuery.missingParameters
+}
+
 /*func (this *NamedParameterQuery) SetValuesFromStruct(parameters interface{}) error {
 
 	var fieldValues reflect.Value
@@ -0,0 +1,239 @@
+package cypressutils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	cErrors "github.com/pkg/errors"
+	"strings"
+)
+
+const (
+	CURSOR_NEXT     = "next"
+	CURSOR_PREVIOUS = "prev"
+)
+
+// CursorPage asks for the page after, or before, Cursor in the order of OrderColumns. The last of the
+// OrderColumns must be unique, e.g. the primary key, and none of them nullable, or rows get skipped
+type CursorPage struct {
+	OrderColumns []*ColumnOrderBy
+	Cursor       string
+	PageSize     int
+
+	// SkipCount leaves out the count query, TotalCount is then -1
+	SkipCount bool
+}
+
+// pageCursor is what the opaque cursor token carries: the values of the OrderColumns of the row at the
+// edge of a page and the side it is to be read from
+type pageCursor struct {
+	Direction string        `json:"d"`
+	Values    []interface{} `json:"v"`
+}
+
+func NewCursorPage(pageSize int, cursor string, orderColumns ...*ColumnOrderBy) *CursorPage {
+	return &CursorPage{OrderColumns: orderColumns, Cursor: cursor, PageSize: pageSize}
+}
+
+func (page *CursorPage) WithoutCount() *CursorPage {
+	page.SkipCount = true
+	return page
+}
+
+func (page *CursorPage) validate() error {
+	var err error
+	switch {
+	case page == nil:
+		err = cErrors.New("CURSOR: cursor page is nil")
+	case page.PageSize < 1:
+		err = cErrors.New("CURSOR: page size must be greater than 0")
+	case len(page.OrderColumns) == 0:
+		err = cErrors.New("CURSOR: order columns must be provided")
+	}
+
+	for index := 0; err == nil && index < len(page.OrderColumns); index++ {
+		column := page.OrderColumns[index]
+		if column == nil || column.Aggregate != NO_AGGREGATE || column.Window != nil {
+			err = cErrors.New("CURSOR: order columns must be plain table columns")
+			break
+		}
+		err = validateIdentifier(column.ColumnName, "CURSOR: ")
+	}
+
+	if err != nil {
+		ThrowException(err)
+	}
+	return err
+}
+
+// keysetQuery copies the builder with the rows past the cursor in its WHERE, the cursor order and one row
+// more than the page size, so that it is known whether another page follows. No OFFSET is scanned
+func (page *CursorPage) keysetQuery(queryBuilder *QueryBuilder, dialect Dialect, queryArguments *CypressHashMap) (*QueryBuilder, *pageCursor, error) {
+	if err := page.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	cursor := &pageCursor{Direction: CURSOR_NEXT}
+	if page.Cursor != "" {
+		decoded, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(decoded.Values) != len(page.OrderColumns) {
+			err = cErrors.New(fmt.Sprintf("CURSOR: cursor has %d values for %d order columns", len(decoded.Values), len(page.OrderColumns)))
+			ThrowException(err)
+			return nil, nil, err
+		}
+		cursor = decoded
+	}
+	backward := cursor.Direction == CURSOR_PREVIOUS
+
+	qrQueryBuilder := rawQueryBuilderFrom(queryBuilder, dialect)
+
+	if cursor.Values != nil {
+		for index, value := range cursor.Values {
+			queryArguments.AddQueryArgument(fmt.Sprintf(":cursor_%d", index), value)
+		}
+
+		keyset := page.keysetCondition(backward)
+		if whereClause := qrQueryBuilder.GetWhereClause(); whereClause != "" {
+			keyset = "(" + whereClause + ") AND (" + keyset + ")"
+		}
+		qrQueryBuilder.WhereStr(keyset)
+	}
+
+	orderColumns := make([]*ColumnOrderBy, len(page.OrderColumns))
+	for index, column := range page.OrderColumns {
+		orderBy := column.OrderBy_
+		if orderBy == NO_ORDER {
+			orderBy = ASC
+		}
+		if backward {
+			orderBy = reverseOrder(orderBy)
+		}
+		orderColumns[index] = NewColumnOrderBy(column.ColumnName, orderBy)
+	}
+
+	queryArguments.AddQueryArgument(":num_of_records", page.PageSize+1)
+	queryArguments.AddQueryArgument(":offset", 0)
+	qrQueryBuilder.RemoveClause(CLAUSE_ORDER_BY, CLAUSE_LIMIT).OrderByColumns(orderColumns...).Limit()
+
+	return qrQueryBuilder, cursor, qrQueryBuilder.Err
+}
+
+// keysetCondition is the row comparison (a, b) > (:cursor_0, :cursor_1) spelled out, so that it works on
+// every dialect and with a mix of ASC and DESC: a > :cursor_0 OR (a = :cursor_0 AND b > :cursor_1)
+func (page *CursorPage) keysetCondition(backward bool) string {
+	var buf bytes.Buffer
+	for index, column := range page.OrderColumns {
+		if index > 0 {
+			buf.WriteString(" OR ")
+		}
+
+		buf.WriteString("(")
+		for previous := 0; previous < index; previous++ {
+			buf.WriteString(fmt.Sprintf("%s = :cursor_%d AND ", page.OrderColumns[previous].ColumnName, previous))
+		}
+
+		operator := ">"
+		if (column.OrderBy_ == DESC) != backward {
+			operator = "<"
+		}
+		buf.WriteString(fmt.Sprintf("%s %s :cursor_%d)", column.ColumnName, operator, index))
+	}
+	return buf.String()
+}
+
+// wrap puts the records back in the cursor order and sets the cursors of the pages around them
+func (page *CursorPage) wrap(tableName string, records *CypressArrayList, cursor *pageCursor, totalCount int) *PageableWrapper {
+	backward := cursor.Direction == CURSOR_PREVIOUS
+	hasMore := records != nil && records.Size() > page.PageSize
+
+	size := 0
+	if records != nil {
+		size = min(records.Size(), page.PageSize)
+	}
+
+	pageRecords := NewList()
+	for index := 0; index < size; index++ {
+		if backward {
+			pageRecords.AddNewRecord(records.GetRecord(size - 1 - index))
+		} else {
+			pageRecords.AddNewRecord(records.GetRecord(index))
+		}
+	}
+
+	wrapper := NewPageableWrapper()
+	wrapper.SetDomain(tableName)
+	wrapper.SetPageSize(page.PageSize)
+	wrapper.SetData(pageRecords)
+	wrapper.SetTotalCount(totalCount)
+
+	if pageRecords.Size() == 0 {
+		return wrapper
+	}
+
+	if (!backward && hasMore) || (backward && cursor.Values != nil) {
+		wrapper.SetNextCursor(page.encodeCursor(CURSOR_NEXT, pageRecords.GetRecord(pageRecords.Size()-1)))
+	}
+	if (backward && hasMore) || (!backward && cursor.Values != nil) {
+		wrapper.SetPreviousCursor(page.encodeCursor(CURSOR_PREVIOUS, pageRecords.GetRecord(0)))
+	}
+	return wrapper
+}
+
+func (page *CursorPage) encodeCursor(direction string, record *CypressHashMap) string {
+	cursor := &pageCursor{Direction: direction}
+	for _, column := range page.OrderColumns {
+		columnName := column.ColumnName
+		if !record.Contains(columnName) {
+			columnName = columnName[strings.LastIndex(columnName, ".")+1:]
+		}
+		cursor.Values = append(cursor.Values, record.GetValue(columnName))
+	}
+
+	encoded, err := json.Marshal(cursor)
+	if err != nil {
+		ThrowException(err)
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeCursor(token string) (*pageCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		err = cErrors.New("CURSOR: cursor is malformed")
+		ThrowException(err)
+		return nil, err
+	}
+
+	cursor := &pageCursor{}
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+	if err = decoder.Decode(cursor); err != nil || (cursor.Direction != CURSOR_NEXT && cursor.Direction != CURSOR_PREVIOUS) {
+		err = cErrors.New("CURSOR: cursor is malformed")
+		ThrowException(err)
+		return nil, err
+	}
+
+	//JSON NUMBERS WOULD OTHERWISE COME BACK AS float64 AND LOSE LARGE IDs
+	for index, value := range cursor.Values {
+		if number, ok := value.(json.Number); ok {
+			if integer, err := number.Int64(); err == nil {
+				cursor.Values[index] = integer
+			} else {
+				cursor.Values[index], _ = number.Float64()
+			}
+		}
+	}
+	return cursor, nil
+}
+
+func reverseOrder(orderBy OrderBy) OrderBy {
+	if orderBy == DESC {
+		return ASC
+	}
+	return DESC
+}
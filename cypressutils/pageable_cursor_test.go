@@ -0,0 +1,117 @@
+package cypressutils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/codecypress/go-ancillary-utils/cypressfakedb"
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+func selectPeopleWithCursor(t *testing.T, organizationId string, cursorPage *cypressutils.CursorPage) *cypressutils.PageableWrapper {
+	t.Helper()
+
+	queryBuilder := cypressutils.NewQueryBuilder().Select().SelectColumn("id, name").FromTable("people")
+	twrapper := cypressutils.SelectWithCursor(organizationId, queryBuilder, nil, cursorPage)
+	if twrapper.HasErrors {
+		t.Fatalf("SelectWithCursor failed: %s", twrapper.GetErrors())
+	}
+	return twrapper.GetData().(*cypressutils.PageableWrapper)
+}
+
+func peopleCursorPage(cursor string) *cypressutils.CursorPage {
+	return cypressutils.NewCursorPage(2, cursor, cypressutils.NewColumnOrderBy("name", cypressutils.ASC),
+		cypressutils.NewColumnOrderBy("id", cypressutils.ASC))
+}
+
+func pageNames(pageableWrapper *cypressutils.PageableWrapper) string {
+	cypressList := pageableWrapper.GetData().(*cypressutils.CypressArrayList)
+
+	var names []string
+	for index := 0; index < cypressList.Size(); index++ {
+		names = append(names, cypressList.GetRecord(index).GetStringValue("name"))
+	}
+	return strings.Join(names, ", ")
+}
+
+func lastPageQuery(t *testing.T, fakeDB *cypressfakedb.FakeDB) *cypressfakedb.RecordedStatement {
+	t.Helper()
+
+	for _, statement := range fakeDB.GetStatements() {
+		if strings.Contains(statement.Query, "ORDER BY") {
+			return statement
+		}
+	}
+	t.Fatal("no page was selected")
+	return nil
+}
+
+func TestCursorPagesForwardAndBack(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+
+	//ONE ROW MORE THAN THE PAGE SIZE TELLS THAT A NEXT PAGE FOLLOWS
+	fakeDB.Expect("ORDER BY").WithColumns("id", "name").AddRow(1, "Ann").AddRow(2, "Bob").AddRow(3, "Cid")
+	fakeDB.Expect("COUNT(*)").WithColumns("count").AddRow(3)
+
+	firstPage := selectPeopleWithCursor(t, organizationId, peopleCursorPage(""))
+	if names := pageNames(firstPage); names != "Ann, Bob" {
+		t.Errorf("first page = %s, want Ann, Bob", names)
+	}
+	if firstPage.GetNextCursor() == "" || firstPage.GetPreviousCursor() != "" || firstPage.GetTotalCount() != 3 {
+		t.Errorf("first page next = %q, previous = %q, total = %d", firstPage.GetNextCursor(), firstPage.GetPreviousCursor(),
+			firstPage.GetTotalCount())
+	}
+	if query := lastPageQuery(t, fakeDB).Query; strings.Contains(query, "WHERE") {
+		t.Errorf("first page filtered by a cursor: %s", query)
+	}
+
+	fakeDB.Reset()
+	fakeDB.Expect("ORDER BY").WithColumns("id", "name").AddRow(3, "Cid")
+
+	secondPage := selectPeopleWithCursor(t, organizationId, peopleCursorPage(firstPage.GetNextCursor()).WithoutCount())
+	statement := lastPageQuery(t, fakeDB)
+	if !strings.Contains(statement.Query, `WHERE (name > $1) OR (name = $2 AND id > $3) ORDER BY "name" ASC, "id" ASC`) {
+		t.Errorf("second page query = %s", statement.Query)
+	}
+	if len(statement.Args) < 3 || statement.Args[0] != "Bob" || statement.Args[2] != "2" {
+		t.Errorf("second page args = %v, want the name and id of Bob", statement.Args)
+	}
+	if names := pageNames(secondPage); names != "Cid" {
+		t.Errorf("second page = %s, want Cid", names)
+	}
+	if secondPage.GetNextCursor() != "" || secondPage.GetPreviousCursor() == "" || secondPage.GetTotalCount() != -1 {
+		t.Errorf("second page next = %q, previous = %q, total = %d", secondPage.GetNextCursor(), secondPage.GetPreviousCursor(),
+			secondPage.GetTotalCount())
+	}
+	if len(fakeDB.GetStatements()) != 1 {
+		t.Errorf("%d statements run, WithoutCount should leave out the count", len(fakeDB.GetStatements()))
+	}
+
+	//GOING BACK READS IN THE REVERSE ORDER AND TURNS THE ROWS AROUND
+	fakeDB.Reset()
+	fakeDB.Expect("ORDER BY").WithColumns("id", "name").AddRow(2, "Bob").AddRow(1, "Ann")
+
+	previousPage := selectPeopleWithCursor(t, organizationId, peopleCursorPage(secondPage.GetPreviousCursor()).WithoutCount())
+	if query := lastPageQuery(t, fakeDB).Query; !strings.Contains(query, `WHERE (name < $1) OR (name = $2 AND id < $3) ORDER BY "name" DESC, "id" DESC`) {
+		t.Errorf("previous page query = %s", query)
+	}
+	if names := pageNames(previousPage); names != "Ann, Bob" {
+		t.Errorf("previous page = %s, want Ann, Bob", names)
+	}
+	if previousPage.GetNextCursor() == "" {
+		t.Error("previous page has no next cursor")
+	}
+}
+
+func TestCursorRejectsAMalformedToken(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+
+	queryBuilder := cypressutils.NewQueryBuilder().Select().SelectColumn("id, name").FromTable("people")
+	twrapper := cypressutils.SelectWithCursor(organizationId, queryBuilder, nil, peopleCursorPage("not a cursor"))
+	if !twrapper.HasErrors {
+		t.Fatal("SelectWithCursor accepted a malformed cursor")
+	}
+	if len(fakeDB.GetStatements()) != 0 {
+		t.Errorf("%d statements run for a malformed cursor", len(fakeDB.GetStatements()))
+	}
+}
@@ -11,6 +11,9 @@ type PageableWrapper struct {
 	PageSize    int         `json:"page_size"`
 	TotalCount  int         `json:"total_count"`
 	Data        interface{} `json:"data"`
+
+	NextCursor     string `json:"next_cursor,omitempty"`
+	PreviousCursor string `json:"previous_cursor,omitempty"`
 }
 
 func NewPageableWrapper() *PageableWrapper {
@@ -65,6 +68,22 @@ func (wrapper *PageableWrapper) SetData(data interface{}) {
 	wrapper.Data = data
 }
 
+func (wrapper *PageableWrapper) GetNextCursor() string {
+	return wrapper.NextCursor
+}
+
+func (wrapper *PageableWrapper) SetNextCursor(nextCursor string) {
+	wrapper.NextCursor = nextCursor
+}
+
+func (wrapper *PageableWrapper) GetPreviousCursor() string {
+	return wrapper.PreviousCursor
+}
+
+func (wrapper *PageableWrapper) SetPreviousCursor(previousCursor string) {
+	wrapper.PreviousCursor = previousCursor
+}
+
 func (wrapper *PageableWrapper) GetSingleRecord() (*CypressHashMap, error) {
 	//interface{}.(Data).(type)
 	switch wrapper.Data.(type) {
@@ -4,72 +4,115 @@ import (
 	"bytes"
 	"fmt"
 	cErrors "github.com/pkg/errors"
-	"regexp"
+	"reflect"
 	"strings"
 )
 
+// QueryBuilder keeps the statement as a tree of clauses which is only turned into SQL by ToString or Build.
+// The fluent methods fill in the clauses of the current statement, so they can be called in any order
 type QueryBuilder struct {
-	query, joinStatement, whereClause, tableName, primaryKeyColumn   string
-	primaryKeyColumns, fetchedColumns, insertColumns, insertedValues []string
-	fetchColumnsList                                                 []*Column
-	Err                                                              error
+	tableName, primaryKeyColumn string
+	primaryKeyColumns           []string
+	parts                       []*queryPart
+	commonTableExpressions      []*commonTableExpression
+	arguments                   *CypressHashMap
+	dialect                     Dialect
+	countOver                   bool
+	allowFullTable              bool
+	Err                         error
 }
 
 /*--------------------------------START OF INSERT QUERIES---------------------------*/
 
-func NewQueryBuilder() *QueryBuilder {
-	return &QueryBuilder{
-		query:             "",
-		joinStatement:     "",
-		whereClause:       "",
+func NewQueryBuilder(dialect ...Dialect) *QueryBuilder {
+	builder := &QueryBuilder{
 		tableName:         "",
 		primaryKeyColumn:  "",
 		primaryKeyColumns: []string{},
-		fetchedColumns:    []string{"*"},
-		insertedValues:    []string{""},
-		fetchColumnsList:  []*Column{},
+		parts:             []*queryPart{},
+		arguments:         NewMap(),
+		dialect:           GetDialect(PostgreSQL),
 		Err:               nil,
 	}
+
+	if dialect != nil && dialect[0] != nil {
+		builder.dialect = dialect[0]
+	}
+	return builder
 }
 
 func (builder *QueryBuilder) Insert() *QueryBuilder {
-	builder.query += "INSERT INTO "
+	builder.startStatement(STATEMENT_INSERT, CLAUSE_INSERT)
 	return builder
 }
 
 func (builder *QueryBuilder) Prepend(prependStr string) *QueryBuilder {
-	builder.query = prependStr + builder.query + " "
+	builder.parts = append([]*queryPart{{text: prependStr}}, builder.parts...)
 	return builder
 }
 
+// Append adds raw text at the end of the clause that was set last
 func (builder *QueryBuilder) Append(appendStr string) *QueryBuilder {
-	builder.query += appendStr + " "
+	builder.addText(appendStr)
 	return builder
 }
 
 func (builder *QueryBuilder) Into(tableName string) *QueryBuilder {
 
-	err := validateTableName(tableName, "INSERT: Table name is empty")
+	err := validateTableName(tableName, "INSERT: Table name is empty", "INSERT: ")
 	if err != nil {
+		builder.Err = err
 		return builder
 	}
 	builder.tableName = tableName
-	builder.query += tableName + " "
+	statement := builder.openClause(CLAUSE_INSERT)
+	statement.table = tableName
+	statement.addTableName(tableName)
 	return builder
 }
 
 func (builder *QueryBuilder) Columns(columns []string) *QueryBuilder {
-	builder.insertColumns = columns
-	builder.query += concatenateColumnNames(columns) + " "
+	for _, column := range columns {
+		if err := validateIdentifier(column, "INSERT: "); err != nil {
+			builder.Err = err
+			return builder
+		}
+	}
+
+	statement := builder.openClause(CLAUSE_INSERT)
+	statement.insertColumns = columns
 	return builder
 }
 
 func (builder *QueryBuilder) Values(namedVariables []string) *QueryBuilder {
+	if builder.validateValues(namedVariables) != nil {
+		return builder
+	}
+
+	statement := builder.openClause(CLAUSE_VALUES)
+	statement.remove(CLAUSE_VALUES)
+	statement.valueRows = [][]string{namedVariables}
+	return builder
+}
+
+// AddValues adds a row to a multi-row VALUES
+func (builder *QueryBuilder) AddValues(namedVariables []string) *QueryBuilder {
+	if builder.validateValues(namedVariables) != nil {
+		return builder
+	}
+
+	statement := builder.openClause(CLAUSE_VALUES)
+	statement.valuesText, statement.valuesKeyword = "", false
+	statement.valueRows = append(statement.valueRows, namedVariables)
+	return builder
+}
+
+func (builder *QueryBuilder) validateValues(namedVariables []string) error {
 	if namedVariables == nil || len(namedVariables) < 1 {
 		err := cErrors.New("INSERT: No insert values provided")
 		ThrowException(err)
 		builder.Err = err
-		return builder
+		return err
 	}
 
 	for _, value := range namedVariables {
@@ -77,21 +120,19 @@ func (builder *QueryBuilder) Values(namedVariables []string) *QueryBuilder {
 			err := cErrors.New("INSERT: '" + value + "' named variable must start with full colon[:]")
 			ThrowException(err)
 			builder.Err = err
-			return builder
+			return err
 		}
 	}
 
-	if builder.insertColumns != nil {
-		if len(builder.insertColumns) != len(namedVariables) {
+	if insertColumns := builder.statement().insertColumns; insertColumns != nil {
+		if len(insertColumns) != len(namedVariables) {
 			err := cErrors.New("INSERT: Number of columns and values do not match")
 			ThrowException(err)
 			builder.Err = err
-			return builder
+			return err
 		}
 	}
-
-	builder.query += " VALUES " + concatenateColumnNames(namedVariables)
-	return builder
+	return nil
 }
 
 func (builder *QueryBuilder) ValuesConcatenated(valuesString string) *QueryBuilder {
@@ -102,7 +143,10 @@ func (builder *QueryBuilder) ValuesConcatenated(valuesString string) *QueryBuild
 		return builder
 	}
 
-	builder.query += " VALUES " + valuesString
+	statement := builder.openClause(CLAUSE_VALUES)
+	statement.remove(CLAUSE_VALUES)
+	statement.valuesText = valuesString
+	statement.valuesKeyword = true
 	return builder
 }
 
@@ -114,25 +158,22 @@ func (builder *QueryBuilder) OnDuplicateKey(columns []string) *QueryBuilder {
 		return builder
 	}
 
-	builder.query += " ON DUPLICATE KEY UPDATE "
-	shouldAddComma := false
-
-	for index := range columns {
-		column := columns[index]
-		if shouldAddComma {
-			builder.query += ", "
-		} else {
-			shouldAddComma = true
-		}
-
-		builder.query += column + " = :" + column
+	//THE CONFLICT COLUMNS ARE ONLY NEEDED WHEN RENDERING, THIS JUST FAILS EARLY FOR DIALECTS WITHOUT UPSERTS
+	if _, err := builder.dialect.OnDuplicateKeyUpdate(builder.conflictColumns(), columns); err != nil {
+		ThrowException(err)
+		builder.Err = err
+		return builder
 	}
 
+	statement := builder.openClause(CLAUSE_ON_DUPLICATE_KEY)
+	statement.onDuplicateKey = columns
 	return builder
 }
 
 func (builder *QueryBuilder) ValuesFromSelect(selectSubQuery string) *QueryBuilder {
-	builder.query += "(" + selectSubQuery + ") "
+	statement := builder.openClause(CLAUSE_VALUES)
+	statement.remove(CLAUSE_VALUES)
+	statement.valuesText = "(" + selectSubQuery + ")"
 	return builder
 }
 
@@ -145,28 +186,37 @@ func (builder *QueryBuilder) ValuesFromSelect(selectSubQuery string) *QueryBuild
 /*--------------------------------START OF UPDATE QUERIES-------------------------------------*/
 
 func (builder *QueryBuilder) Update(tableName string) *QueryBuilder {
-	err := validateTableName(tableName, "UPDATE: Table name is empty")
+	err := validateTableName(tableName, "UPDATE: Table name is empty", "UPDATE: ")
 	if err != nil {
+		builder.Err = err
 		return builder
 	}
 
 	builder.tableName = tableName
-	builder.query += "UPDATE " + tableName + " SET "
+	statement := builder.startStatement(STATEMENT_UPDATE, CLAUSE_UPDATE)
+	statement.table = tableName
+	statement.addTableName(tableName)
 	return builder
 }
 
 func (builder *QueryBuilder) Set(hashMap *CypressHashMap) *QueryBuilder {
 	err := validateUpdateSet(hashMap)
 	if err != nil {
+		builder.Err = err
 		return builder
 	}
 
-	builder.query += concatenateUpdateSet(hashMap)
-	return builder
+	return builder.SpecialSet(hashMap)
 }
 
 func (builder *QueryBuilder) SpecialSet(hashMap *CypressHashMap) *QueryBuilder {
-	builder.query += concatenateUpdateSet(hashMap)
+	statement := builder.openClause(CLAUSE_SET)
+	for pair := hashMap.GetData().Oldest(); pair != nil; pair = pair.Next() {
+		statement.assignments = append(statement.assignments, &assignment{
+			column: fmt.Sprintf("%v", pair.Key),
+			value:  fmt.Sprintf("%v", pair.Value),
+		})
+	}
 	return builder
 }
 
@@ -177,7 +227,25 @@ func (builder *QueryBuilder) Returning(returningPhrase string) *QueryBuilder {
 		builder.Err = err
 		return builder
 	}
-	builder.query += " RETURNING " + returningPhrase
+
+	switch builder.dialect.ReturningStyle() {
+	case RETURNING_AFTER_STATEMENT:
+	case RETURNING_OUTPUT_CLAUSE:
+		if !builder.statement().isDataModification() {
+			err := cErrors.New("RETURNING STATEMENT: no position for the OUTPUT clause in: " + builder.ToString())
+			ThrowException(err)
+			builder.Err = err
+			return builder
+		}
+	default:
+		err := cErrors.New("RETURNING STATEMENT: not supported by " + string(builder.dialect.GetDatabaseServer()))
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	statement := builder.openClause(CLAUSE_RETURNING)
+	statement.returning = returningPhrase
 	return builder
 }
 
@@ -190,65 +258,110 @@ func (builder *QueryBuilder) Returning(returningPhrase string) *QueryBuilder {
 /*--------------------------------START OF DELETE QUERIES-------------------------------------*/
 
 func (builder *QueryBuilder) DeleteFrom(tableName string) *QueryBuilder {
-	err := validateTableName(tableName, "DELETE: Table name is empty")
+	err := validateTableName(tableName, "DELETE: Table name is empty", "DELETE: ")
 	if err != nil {
+		builder.Err = err
 		return builder
 	}
 
 	builder.tableName = tableName
-	builder.query += "DELETE FROM " + tableName + " "
+	statement := builder.startStatement(STATEMENT_DELETE, CLAUSE_DELETE)
+	statement.table = tableName
+	statement.addTableName(tableName)
 	return builder
 }
 
 /*--------------------------------START OF SELECT QUERIES-------------------------------------*/
 
+// Select starts a new statement when the current one already is an INSERT, UPDATE, DELETE or SELECT,
+// e.g. INSERT INTO ... SELECT or the SELECT that follows a WITH
 func (builder *QueryBuilder) Select() *QueryBuilder {
-	builder.query += "SELECT "
+	builder.startStatement(STATEMENT_SELECT, CLAUSE_SELECT)
 	return builder
 }
 
 func (builder *QueryBuilder) RawQuery(rawQuery string) *QueryBuilder {
-	builder.query += rawQuery + " "
+	builder.addText(rawQuery)
 	return builder
 }
 
+// SelectColumn takes a comma separated list of columns, alias.*, aggregates of a column and aliases,
+// e.g. "u.id, u.name AS user_name, COUNT(o.id) AS orders"
 func (builder *QueryBuilder) SelectColumn(column string) *QueryBuilder {
-	builder.fetchColumnsList = append(builder.fetchColumnsList, &Column{ColumnName: column})
-	builder.fetchedColumns[0] = concatenateColumnNamesForFetch(builder)
-	builder.query += builder.fetchedColumns[0]
+	if err := validateList(column, selectItemRegex, "SELECT: "); err != nil {
+		builder.Err = err
+		return builder
+	}
+	return builder.selectColumns(&Column{ColumnName: column})
+}
+
+// SelectExpression selects expression as it is. It is not validated so it must never hold client input
+func (builder *QueryBuilder) SelectExpression(expression string) *QueryBuilder {
+	if strings.TrimSpace(expression) == "" {
+		err := cErrors.New("SELECT: expression is empty")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+	return builder.selectColumns(&Column{ColumnName: expression})
+}
+
+func (builder *QueryBuilder) SelectColumns(columns ...*Column) *QueryBuilder {
+	for _, column := range columns {
+		if err := validateColumn(column); err != nil {
+			builder.Err = err
+			return builder
+		}
+	}
+	return builder.selectColumns(columns...)
+}
+
+func (builder *QueryBuilder) selectColumns(columns ...*Column) *QueryBuilder {
+	statement := builder.openClause(CLAUSE_SELECT)
+	for _, column := range columns {
+		statement.selectItems = append(statement.selectItems, &selectItem{column: column})
+	}
 	return builder
 }
 
 func (builder *QueryBuilder) FromTable(tableName string) *QueryBuilder {
-	err := validateTableName(tableName, "SELECT: Table name is empty")
-
+	err := validateTableName(tableName, "SELECT: Table name is empty", "SELECT: ")
 	if err != nil {
+		builder.Err = err
 		return builder
 	}
 
 	builder.tableName = tableName
-	builder.query += " FROM " + tableName + " "
+	statement := builder.openClause(CLAUSE_FROM)
+	statement.hasFrom = true
+	statement.fromTable = tableName
+	statement.addTableName(tableName)
 	return builder
 }
 
 func (builder *QueryBuilder) From() *QueryBuilder {
-	builder.query += " FROM "
+	statement := builder.openClause(CLAUSE_FROM)
+	statement.hasFrom = true
 	return builder
 }
 
 func (builder *QueryBuilder) WherePred(filterPredicate *FilterPredicate) *QueryBuilder {
-	return builder.WhereStr(filterPredicate.GetClause())
+	clause, err := builder.takePredicate(filterPredicate)
+	if err != nil {
+		return builder
+	}
+	return builder.WhereStr(clause)
 }
 
+// WhereStr replaces the WHERE clause of the current statement
 func (builder *QueryBuilder) WhereStr(whereClause string) *QueryBuilder {
 	err := validateSelection(whereClause)
 	if err != nil {
 		return builder
 	}
 
-	builder.whereClause = whereClause
-
-	builder.query += " WHERE " + whereClause + " "
+	statement := builder.openClause(CLAUSE_WHERE)
+	statement.where = whereClause
 	return builder
 }
 
@@ -259,12 +372,21 @@ func (builder *QueryBuilder) GroupBy(columns string) *QueryBuilder {
 		builder.Err = err
 		return builder
 	}
-	builder.query += " GROUP BY " + columns + " "
+	if err := validateList(columns, groupByItemRegex, "GROUP: "); err != nil {
+		builder.Err = err
+		return builder
+	}
+	statement := builder.openClause(CLAUSE_GROUP_BY)
+	statement.groupBy = columns
 	return builder
 }
 
 func (builder *QueryBuilder) HavingPred(filterPredicate *FilterPredicate) *QueryBuilder {
-	return builder.HavingStr(filterPredicate.GetClause())
+	clause, err := builder.takePredicate(filterPredicate)
+	if err != nil {
+		return builder
+	}
+	return builder.HavingStr(clause)
 }
 
 func (builder *QueryBuilder) HavingStr(havingClause string) *QueryBuilder {
@@ -274,10 +396,14 @@ func (builder *QueryBuilder) HavingStr(havingClause string) *QueryBuilder {
 		builder.Err = err
 		return builder
 	}
-	builder.query += " HAVING " + havingClause + " "
+	statement := builder.openClause(CLAUSE_HAVING)
+	statement.having = havingClause
 	return builder
 }
 
+// OrderBy adds to the ORDER BY of the current statement, earlier columns keep precedence. It takes a comma
+// separated list of columns, aggregates of a column or positions, each optionally followed by ASC or DESC and
+// NULLS FIRST or NULLS LAST
 func (builder *QueryBuilder) OrderBy(orderBy string) *QueryBuilder {
 	if orderBy == "" {
 		err := cErrors.New("Order By clause cannot be empty")
@@ -285,42 +411,62 @@ func (builder *QueryBuilder) OrderBy(orderBy string) *QueryBuilder {
 		builder.Err = err
 		return builder
 	}
-	builder.query += " ORDER BY " + orderBy + " "
+	if err := validateList(orderBy, orderByItemRegex, "ORDER BY: "); err != nil {
+		builder.Err = err
+		return builder
+	}
+	return builder.orderBy(orderBy)
+}
+
+func (builder *QueryBuilder) orderBy(orderBy string) *QueryBuilder {
+	statement := builder.openClause(CLAUSE_ORDER_BY)
+	statement.orderBy = append(statement.orderBy, orderBy)
 	return builder
 }
 
 /*********************************************************/
 
+// OrderByColumns is OrderBy for typed columns. A select alias can be given as the column name
+func (builder *QueryBuilder) OrderByColumns(columns ...*ColumnOrderBy) *QueryBuilder {
+	if len(columns) == 0 {
+		err := cErrors.New("Order By clause cannot be empty")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+	if err := validateOrderBy(columns); err != nil {
+		builder.Err = err
+		return builder
+	}
+	return builder.orderBy(concatenateOrderByColumnNames(nil, columns))
+}
+
 func (builder *QueryBuilder) Case() *QueryBuilder {
-	builder.query += "(CASE "
+	builder.addText("(CASE")
 	return builder
 }
 
 func (builder *QueryBuilder) End(succeedingComma ...bool) *QueryBuilder {
-	builder.query += "END)"
-
 	if succeedingComma != nil && succeedingComma[0] {
-		builder.query += "), "
+		builder.addText("END)),")
 	} else {
-		builder.query += ") "
+		builder.addText("END))")
 	}
 	return builder
 }
 
 func (builder *QueryBuilder) EndAs(alias string, succeedingComma ...bool) *QueryBuilder {
-	builder.query += "END AS " + alias
-
 	if succeedingComma != nil && succeedingComma[0] {
-		builder.query += "), "
+		builder.addText("END AS " + alias + "),")
 	} else {
-		builder.query += ") "
+		builder.addText("END AS " + alias + ")")
 	}
 
 	return builder
 }
 
 func (builder *QueryBuilder) When(whenClause string) *QueryBuilder {
-	builder.query += "WHEN " + whenClause + " "
+	builder.addText("WHEN " + whenClause)
 	return builder
 }
 
@@ -345,14 +491,14 @@ func (builder *QueryBuilder) Then(namedVariable string) *QueryBuilder {
 		return builder
 	}
 
-	builder.query += "THEN " + namedVariable + " "
+	builder.addText("THEN " + namedVariable)
 
 	return builder
 }
 
 func (builder *QueryBuilder) Else(namedVariable ...string) *QueryBuilder {
 	if namedVariable == nil {
-		builder.query += " ELSE "
+		builder.addText("ELSE")
 		return builder
 	}
 
@@ -377,25 +523,28 @@ func (builder *QueryBuilder) Else(namedVariable ...string) *QueryBuilder {
 		return builder
 	}
 
-	builder.query += "ELSE " + namedVariable[0] + " "
+	builder.addText("ELSE " + namedVariable[0])
 
 	return builder
 }
 
+// Limit pages the current statement with :num_of_records and :offset. The dialect decides how once the
+// statement is rendered, by then its ORDER BY is known
 func (builder *QueryBuilder) Limit() *QueryBuilder {
-	builder.query += " LIMIT :num_of_records OFFSET :offset "
+	statement := builder.openClause(CLAUSE_LIMIT)
+	statement.limit = &limitClause{numOfRecordsVariable: ":num_of_records", offsetVariable: ":offset"}
 	return builder
 }
 
 /*********************************************************/
 
 func (builder *QueryBuilder) Union(precedingQuery, followingQuery string) *QueryBuilder {
-	builder.query += precedingQuery + " UNION " + followingQuery + " "
+	builder.addText(precedingQuery + " UNION " + followingQuery)
 	return builder
 }
 
 func (builder *QueryBuilder) UnionAll(precedingQuery, followingQuery string) *QueryBuilder {
-	builder.query += precedingQuery + " UNION ALL " + followingQuery + " "
+	builder.addText(precedingQuery + " UNION ALL " + followingQuery)
 	return builder
 }
 
@@ -408,38 +557,257 @@ func (builder *QueryBuilder) JoinPhrase(joinPhrase string) *QueryBuilder {
 		builder.Err = err
 		return builder
 	}
-	builder.joinStatement += joinPhrase
-	builder.query += joinPhrase
+	statement := builder.openClause(CLAUSE_JOIN)
+	statement.joins = append(statement.joins, &joinClause{text: joinPhrase})
 	return builder
 }
 
+// ToString renders the builder for its own dialect, leaving the :named variables in place
 func (builder *QueryBuilder) ToString() string {
-	regexMatcher := regexp.MustCompile("\\s{2,}")
+	query, err := builder.render(builder.dialect, "")
+	if err != nil {
+		ThrowException(err)
+		builder.Err = err
+	}
+	return query
+}
+
+// ToStringReturning gives the statement with the rows it touched returned in the dialect's own way,
+// the builder itself is left as it is
+func (builder *QueryBuilder) ToStringReturning(columns string) string {
+	if builder.dialect.ReturningStyle() == RETURNING_NOT_SUPPORTED {
+		return builder.ToString()
+	}
+
+	query, err := builder.render(builder.dialect, columns)
+	if err != nil {
+		ThrowException(err)
+		builder.Err = err
+	}
+	return query
+}
+
+// Build renders the builder for dialect, or for its own dialect when nil, and swaps the :named variables for
+// the dialect's placeholders. args holds the values bound with SetArgument in placeholder order
+func (builder *QueryBuilder) Build(dialect Dialect) (sql string, args []interface{}, err error) {
+	if builder.Err != nil {
+		return "", nil, builder.Err
+	}
+	if dialect == nil {
+		dialect = builder.dialect
+	}
+
+	query, err := builder.render(dialect, "")
+	if err != nil {
+		return "", nil, err
+	}
+
+	namedParameter := NewNamedParameterQuery(query, builder.withArguments(nil), dialect)
+	if missing := namedParameter.GetMissingParameters(); len(missing) > 0 {
+		return "", nil, cErrors.New("BUILD: No value bound for " + strings.Join(missing, ", ") + " in: " + query)
+	}
 
-	result := strings.TrimSpace(builder.query)
-	result = regexMatcher.ReplaceAllString(result, " ")
+	return namedParameter.GetParsedQuery(), namedParameter.GetParsedParameters(), nil
+}
 
-	return result
+// renderError renders the builder once, errors that only show when rendering, e.g. a column outside of
+// the allowlist, then stop it from being executed
+func (builder *QueryBuilder) renderError() error {
+	if builder.Err == nil {
+		builder.ToString()
+	}
+	return builder.Err
 }
 
 func (builder *QueryBuilder) DisplayQuery() {
-	fmt.Println(FormatSQL(builder.query))
+	fmt.Println(FormatSQL(builder.ToString()))
+}
+
+// Clone copies the whole tree and the bound arguments, changes to the clone leave the builder alone
+func (builder *QueryBuilder) Clone() *QueryBuilder {
+	clone := *builder
+	clone.primaryKeyColumns = append([]string{}, builder.primaryKeyColumns...)
+	clone.arguments = builder.arguments.CloneMe()
+
+	clone.parts = make([]*queryPart, len(builder.parts))
+	for index, part := range builder.parts {
+		clone.parts[index] = &queryPart{text: part.text}
+		if part.statement != nil {
+			clone.parts[index].statement = part.statement.clone()
+		}
+	}
+
+	clone.commonTableExpressions = make([]*commonTableExpression, len(builder.commonTableExpressions))
+	for index, cte := range builder.commonTableExpressions {
+		copied := *cte
+		copied.query = cte.query.Clone()
+		clone.commonTableExpressions[index] = &copied
+	}
+	return &clone
+}
+
+/*******************CLAUSES *************************/
+
+func (builder *QueryBuilder) HasClause(kind ClauseKind) bool {
+	if kind == CLAUSE_WITH {
+		return len(builder.commonTableExpressions) > 0
+	}
+	return builder.statement().has(kind)
+}
+
+// RemoveClause drops a clause of the current statement, e.g. the ORDER BY and LIMIT of a query that is being counted
+func (builder *QueryBuilder) RemoveClause(kinds ...ClauseKind) *QueryBuilder {
+	statement := builder.statement()
+	for _, kind := range kinds {
+		if kind == CLAUSE_WITH {
+			builder.commonTableExpressions = nil
+			continue
+		}
+		statement.remove(kind)
+	}
+	return builder
+}
+
+func (builder *QueryBuilder) SetArgument(namedVariable string, value interface{}) *QueryBuilder {
+	if !strings.HasPrefix(namedVariable, ":") {
+		namedVariable = ":" + namedVariable
+	}
+	builder.arguments.PutValue(namedVariable, value)
+	return builder
+}
+
+func (builder *QueryBuilder) SetArguments(queryArguments *CypressHashMap) *QueryBuilder {
+	if queryArguments == nil {
+		return builder
+	}
+	for pair := queryArguments.GetData().Oldest(); pair != nil; pair = pair.Next() {
+		builder.SetArgument(fmt.Sprintf("%v", pair.Key), pair.Value)
+	}
+	return builder
+}
+
+func (builder *QueryBuilder) GetArguments() *CypressHashMap {
+	return builder.arguments
+}
+
+// withArguments adds the values bound on the builder, then those of its CTEs, to queryArguments. What the
+// caller passed wins
+func (builder *QueryBuilder) withArguments(queryArguments *CypressHashMap) *CypressHashMap {
+	if queryArguments == nil {
+		queryArguments = NewMap()
+	}
+	for pair := builder.arguments.GetData().Oldest(); pair != nil; pair = pair.Next() {
+		key := fmt.Sprintf("%v", pair.Key)
+		if !queryArguments.Contains(key) {
+			queryArguments.PutValue(key, pair.Value)
+		}
+	}
+	for _, cte := range builder.commonTableExpressions {
+		cte.query.withArguments(queryArguments)
+	}
+	return queryArguments
+}
+
+// takePredicate binds the values of the predicate on the builder. The predicate's :named variables that the
+// builder already binds to another value are renamed, in the clause that is returned too
+func (builder *QueryBuilder) takePredicate(filterPredicate *FilterPredicate) (string, error) {
+	if filterPredicate == nil {
+		err := cErrors.New("PREDICATE: predicate is nil")
+		ThrowException(err)
+		builder.Err = err
+		return "", err
+	}
+	if filterPredicate.Err != nil {
+		builder.Err = filterPredicate.Err
+		return "", filterPredicate.Err
+	}
+
+	clause, arguments := renameTakenArguments(filterPredicate.GetClause(), filterPredicate.GetArguments(),
+		func(namedVariable string, value interface{}) bool {
+			return builder.arguments.Contains(namedVariable) && !reflect.DeepEqual(builder.arguments.GetValue(namedVariable), value)
+		})
+	builder.SetArguments(arguments)
+	return clause, nil
 }
 
 /*******************GETTERS AND SETTERS *************************/
 
 func (builder *QueryBuilder) GetJoinStatement() string {
-	return builder.joinStatement
+	joins, err := builder.statement().renderJoins(builder.dialect)
+	if err != nil {
+		ThrowException(err)
+		builder.Err = err
+	}
+	return joins
 }
 
 func (builder *QueryBuilder) GetWhereClause() string {
-	return builder.whereClause
+	return builder.statement().where
+}
+
+func (builder *QueryBuilder) GetSelectColumns() []*Column {
+	var columns []*Column
+	for _, item := range builder.statement().selectItems {
+		if item.column != nil {
+			columns = append(columns, item.column)
+		}
+	}
+	return columns
+}
+
+func (builder *QueryBuilder) GetFromTable() string {
+	return builder.statement().fromTable
+}
+
+func (builder *QueryBuilder) GetGroupBy() string {
+	return builder.statement().groupBy
+}
+
+func (builder *QueryBuilder) GetHavingClause() string {
+	return builder.statement().having
+}
+
+func (builder *QueryBuilder) GetOrderBy() []string {
+	return builder.statement().orderBy
+}
+
+func (builder *QueryBuilder) GetReturning() string {
+	return builder.statement().returning
 }
 
 func (builder *QueryBuilder) GetTableName() string {
 	return builder.tableName
 }
 
+func (builder *QueryBuilder) GetDialect() Dialect {
+	return builder.dialect
+}
+
+func (builder *QueryBuilder) SetDialect(dialect Dialect) *QueryBuilder {
+	if dialect == nil {
+		err := cErrors.New("SetDialect: dialect cannot be nil")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+	builder.dialect = dialect
+	return builder
+}
+
+// GetStatementType is the type of the first statement, e.g. UPDATE for WITH x AS (UPDATE ...) SELECT ...
+func (builder *QueryBuilder) GetStatementType() StatementType {
+	for _, part := range builder.parts {
+		if part.statement != nil && part.statement.statementType != STATEMENT_UNKNOWN {
+			return part.statement.statementType
+		}
+	}
+	return STATEMENT_UNKNOWN
+}
+
+func (builder *QueryBuilder) HasOrderBy() bool {
+	return builder.statement().has(CLAUSE_ORDER_BY)
+}
+
 func (builder *QueryBuilder) GetPrimaryKeyColumns() []string {
 	return builder.primaryKeyColumns
 }
@@ -470,6 +838,100 @@ func (builder *QueryBuilder) AddPrimaryKeyColumns(column string) *QueryBuilder {
 	return builder
 }
 
+/**************** TREE ****************/
+
+// statement is the statement the fluent methods are filling in, the last one
+func (builder *QueryBuilder) statement() *queryStatement {
+	for index := len(builder.parts) - 1; index >= 0; index-- {
+		if builder.parts[index].statement != nil {
+			return builder.parts[index].statement
+		}
+	}
+
+	statement := newQueryStatement()
+	builder.parts = append(builder.parts, &queryPart{statement: statement})
+	return statement
+}
+
+func (builder *QueryBuilder) startStatement(statementType StatementType, clause ClauseKind) *queryStatement {
+	statement := builder.statement()
+	if statement.statementType != STATEMENT_UNKNOWN {
+		statement = newQueryStatement()
+		builder.parts = append(builder.parts, &queryPart{statement: statement})
+	}
+
+	statement.statementType = statementType
+	statement.openClause = clause
+	return statement
+}
+
+func (builder *QueryBuilder) openClause(clause ClauseKind) *queryStatement {
+	statement := builder.statement()
+	statement.openClause = clause
+	return statement
+}
+
+func (builder *QueryBuilder) addText(text string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+
+	//WITHOUT A STATEMENT THE TEXT STANDS ON ITS OWN, e.g. A RAW QUERY THAT IS LATER PAGED WITH Limit
+	if len(builder.parts) == 0 || builder.parts[len(builder.parts)-1].statement == nil {
+		builder.parts = append(builder.parts, &queryPart{text: text})
+		return
+	}
+	builder.statement().addText(text)
+}
+
+func (builder *QueryBuilder) conflictColumns() []string {
+	if len(builder.primaryKeyColumns) == 0 && builder.primaryKeyColumn != "" {
+		return []string{builder.primaryKeyColumn}
+	}
+	return builder.primaryKeyColumns
+}
+
+// render joins the parts. returning is added to the first INSERT, UPDATE or DELETE
+func (builder *QueryBuilder) render(dialect Dialect, returning string) (string, error) {
+	var pieces []string
+	returningAdded := false
+
+	if len(builder.commonTableExpressions) > 0 {
+		with, err := renderCommonTableExpressions(builder.commonTableExpressions, dialect)
+		if err != nil {
+			return "", err
+		}
+		pieces = append(pieces, with)
+	}
+
+	for _, part := range builder.parts {
+		if part.statement == nil {
+			pieces = append(pieces, strings.TrimSpace(part.text))
+			continue
+		}
+
+		if err := builder.checkBounded(part.statement); err != nil {
+			return "", err
+		}
+
+		statementReturning := ""
+		if returning != "" && !returningAdded && part.statement.isDataModification() {
+			statementReturning = returning
+			returningAdded = true
+		}
+
+		query, err := part.statement.render(dialect, builder.conflictColumns(), statementReturning)
+		if err != nil {
+			return "", err
+		}
+		if query != "" {
+			pieces = append(pieces, query)
+		}
+	}
+
+	return strings.Join(pieces, " "), nil
+}
+
 /**************** UTILITY FUNCTIONS ****************/
 
 func concatenateUpdateSet(hashmap *CypressHashMap) string {
@@ -490,24 +952,17 @@ func concatenateUpdateSet(hashmap *CypressHashMap) string {
 	return buf.String()
 }
 
-func concatenateColumnNamesForFetch(builder *QueryBuilder) string {
+func concatenateColumnNamesForFetch(dialect Dialect, columns []*Column) string {
 	var buf bytes.Buffer
-	length := len(builder.fetchColumnsList)
+	length := len(columns)
 	for index := 0; index < length; index++ {
-		column := builder.fetchColumnsList[index]
+		column := columns[index]
 
-		if column.Aggregate != NO_AGGREGATE {
-			buf.WriteString(column.Aggregate.name())
-			buf.WriteString("(")
-			buf.WriteString(column.ColumnName)
-			buf.WriteString(")")
-		} else {
-			buf.WriteString(column.ColumnName)
-		}
+		buf.WriteString(column.expression(dialect))
 
 		if column.ColumnAlias != "" {
 			buf.WriteString(" AS ")
-			buf.WriteString(column.ColumnAlias)
+			buf.WriteString(quoteIdentifier(dialect, column.ColumnAlias))
 		}
 
 		if index != length-1 {
@@ -534,22 +989,17 @@ func concatenateColumnNames(columns []string) string {
 	return buf.String()
 }
 
-func concatenateOrderByColumnNames(columns []*ColumnOrderBy) string {
+func concatenateOrderByColumnNames(dialect Dialect, columns []*ColumnOrderBy) string {
 	var buf bytes.Buffer
 	length := len(columns)
 	for index := 0; index < length; index++ {
 		columnOrderBy := columns[index]
 
-		if columnOrderBy.Aggregate != NO_AGGREGATE {
-			buf.WriteString(columnOrderBy.Aggregate.name())
-			buf.WriteString("(")
-			buf.WriteString(columnOrderBy.ColumnName)
-			buf.WriteString(")")
-		} else {
-			buf.WriteString(columnOrderBy.ColumnName)
+		buf.WriteString(columnOrderBy.expression(dialect))
+		if columnOrderBy.OrderBy_ != NO_ORDER {
+			buf.WriteString(" ")
+			buf.WriteString(columnOrderBy.OrderBy_.name())
 		}
-		buf.WriteString(" ")
-		buf.WriteString(columnOrderBy.OrderBy_.name())
 
 		if index != length-1 {
 			buf.WriteString(", ")
@@ -584,13 +1034,13 @@ func validateSelection(whereClause string) error {
 	return nil
 }
 
-func validateTableName(tableName, errorMessage string) error {
+func validateTableName(tableName, errorMessage, errorLocation string) error {
 	if tableName == "" {
 		err := cErrors.New(errorMessage)
 		ThrowException(err)
 		return err
 	}
-	return nil
+	return validateTableReference(tableName, errorLocation)
 }
 
 func validateUpdateSet(hashmap *CypressHashMap) error {
@@ -609,6 +1059,9 @@ func validateUpdateSet(hashmap *CypressHashMap) error {
 			ThrowException(err)
 			return err
 		}
+		if err := validateIdentifier(column, "UPDATE: "); err != nil {
+			return err
+		}
 
 		if len(namedVar) < 2 {
 			err := cErrors.New("UPDATE: named variable for update is empty")
@@ -642,3 +1095,8 @@ func validateColumnArg(columnArg, message string) error {
 
 	return nil
 }
+
+// rawQueryBuilderFrom gives a copy of the caller's builder, rendered for the organization's dialect
+func rawQueryBuilderFrom(queryBuilder *QueryBuilder, dialect Dialect) *QueryBuilder {
+	return queryBuilder.Clone().SetDialect(dialect)
+}
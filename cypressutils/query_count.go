@@ -0,0 +1,218 @@
+package cypressutils
+
+import (
+	cErrors "github.com/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+// WINDOW_COUNT_COLUMN is the column COUNT(*) OVER () is selected as in a page of a builder that counts with CountOver
+const WINDOW_COUNT_COLUMN = "window_total_count"
+
+// CountOver makes the paged selects take the total from COUNT(*) OVER () in the page itself, in the same round
+// trip, instead of running a count query after it. A page past the last one has no row to carry the total and
+// is counted the usual way, so is a DISTINCT select, where the window would see the rows before DISTINCT
+func (builder *QueryBuilder) CountOver() *QueryBuilder {
+	builder.countOver = true
+	return builder
+}
+
+// countQueryBuilder counts the rows of the whole statement, joins, GROUP BY, HAVING and DISTINCT included, by
+// selecting COUNT(*) from it
+func countQueryBuilder(queryBuilder *QueryBuilder, dialect Dialect) *QueryBuilder {
+	return NewQueryBuilder(dialect).copyCommonTableExpressions(queryBuilder).Select().SelectExpression("COUNT(*) AS count").
+		fromQuery(rowsQueryBuilder(queryBuilder, dialect), "count_query")
+}
+
+// existsQueryBuilder selects a single row of the statement, if it has any
+func existsQueryBuilder(queryBuilder *QueryBuilder, dialect Dialect) *QueryBuilder {
+	existsBuilder := NewQueryBuilder(dialect).copyCommonTableExpressions(queryBuilder).Select().SelectExpression("1 AS found").
+		fromQuery(rowsQueryBuilder(queryBuilder, dialect), "exists_query")
+
+	statement := existsBuilder.openClause(CLAUSE_LIMIT)
+	statement.limit = &limitClause{numOfRecordsVariable: ":exists_num_of_records", offsetVariable: ":exists_offset"}
+	return existsBuilder.SetArgument(":exists_num_of_records", 1).SetArgument(":exists_offset", 0)
+}
+
+// rowsQueryBuilder copies the builder without what has no bearing on the number of rows: the ORDER BY, the LIMIT,
+// the lock and, when the rows do not depend on it, the select list. A join's columns would otherwise clash in the
+// derived table on MySQL and SQL Server. The CTEs are left to the outer query, not every dialect takes a WITH in a FROM
+func rowsQueryBuilder(queryBuilder *QueryBuilder, dialect Dialect) *QueryBuilder {
+	rowsBuilder := rawQueryBuilderFrom(queryBuilder, dialect).RemoveClause(CLAUSE_WITH, CLAUSE_ORDER_BY, CLAUSE_LIMIT, CLAUSE_LOCK)
+
+	statement := rowsBuilder.statement()
+	if len(rowsBuilder.parts) == 1 && statement.hasDispensableSelectList() {
+		statement.selectItems = []*selectItem{{column: &Column{ColumnName: "1", ColumnAlias: "counted"}}}
+	}
+	return rowsBuilder
+}
+
+// fromQuery selects from subQuery, rendered for the builder's dialect, as from a table called alias
+func (builder *QueryBuilder) fromQuery(subQuery *QueryBuilder, alias string) *QueryBuilder {
+	if subQuery.Err != nil {
+		builder.Err = subQuery.Err
+		return builder
+	}
+
+	query, err := subQuery.render(builder.dialect, "")
+	if err != nil {
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	builder.SetArguments(subQuery.arguments)
+	statement := builder.openClause(CLAUSE_FROM)
+	statement.hasFrom = true
+	statement.fromTable = "(" + query + ") " + alias
+	return builder
+}
+
+// addWindowCount selects COUNT(*) OVER () in the page of a builder that counts with CountOver. It tells whether
+// it could, a UNION would only be counted in its last SELECT and Oracle takes no other column next to a bare *
+func addWindowCount(qrQueryBuilder *QueryBuilder) bool {
+	statement := qrQueryBuilder.statement()
+	if !qrQueryBuilder.countOver || len(qrQueryBuilder.parts) != 1 || statement.statementType != STATEMENT_SELECT ||
+		statement.isDistinct() {
+		return false
+	}
+
+	for _, item := range statement.selectItems {
+		if item.column == nil {
+			return false
+		}
+		if item.column.ColumnName == "*" && qrQueryBuilder.dialect.GetDatabaseServer() == Oracle {
+			return false
+		}
+	}
+
+	qrQueryBuilder.selectColumns(&Column{Aggregate: COUNT, Window: NewWindow(), ColumnAlias: WINDOW_COUNT_COLUMN})
+	return true
+}
+
+// takeWindowCount reads the total addWindowCount selected and drops its column from the records. There is
+// nothing to read from an empty page
+func takeWindowCount(records interface{}) (int, bool) {
+	cypressList, ok := records.(*CypressArrayList)
+	if !ok || cypressList == nil || cypressList.Size() == 0 {
+		return 0, false
+	}
+
+	count := readCount(cypressList.GetRecord(0), WINDOW_COUNT_COLUMN)
+	for index := 0; index < cypressList.Size(); index++ {
+		record := cypressList.GetRecord(index)
+		record.RemoveColumn(WINDOW_COUNT_COLUMN)
+		record.RemoveColumn(strings.ToUpper(WINDOW_COUNT_COLUMN))
+	}
+	return count, true
+}
+
+// countFrom reads the count of a countQueryBuilder out of the data of its wrapper
+func countFrom(twrapper *TransactionWrapper) (int, error) {
+	if twrapper.HasErrors {
+		return 0, cErrors.New("COUNT: " + twrapper.GetErrors())
+	}
+
+	cypressList, ok := twrapper.GetData().(*CypressArrayList)
+	if !ok || cypressList == nil || cypressList.Size() == 0 {
+		return 0, cErrors.New("COUNT: count query returned no row")
+	}
+	return readCount(cypressList.GetRecord(0), "count"), nil
+}
+
+func existsFrom(twrapper *TransactionWrapper) (bool, error) {
+	if twrapper.HasErrors {
+		return false, cErrors.New("EXISTS: " + twrapper.GetErrors())
+	}
+
+	cypressList, ok := twrapper.GetData().(*CypressArrayList)
+	return ok && cypressList != nil && cypressList.Size() > 0, nil
+}
+
+// readCount takes column from record whatever type the driver gave it. Oracle hands the column names back in
+// upper case
+func readCount(record *CypressHashMap, column string) int {
+	if !record.Contains(column) {
+		column = strings.ToUpper(column)
+	}
+	count, _ := strconv.Atoi(record.GetStringValue(column))
+	return count
+}
+
+/**************** SELECT LIST ****************/
+
+// hasDispensableSelectList tells whether the statement returns as many rows whatever it selects: no DISTINCT, no
+// HAVING that may refer to the aliases, and either a GROUP BY on columns of the tables or no aggregate folding the
+// rows into one
+func (statement *queryStatement) hasDispensableSelectList() bool {
+	if statement.statementType != STATEMENT_SELECT || statement.having != "" || statement.isDistinct() ||
+		len(statement.trailing[CLAUSE_SELECT]) > 0 {
+		return false
+	}
+	if statement.groupBy != "" {
+		return !statement.groupsBySelectList()
+	}
+
+	for _, item := range statement.selectItems {
+		column := item.column
+		switch {
+		case column == nil:
+			return false
+		case column.Window != nil:
+			continue
+		case column.Aggregate != NO_AGGREGATE:
+			return false
+		}
+
+		//SelectExpression IS NOT VALIDATED SO WHAT IS NOT A PLAIN COLUMN MAY WELL BE AN AGGREGATE
+		for _, name := range strings.Split(column.ColumnName, ",") {
+			name = strings.TrimSpace(name)
+			if !selectItemRegex.MatchString(name) || aggregateColumnRegex.MatchString(name) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// groupsBySelectList tells whether a GROUP BY item is a position or an alias of the select list, which the
+// GROUP BY would lose along with the select list
+func (statement *queryStatement) groupsBySelectList() bool {
+	aliases := statement.selectAliases()
+	for _, item := range strings.Split(statement.groupBy, ",") {
+		item = strings.TrimSpace(item)
+		if _, err := strconv.Atoi(item); err == nil || aliases[identifierKey(item)] {
+			return true
+		}
+	}
+	return false
+}
+
+// selectAliases are the aliases of the select list. What follows the last space of an item is taken as one, an
+// expression's alias is among them even when it cannot be told apart from the expression
+func (statement *queryStatement) selectAliases() map[string]bool {
+	aliases := make(map[string]bool)
+	for _, item := range statement.selectItems {
+		list := item.text
+		if item.column != nil {
+			list = item.column.ColumnName
+			if item.column.ColumnAlias != "" {
+				aliases[identifierKey(item.column.ColumnAlias)] = true
+			}
+		}
+
+		for _, name := range strings.Split(list, ",") {
+			if fields := strings.Fields(name); len(fields) > 1 {
+				aliases[identifierKey(fields[len(fields)-1])] = true
+			}
+		}
+	}
+	return aliases
+}
+
+func (statement *queryStatement) isDistinct() bool {
+	if statement.distinct || len(statement.distinctOn) > 0 {
+		return true
+	}
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(concatenateSelectItems(nil, statement.selectItems))), "DISTINCT")
+}
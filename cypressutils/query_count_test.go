@@ -0,0 +1,86 @@
+package cypressutils_test
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/codecypress/go-ancillary-utils/cypressfakedb"
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+var organizationCounter int64
+
+// registerFakeOrganization puts a fake database of its own behind a new organization id for the test
+func registerFakeOrganization(t *testing.T, databaseServer ...cypressutils.DbTypes) (string, *cypressfakedb.FakeDB) {
+	t.Helper()
+
+	organizationId := fmt.Sprintf("%s_%d", t.Name(), atomic.AddInt64(&organizationCounter, 1))
+	fakeDB, err := cypressfakedb.RegisterOrganization(organizationId, databaseServer...)
+	if err != nil {
+		t.Fatalf("registering %s: %v", organizationId, err)
+	}
+
+	t.Cleanup(func() {
+		cypressfakedb.UnregisterOrganization(organizationId, fakeDB)
+	})
+	return organizationId, fakeDB
+}
+
+func countQuery(t *testing.T, queryBuilder *cypressutils.QueryBuilder) string {
+	t.Helper()
+
+	organizationId, fakeDB := registerFakeOrganization(t)
+	fakeDB.Expect("count_query").WithColumns("count").AddRow(3)
+
+	if count := cypressutils.Count(organizationId, queryBuilder, cypressutils.NewMap()); count != 3 {
+		t.Fatalf("Count = %d, want 3", count)
+	}
+
+	statement := fakeDB.GetLastStatement()
+	if statement == nil {
+		t.Fatal("no statement was run")
+	}
+	return statement.Query
+}
+
+func TestCountGroupByColumnDropsSelectList(t *testing.T) {
+	queryBuilder := cypressutils.NewQueryBuilder().Select().SelectColumn("customer_id, COUNT(id) AS orders").
+		FromTable("orders").GroupBy("customer_id")
+
+	query := countQuery(t, queryBuilder)
+	if !strings.Contains(query, `SELECT 1 AS "counted" FROM "orders" GROUP BY "customer_id"`) {
+		t.Errorf("select list kept in %s", query)
+	}
+}
+
+func TestCountGroupByPositionKeepsSelectList(t *testing.T) {
+	queryBuilder := cypressutils.NewQueryBuilder().Select().SelectColumn("customer_id, COUNT(id) AS orders").
+		FromTable("orders").GroupBy("1")
+
+	query := countQuery(t, queryBuilder)
+	if !strings.Contains(query, `SELECT "customer_id", COUNT("id") AS "orders" FROM "orders" GROUP BY 1`) {
+		t.Errorf("select list dropped in %s", query)
+	}
+}
+
+func TestCountGroupBySelectAliasKeepsSelectList(t *testing.T) {
+	queryBuilder := cypressutils.NewQueryBuilder().Select().SelectColumn("customer_id AS customer, COUNT(id) AS orders").
+		FromTable("orders").GroupBy("customer")
+
+	query := countQuery(t, queryBuilder)
+	if !strings.Contains(query, `SELECT "customer_id" AS "customer", COUNT("id") AS "orders" FROM "orders" GROUP BY "customer"`) {
+		t.Errorf("select list dropped in %s", query)
+	}
+}
+
+func TestCountGroupByExpressionAliasKeepsSelectList(t *testing.T) {
+	queryBuilder := cypressutils.NewQueryBuilder().Select().SelectExpression("CASE WHEN total > 100 THEN 'big' ELSE 'small' END AS size").
+		FromTable("orders").GroupBy("size")
+
+	query := countQuery(t, queryBuilder)
+	if !strings.Contains(query, "END AS size FROM") {
+		t.Errorf("select list dropped in %s", query)
+	}
+}
@@ -0,0 +1,118 @@
+package cypressutils
+
+import (
+	cErrors "github.com/pkg/errors"
+	"strings"
+)
+
+// commonTableExpression is a WITH entry, kept as a builder so that it is rendered for the dialect of the
+// query it is part of and brings its arguments along
+type commonTableExpression struct {
+	name      string
+	columns   []string
+	query     *QueryBuilder
+	recursive bool
+}
+
+// With puts name AS (query) in front of the builder. The rest of the builder, and the paging, counting and
+// exists helpers of the repository, can then select from name like from a table
+func (builder *QueryBuilder) With(name string, query *QueryBuilder, columns ...string) *QueryBuilder {
+	return builder.with(name, query, false, columns)
+}
+
+// WithRecursive is With for a query that refers to name itself, usually an anchor SELECT followed by
+// Append("UNION ALL") and the recursive SELECT. Oracle needs the columns to be listed
+func (builder *QueryBuilder) WithRecursive(name string, query *QueryBuilder, columns ...string) *QueryBuilder {
+	return builder.with(name, query, true, columns)
+}
+
+func (builder *QueryBuilder) GetCommonTableExpression(name string) *QueryBuilder {
+	for _, cte := range builder.commonTableExpressions {
+		if strings.EqualFold(cte.name, name) {
+			return cte.query
+		}
+	}
+	return nil
+}
+
+func (builder *QueryBuilder) with(name string, query *QueryBuilder, recursive bool, columns []string) *QueryBuilder {
+	if err := validateIdentifier(name, "WITH: "); err != nil {
+		builder.Err = err
+		return builder
+	}
+
+	if query == nil {
+		err := cErrors.New("WITH: " + name + " has no query")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	if query.Err != nil {
+		builder.Err = query.Err
+		return builder
+	}
+
+	for _, column := range columns {
+		if err := validateIdentifier(column, "WITH: "); err != nil {
+			builder.Err = err
+			return builder
+		}
+	}
+
+	if builder.GetCommonTableExpression(name) != nil {
+		err := cErrors.New("WITH: " + name + " is defined twice")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	builder.commonTableExpressions = append(builder.commonTableExpressions, &commonTableExpression{
+		name:      name,
+		columns:   columns,
+		query:     query,
+		recursive: recursive,
+	})
+	return builder
+}
+
+// copyCommonTableExpressions gives a builder made from parts of another, e.g. its count, the same CTEs
+func (builder *QueryBuilder) copyCommonTableExpressions(queryBuilder *QueryBuilder) *QueryBuilder {
+	for _, cte := range queryBuilder.commonTableExpressions {
+		copied := *cte
+		builder.commonTableExpressions = append(builder.commonTableExpressions, &copied)
+	}
+	return builder
+}
+
+func renderCommonTableExpressions(commonTableExpressions []*commonTableExpression, dialect Dialect) (string, error) {
+	recursive := false
+	definitions := make([]string, 0, len(commonTableExpressions))
+
+	for _, cte := range commonTableExpressions {
+		if cte.recursive {
+			recursive = true
+			if dialect.GetDatabaseServer() == Oracle && len(cte.columns) == 0 {
+				return "", cErrors.New("WITH: Oracle needs the columns of the recursive " + cte.name + " to be listed")
+			}
+		}
+
+		query, err := cte.query.render(dialect, "")
+		if err != nil {
+			return "", err
+		}
+
+		definition := quoteIdentifier(dialect, cte.name)
+		if len(cte.columns) > 0 {
+			definition += " (" + strings.Join(quoteIdentifiers(dialect, cte.columns), ", ") + ")"
+		}
+		definitions = append(definitions, definition+" AS ("+query+")")
+	}
+
+	//SQL SERVER AND ORACLE TELL RECURSIVE CTEs APART THEMSELVES AND REJECT THE KEYWORD
+	with := "WITH "
+	if recursive && (dialect.GetDatabaseServer() == PostgreSQL || dialect.GetDatabaseServer() == MySQL) {
+		with = "WITH RECURSIVE "
+	}
+	return with + strings.Join(definitions, ", "), nil
+}
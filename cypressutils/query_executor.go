@@ -3,7 +3,7 @@ package cypressutils
 //NOTE: THIS CLASS AUTO-COMMITS ALL TRANSACTIONS
 
 import (
-	"bytes"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -15,10 +15,16 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"time"
 )
 
-func insert(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
+func insert(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
 	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "insert", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer markQueryTimeout(ctx, organizationId, twrapper, nil)
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -27,11 +33,12 @@ func insert(organizationId string, queryBuilder *QueryBuilder, queryArguments *C
 		}
 	}()
 
-	if err := queryBuilder.Err; err != nil {
+	if err := queryBuilder.renderError(); err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
 		return twrapper
 	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
 
 	dbConn, err := GetConnection(organizationId)
 	if err != nil {
@@ -41,9 +48,7 @@ func insert(organizationId string, queryBuilder *QueryBuilder, queryArguments *C
 		return twrapper
 	}
 
-	defer dbConn.Close()
-
-	tempQuery := queryBuilder.ToString() + " RETURNING *"
+	tempQuery := queryBuilder.ToStringReturning("*")
 
 	_, err = validateQueryArguments(tempQuery, queryArguments)
 	if err != nil {
@@ -52,7 +57,7 @@ func insert(organizationId string, queryBuilder *QueryBuilder, queryArguments *C
 		return twrapper
 	}
 
-	namedParameter := NewNamedParameterQuery(tempQuery, queryArguments)
+	namedParameter := NewNamedParameterQuery(tempQuery, queryArguments, GetOrganizationDialect(organizationId))
 
 	twrapper.AddQueryExecuted(tempQuery)
 	/*if showSql, _ := ConfShowSQL(); showSql {
@@ -62,7 +67,20 @@ func insert(organizationId string, queryBuilder *QueryBuilder, queryArguments *C
 	parsedQuery := namedParameter.GetParsedQuery()
 	parsedParameters := namedParameter.GetParsedParameters()
 
-	resRows, err := dbConn.Query(parsedQuery, parsedParameters...)
+	if queryBuilder.GetDialect().ReturningStyle() == RETURNING_NOT_SUPPORTED {
+		result, err := dbConn.ExecContext(ctx, parsedQuery, parsedParameters...)
+		if err != nil {
+			twrapper.SetHasErrors(true)
+			twrapper.AddError(err.Error())
+			ThrowException(cErrors.Cause(err))
+			return twrapper
+		}
+
+		twrapper.SetData(insertedRecordFromArguments(queryArguments, result))
+		return twrapper
+	}
+
+	resRows, err := dbConn.QueryContext(ctx, parsedQuery, parsedParameters...)
 
 	if err != nil {
 		twrapper.SetHasErrors(true)
@@ -71,6 +89,8 @@ func insert(organizationId string, queryBuilder *QueryBuilder, queryArguments *C
 		return twrapper
 	}
 
+	defer resRows.Close()
+
 	columns, err := resRows.Columns()
 	if err != nil {
 		twrapper.SetHasErrors(true)
@@ -127,8 +147,16 @@ func insert(organizationId string, queryBuilder *QueryBuilder, queryArguments *C
 	return twrapper
 }
 
-func batchInsert(organizationId string, queryBuilder *QueryBuilder, queryArgsList *CypressArrayList) (twrapper *TransactionWrapper) {
-	twrapper = NewTransactionWrapper(false)
+// batchInsert runs insertChunks in a transaction of its own. BATCH_ALL_OR_NOTHING rolls it back when a chunk
+// fails, BATCH_BEST_EFFORT commits the chunks that went in unless a failed chunk could not be undone
+func batchInsert(ctx context.Context, organizationId string, queryBuilders []*QueryBuilder, queryArgumentsList []*CypressHashMap,
+	mode BatchMode, returning bool) (twrapper *TransactionWrapper) {
+	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "batchInsert", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer markQueryTimeout(ctx, organizationId, twrapper, nil)
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -137,13 +165,15 @@ func batchInsert(organizationId string, queryBuilder *QueryBuilder, queryArgsLis
 		}
 	}()
 
-	if err := queryBuilder.Err; err != nil {
+	dbConn, err := GetConnection(organizationId)
+	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
 		return twrapper
 	}
 
-	dbConn, err := GetConnection(organizationId)
+	trx, err := dbConn.BeginTx(ctx, nil)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -151,9 +181,27 @@ func batchInsert(organizationId string, queryBuilder *QueryBuilder, queryArgsLis
 		return twrapper
 	}
 
-	defer dbConn.Close()
+	chunksWrapper, err := insertChunks(ctx, organizationId, trx, queryBuilders, queryArgumentsList, mode, returning)
+	twrapper.CopyFrom(chunksWrapper)
+	twrapper.SetData(chunksWrapper.GetData())
+	if err != nil {
+		if err2 := trx.Rollback(); err2 != nil {
+			twrapper.AddError(err2.Error())
+			logrus.Error(err2)
+		}
 
-	trx, err := dbConn.Begin()
+		twrapper.SetHasErrors(true)
+		twrapper.AddError("BATCH INSERT: rolled back, no record was inserted")
+		twrapper.Messages = []string{}
+		if returning {
+			twrapper.SetData(NewList())
+		} else {
+			twrapper.SetData(make([]int64, len(queryBuilders)))
+		}
+		return twrapper
+	}
+
+	err = trx.Commit()
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -161,61 +209,67 @@ func batchInsert(organizationId string, queryBuilder *QueryBuilder, queryArgsLis
 		return twrapper
 	}
 
-	parsedQueryParams := []interface{}{}
-	shouldAddMAXComma := false
+	//Stats Give the Connection Pool Details. Lol. Found them by accident
+	//dbConn.Stats().MaxLifetimeClosed
+	return twrapper
+}
 
-	var insertValuesQMark bytes.Buffer
+// bulkUpdate runs txBulkUpdate in a transaction of its own, rolled back when a chunk fails
+func bulkUpdate(ctx context.Context, organizationId string, queryBuilders []*QueryBuilder, queryArgumentsList []*CypressHashMap) (twrapper *TransactionWrapper) {
+	twrapper = NewTransactionWrapper()
 
-	length := queryArgsList.Size()
-	for i := 0; i < length; i++ {
-		if shouldAddMAXComma {
-			insertValuesQMark.WriteString(",")
-		} else {
-			shouldAddMAXComma = true
-		}
+	dbConn, err := GetConnection(organizationId)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
 
-		insertValuesQMark.WriteString("(")
-		hashMap := queryArgsList.GetRecord(i)
-		shouldAddMinComma := false
-		for pair := hashMap.GetData().Oldest(); pair != nil; pair = pair.Next() {
-			//column := fmt.Sprintf("%v", pair.Key)
-			parsedQueryParams = append(parsedQueryParams, pair.Value)
-			if shouldAddMinComma {
-				insertValuesQMark.WriteString(",")
-			} else {
-				shouldAddMinComma = true
-			}
-			insertValuesQMark.WriteString("?")
+	trx, err := dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
+
+	twrapper, err = txBulkUpdate(ctx, organizationId, trx, queryBuilders, queryArgumentsList)
+	if err != nil {
+		if err2 := trx.Rollback(); err2 != nil {
+			twrapper.AddError(err2.Error())
+			logrus.Error(err2)
 		}
 
-		insertValuesQMark.WriteString(")")
+		twrapper.AddError("BULK UPDATE: rolled back, no record was updated")
+		twrapper.Messages = []string{}
+		twrapper.SetData(make([]int64, len(queryBuilders)))
+		return twrapper
 	}
 
-	queryBuilder.ValuesConcatenated(insertValuesQMark.String())
-
-	tempQuery := queryBuilder.ToString()
+	err = trx.Commit()
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
+	return twrapper
+}
 
-	twrapper.AddQueryExecuted(tempQuery)
-	/*if showSql, _ := ConfShowSQL(); showSql {
-		fmt.Println(FormatSQL(tempQuery))
-	}*/
+// bulkLoad runs txBulkLoad in a transaction of its own, rolled back unless every row is loaded
+func bulkLoad(ctx context.Context, organizationId, tableName string, columns []string, source RowSource) (twrapper *TransactionWrapper) {
+	twrapper = NewTransactionWrapper()
 
-	_, err = trx.Exec(tempQuery, parsedQueryParams...)
+	dbConn, err := GetConnection(organizationId)
 	if err != nil {
-		err2 := trx.Rollback()
-		if err2 != nil {
-			twrapper.SetHasErrors(true)
-			twrapper.AddError(err.Error())
-			logrus.Error(err2)
-		}
-
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
 		ThrowException(cErrors.Cause(err))
 		return twrapper
 	}
 
-	err = trx.Commit()
+	trx, err := dbConn.BeginTx(ctx, nil)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -223,15 +277,30 @@ func batchInsert(organizationId string, queryBuilder *QueryBuilder, queryArgsLis
 		return twrapper
 	}
 
-	twrapper.SetData(true)
-	//Stats Give the Connection Pool Details. Lol. Found them by accident
+	twrapper, err = txBulkLoad(ctx, organizationId, trx, tableName, columns, source)
+	if err != nil {
+		if err2 := trx.Rollback(); err2 != nil {
+			twrapper.AddError(err2.Error())
+			logrus.Error(err2)
+		}
 
-	//dbConn.Stats().MaxLifetimeClosed
+		twrapper.AddError("BULK LOAD: rolled back, no record was loaded")
+		twrapper.SetData(int64(0))
+		return twrapper
+	}
+
+	err = trx.Commit()
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
 	return twrapper
 }
 
-func getAutoIncrementPrimaryKey(organizationId string, dbConn *sql.DB, tableName string) (string, error) {
-	cypressList, err := getPrimaryKeyColumns(organizationId, dbConn, tableName)
+func getAutoIncrementPrimaryKey(ctx context.Context, organizationId string, dbConn *sql.DB, tableName string) (string, error) {
+	cypressList, err := getPrimaryKeyColumns(ctx, organizationId, dbConn, tableName)
 	if err != nil {
 		return "", err
 	}
@@ -239,7 +308,7 @@ func getAutoIncrementPrimaryKey(organizationId string, dbConn *sql.DB, tableName
 	length := cypressList.Size()
 	for index := 0; index < length; index++ {
 		hashMap := cypressList.GetRecord(index)
-		if strings.Contains(hashMap.GetStringValueOrIfNull("column_default", ""), "nextval") {
+		if GetOrganizationDialect(organizationId).IsAutoIncrementColumn(hashMap) {
 			return hashMap.GetStringValue("column_name"), nil
 		}
 	}
@@ -247,7 +316,10 @@ func getAutoIncrementPrimaryKey(organizationId string, dbConn *sql.DB, tableName
 	return "", nil
 }
 
-func getPrimaryKeyColumns(organizationId string, dbConn *sql.DB, tableName string) (*CypressArrayList, error) {
+func getPrimaryKeyColumns(ctx context.Context, organizationId string, dbConn *sql.DB, tableName string) (*CypressArrayList, error) {
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+
 	databaseName := GetConDSN(organizationId).GetDatabaseName()
 
 	arr := strings.Split(tableName, ".")
@@ -256,32 +328,20 @@ func getPrimaryKeyColumns(organizationId string, dbConn *sql.DB, tableName strin
 		return nil, errors.New("missing schema in table name")
 	}
 
-	strSQL := "SELECT kcu.column_name AS column_name, c.column_default\n" +
-		"   FROM information_schema.table_constraints tco\n" +
-		"         JOIN information_schema.key_column_usage kcu\n" +
-		"              ON kcu.constraint_name = tco.constraint_name\n" +
-		"                  AND kcu.constraint_schema = tco.constraint_schema\n" +
-		"         JOIN information_schema.columns c\n" +
-		"              ON kcu.column_name = c.column_name\n" +
-		"                  AND kcu.table_schema = c.table_schema\n" +
-		"                  AND kcu.table_name = c.table_name\n" +
-		"\n" +
-		"   WHERE tco.constraint_type = 'PRIMARY KEY'\n" +
-		"       AND kcu.table_catalog = :database_name\n" +
-		"       AND kcu.table_schema = :schema_name\n" +
-		"       AND kcu.table_name = :table_name"
+	dialect := GetOrganizationDialect(organizationId)
+	strSQL := dialect.PrimaryKeyColumnsQuery()
 
 	queryArguments := NewMap()
 	queryArguments.PutValue(":database_name", databaseName)
 	queryArguments.PutValue(":schema_name", arr[0])
 	queryArguments.PutValue(":table_name", arr[1])
 
-	namedParameter := NewNamedParameterQuery(strSQL, queryArguments)
+	namedParameter := NewNamedParameterQuery(strSQL, queryArguments, dialect)
 
 	parsedQuery := namedParameter.GetParsedQuery()
 	parsedParameters := namedParameter.GetParsedParameters()
 
-	resRows, err := dbConn.Query(parsedQuery, parsedParameters...)
+	resRows, err := dbConn.QueryContext(ctx, parsedQuery, parsedParameters...)
 	if err != nil {
 		return nil, err
 	}
@@ -338,7 +398,16 @@ func getPrimaryKeyColumns(organizationId string, dbConn *sql.DB, tableName strin
 }
 
 func RawQuery(organizationId string, query string, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
+	return RawQueryContext(context.Background(), organizationId, query, queryArguments)
+}
+
+func RawQueryContext(ctx context.Context, organizationId string, query string, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
 	twrapper = NewTransactionWrapper(false)
+	defer recordQueryMetrics(organizationId, "rawQuery", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer markQueryTimeout(ctx, organizationId, twrapper, nil)
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -355,8 +424,6 @@ func RawQuery(organizationId string, query string, queryArguments *CypressHashMa
 		return twrapper
 	}
 
-	defer dbConn.Close()
-
 	_, err = validateQueryArguments(query, queryArguments)
 	if err != nil {
 		twrapper.SetHasErrors(true)
@@ -369,11 +436,11 @@ func RawQuery(organizationId string, query string, queryArguments *CypressHashMa
 		fmt.Println(FormatSQL(query))
 	}*/
 
-	namedParameter := NewNamedParameterQuery(query, queryArguments)
+	namedParameter := NewNamedParameterQuery(query, queryArguments, GetOrganizationDialect(organizationId))
 	parsedSelectQuery := namedParameter.GetParsedQuery()
 	parsedSelectParameters := namedParameter.GetParsedParameters()
 
-	_, err = dbConn.Exec(parsedSelectQuery, parsedSelectParameters...)
+	_, err = dbConn.ExecContext(ctx, parsedSelectQuery, parsedSelectParameters...)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -386,8 +453,13 @@ func RawQuery(organizationId string, query string, queryArguments *CypressHashMa
 	return twrapper
 }
 
-func selectData(organizationId string, query string, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
+func selectData(ctx context.Context, organizationId string, query string, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
 	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "selectData", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer markQueryTimeout(ctx, organizationId, twrapper, nil)
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -396,7 +468,7 @@ func selectData(organizationId string, query string, queryArguments *CypressHash
 		}
 	}()
 
-	dbConn, err := GetConnection(organizationId)
+	dbConn, err := GetReadConnection(ctx, organizationId)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -404,8 +476,6 @@ func selectData(organizationId string, query string, queryArguments *CypressHash
 		return twrapper
 	}
 
-	defer dbConn.Close()
-
 	_, err = validateQueryArguments(query, queryArguments)
 	if err != nil {
 		twrapper.SetHasErrors(true)
@@ -418,11 +488,11 @@ func selectData(organizationId string, query string, queryArguments *CypressHash
 		fmt.Println(FormatSQL(query))
 	}*/
 
-	namedParameter := NewNamedParameterQuery(query, queryArguments)
+	namedParameter := NewNamedParameterQuery(query, queryArguments, GetOrganizationDialect(organizationId))
 	parsedSelectQuery := namedParameter.GetParsedQuery()
 	parsedSelectParameters := namedParameter.GetParsedParameters()
 
-	resRows, err := dbConn.Query(parsedSelectQuery, parsedSelectParameters...)
+	resRows, err := dbConn.QueryContext(ctx, parsedSelectQuery, parsedSelectParameters...)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -491,8 +561,17 @@ func selectData(organizationId string, query string, queryArguments *CypressHash
 	return twrapper
 }
 
-func update(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
+func update(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
+	if maxAffectedRows(ctx) > 0 {
+		return limitAffectedRows(ctx, organizationId, queryBuilder, queryArguments, STATEMENT_UPDATE)
+	}
+
 	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "update", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer markQueryTimeout(ctx, organizationId, twrapper, nil)
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -501,11 +580,12 @@ func update(organizationId string, queryBuilder *QueryBuilder, queryArguments *C
 		}
 	}()
 
-	if err := queryBuilder.Err; err != nil {
+	if err := queryBuilder.renderError(); err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
 		return twrapper
 	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
 
 	dbConn, err := GetConnection(organizationId)
 	if err != nil {
@@ -515,8 +595,6 @@ func update(organizationId string, queryBuilder *QueryBuilder, queryArguments *C
 		return twrapper
 	}
 
-	defer dbConn.Close()
-
 	_, err = validateQueryArguments(queryBuilder.ToString(), queryArguments)
 	if err != nil {
 		twrapper.SetHasErrors(true)
@@ -529,11 +607,11 @@ func update(organizationId string, queryBuilder *QueryBuilder, queryArguments *C
 		fmt.Println(FormatSQL(queryBuilder.ToString()))
 	}*/
 
-	namedParameter := NewNamedParameterQuery(queryBuilder.ToString(), queryArguments)
+	namedParameter := NewNamedParameterQuery(queryBuilder.ToString(), queryArguments, GetOrganizationDialect(organizationId))
 	parsedSelectQuery := namedParameter.GetParsedQuery()
 	parsedSelectParameters := namedParameter.GetParsedParameters()
 
-	resRows, err := dbConn.Query(parsedSelectQuery, parsedSelectParameters...)
+	resRows, err := dbConn.QueryContext(ctx, parsedSelectQuery, parsedSelectParameters...)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -601,8 +679,17 @@ func update(organizationId string, queryBuilder *QueryBuilder, queryArguments *C
 	return twrapper
 }
 
-func deleteData(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
+func deleteData(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
+	if maxAffectedRows(ctx) > 0 {
+		return limitAffectedRows(ctx, organizationId, queryBuilder, queryArguments, STATEMENT_DELETE)
+	}
+
 	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "deleteData", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer markQueryTimeout(ctx, organizationId, twrapper, nil)
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -611,11 +698,12 @@ func deleteData(organizationId string, queryBuilder *QueryBuilder, queryArgument
 		}
 	}()
 
-	if err := queryBuilder.Err; err != nil {
+	if err := queryBuilder.renderError(); err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
 		return twrapper
 	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
 
 	dbConn, err := GetConnection(organizationId)
 	if err != nil {
@@ -625,8 +713,6 @@ func deleteData(organizationId string, queryBuilder *QueryBuilder, queryArgument
 		return twrapper
 	}
 
-	defer dbConn.Close()
-
 	_, err = validateQueryArguments(queryBuilder.ToString(), queryArguments)
 	if err != nil {
 		twrapper.SetHasErrors(true)
@@ -639,11 +725,28 @@ func deleteData(organizationId string, queryBuilder *QueryBuilder, queryArgument
 		fmt.Println(FormatSQL(queryBuilder.ToString()))
 	}*/
 
-	namedParameter := NewNamedParameterQuery(queryBuilder.ToString()+" RETURNING *", queryArguments)
+	namedParameter := NewNamedParameterQuery(queryBuilder.ToStringReturning("*"), queryArguments, GetOrganizationDialect(organizationId))
 	parsedSelectQuery := namedParameter.GetParsedQuery()
 	parsedSelectParameters := namedParameter.GetParsedParameters()
 
-	resRows, err := dbConn.Query(parsedSelectQuery, parsedSelectParameters...)
+	if queryBuilder.GetDialect().ReturningStyle() == RETURNING_NOT_SUPPORTED {
+		result, err := dbConn.ExecContext(ctx, parsedSelectQuery, parsedSelectParameters...)
+		if err != nil {
+			twrapper.SetHasErrors(true)
+			twrapper.AddError(err.Error())
+			ThrowException(cErrors.Cause(err))
+			return twrapper
+		}
+
+		//THE DELETED ROWS CANNOT BE RETURNED, ONLY HOW MANY THEY WERE
+		if rowsAffected, err := result.RowsAffected(); err == nil {
+			twrapper.AddMessage(strconv.FormatInt(rowsAffected, 10) + " record(s) deleted")
+		}
+		twrapper.SetData(NewList())
+		return twrapper
+	}
+
+	resRows, err := dbConn.QueryContext(ctx, parsedSelectQuery, parsedSelectParameters...)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -711,6 +814,59 @@ func deleteData(organizationId string, queryBuilder *QueryBuilder, queryArgument
 	return twrapper
 }
 
+// execute runs a statement that returns no rows. The data is the number of rows it affected
+func execute(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
+	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "execute", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer markQueryTimeout(ctx, organizationId, twrapper, nil)
+
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
+
+	dbConn, err := GetConnection(organizationId)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
+
+	_, err = validateQueryArguments(queryBuilder.ToString(), queryArguments)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	twrapper.AddQueryExecuted(queryBuilder.ToString())
+
+	namedParameter := NewNamedParameterQuery(queryBuilder.ToString(), queryArguments, GetOrganizationDialect(organizationId))
+	result, err := dbConn.ExecContext(ctx, namedParameter.GetParsedQuery(), namedParameter.GetParsedParameters()...)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
+	}
+	twrapper.SetData(rowsAffected)
+	return twrapper
+}
+
 func validateQueryArguments(query string, queryArguments *CypressHashMap) (*Set, error) {
 	allQueryVariables := NewSet()
 
@@ -814,3 +970,16 @@ func ParseSQLRawBytesToType(value []byte, columnName string, columnType *sql.Col
 	temp = (temp).Convert(columnType.ScanType())
 	fmt.Println("New Value", temp)*/
 }
+
+// insertedRecordFromArguments stands in for the RETURNING row on servers that cannot return one
+func insertedRecordFromArguments(queryArguments *CypressHashMap, result sql.Result) *CypressHashMap {
+	hashMap := NewMap()
+	for pair := queryArguments.GetData().Oldest(); pair != nil; pair = pair.Next() {
+		hashMap.PutValue(strings.TrimPrefix(fmt.Sprintf("%v", pair.Key), ":"), pair.Value)
+	}
+
+	if lastInsertId, err := result.LastInsertId(); err == nil {
+		hashMap.PutValue("last_insert_id", lastInsertId)
+	}
+	return hashMap
+}
@@ -0,0 +1,268 @@
+package cypressutils
+
+import (
+	"fmt"
+	cErrors "github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+type JoinType string
+
+const (
+	INNER_JOIN JoinType = "INNER JOIN"
+	LEFT_JOIN  JoinType = "LEFT JOIN"
+	RIGHT_JOIN JoinType = "RIGHT JOIN"
+	FULL_JOIN  JoinType = "FULL OUTER JOIN"
+	CROSS_JOIN JoinType = "CROSS JOIN"
+)
+
+var qualifiedColumnRegex = regexp.MustCompile("[A-Za-z_][A-Za-z0-9_$#]*(?:\\.[A-Za-z_][A-Za-z0-9_$#]*)+")
+var stringLiteralRegex = regexp.MustCompile("'[^']*'")
+
+// tableReference is a table of the FROM, UPDATE, DELETE or a JOIN, with the alias it goes by
+type tableReference struct {
+	table, alias string
+}
+
+// joinClause is either a typed join or, from JoinPhrase, raw text
+type joinClause struct {
+	joinType JoinType
+	table    string
+	alias    string
+	on       *FilterPredicate
+	text     string
+}
+
+func (join *joinClause) render(dialect Dialect) (string, error) {
+	if join.text != "" {
+		return strings.TrimSpace(join.text), nil
+	}
+
+	if join.joinType == FULL_JOIN && dialect != nil && dialect.GetDatabaseServer() == MySQL {
+		return "", cErrors.New("JOIN: FULL OUTER JOIN is not supported by " + string(MySQL))
+	}
+
+	if join.joinType != CROSS_JOIN && (join.on == nil || strings.TrimSpace(join.on.GetClause()) == "") {
+		return "", cErrors.New("JOIN: " + string(join.joinType) + " " + join.table + " has no ON condition")
+	}
+
+	joinPhrase := string(join.joinType) + " " + quoteIdentifier(dialect, join.table)
+	if join.alias != "" {
+		joinPhrase += " " + quoteIdentifier(dialect, join.alias)
+	}
+	if join.on != nil {
+		joinPhrase += " ON " + strings.TrimSpace(join.on.GetClause())
+	}
+	return joinPhrase, nil
+}
+
+func (builder *QueryBuilder) InnerJoin(tableName, alias string) *QueryBuilder {
+	return builder.join(INNER_JOIN, tableName, alias)
+}
+
+func (builder *QueryBuilder) LeftJoin(tableName, alias string) *QueryBuilder {
+	return builder.join(LEFT_JOIN, tableName, alias)
+}
+
+func (builder *QueryBuilder) RightJoin(tableName, alias string) *QueryBuilder {
+	return builder.join(RIGHT_JOIN, tableName, alias)
+}
+
+func (builder *QueryBuilder) FullJoin(tableName, alias string) *QueryBuilder {
+	return builder.join(FULL_JOIN, tableName, alias)
+}
+
+func (builder *QueryBuilder) CrossJoin(tableName, alias string) *QueryBuilder {
+	return builder.join(CROSS_JOIN, tableName, alias)
+}
+
+// On sets the condition of the last join. The values bound on the predicate go along with the builder
+func (builder *QueryBuilder) On(filterPredicate *FilterPredicate) *QueryBuilder {
+	statement := builder.statement()
+	if len(statement.joins) == 0 || statement.joins[len(statement.joins)-1].text != "" {
+		err := cErrors.New("JOIN: On must follow InnerJoin, LeftJoin, RightJoin or FullJoin")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	join := statement.joins[len(statement.joins)-1]
+	if join.joinType == CROSS_JOIN {
+		err := cErrors.New("JOIN: CROSS JOIN " + join.table + " takes no ON condition")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	if filterPredicate == nil || strings.TrimSpace(filterPredicate.GetClause()) == "" {
+		err := cErrors.New("JOIN: ON condition of " + join.table + " is empty")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	if err := statement.checkColumnReferences(filterPredicate.GetClause()); err != nil {
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	clause, err := builder.takePredicate(filterPredicate)
+	if err != nil {
+		return builder
+	}
+	join.on = NewFilterPredicate(clause)
+	return builder
+}
+
+// WhereFilter sets the WHERE clause from a GenerateFilterString statement, e.g. {u.name:eq:John}. Columns
+// qualified with an alias must refer to a table of the FROM or of a join
+func (builder *QueryBuilder) WhereFilter(filterStatement string) *QueryBuilder {
+	filter, queryArguments, columns, err := GenerateFilterString(filterStatement)
+	if err != nil {
+		builder.Err = err
+		return builder
+	}
+
+	if err := builder.CheckColumnReferences(columns); err != nil {
+		return builder
+	}
+
+	builder.SetArguments(queryArguments)
+	builder.WhereStr(filter)
+	if builder.Err == nil {
+		statement := builder.statement()
+		for _, column := range columns.GetValues() {
+			statement.filterColumns = append(statement.filterColumns, fmt.Sprintf("%v", column))
+		}
+	}
+	return builder
+}
+
+// CheckColumnReferences fails for the columns, e.g. the ones GenerateFilterString returns, whose qualifier is
+// not a table or an alias of the current statement
+func (builder *QueryBuilder) CheckColumnReferences(columns *Set) error {
+	if columns == nil {
+		return nil
+	}
+
+	statement := builder.statement()
+	for _, column := range columns.GetValues() {
+		columnName, _ := column.(string)
+		if err := statement.checkColumnReference(columnName); err != nil {
+			ThrowException(err)
+			builder.Err = err
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTableAliases maps the aliases of the current statement, and the tables without one, to their table
+func (builder *QueryBuilder) GetTableAliases() map[string]string {
+	aliases := make(map[string]string)
+	for _, reference := range builder.statement().tables {
+		if reference.alias != "" {
+			aliases[reference.alias] = reference.table
+		} else {
+			aliases[reference.table] = reference.table
+		}
+	}
+	return aliases
+}
+
+func (builder *QueryBuilder) join(joinType JoinType, tableName, alias string) *QueryBuilder {
+	if err := validateIdentifier(tableName, "JOIN: "); err != nil {
+		builder.Err = err
+		return builder
+	}
+	if alias != "" {
+		if err := validateIdentifier(alias, "JOIN: "); err != nil {
+			builder.Err = err
+			return builder
+		}
+	}
+
+	statement := builder.openClause(CLAUSE_JOIN)
+	if err := statement.addTableReference(&tableReference{table: tableName, alias: alias}); err != nil {
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	statement.joins = append(statement.joins, &joinClause{joinType: joinType, table: tableName, alias: alias})
+	return builder
+}
+
+/**************** TABLE REFERENCES ****************/
+
+func (statement *queryStatement) addTableReference(reference *tableReference) error {
+	name := reference.alias
+	if name == "" {
+		name = reference.table
+	}
+
+	for _, existing := range statement.tables {
+		if strings.EqualFold(existing.alias, name) || (existing.alias == "" && strings.EqualFold(existing.table, name)) {
+			return cErrors.New("JOIN: '" + name + "' is used twice, give the table another alias")
+		}
+	}
+
+	statement.tables = append(statement.tables, reference)
+	return nil
+}
+
+// checkColumnReferences looks for alias.column in a raw condition, leaving out string literals and
+// schema qualified function calls
+func (statement *queryStatement) checkColumnReferences(condition string) error {
+	condition = stringLiteralRegex.ReplaceAllString(condition, "''")
+
+	for _, bounds := range qualifiedColumnRegex.FindAllStringIndex(condition, -1) {
+		if strings.HasPrefix(strings.TrimSpace(condition[bounds[1]:]), "(") {
+			continue
+		}
+		if bounds[0] > 0 && condition[bounds[0]-1] == ':' {
+			continue
+		}
+		if err := statement.checkColumnReference(condition[bounds[0]:bounds[1]]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (statement *queryStatement) checkColumnReference(columnName string) error {
+	index := strings.LastIndex(columnName, ".")
+	if index <= 0 || len(statement.tables) == 0 {
+		return nil
+	}
+
+	qualifier := columnName[:index]
+	for _, reference := range statement.tables {
+		if strings.EqualFold(reference.alias, qualifier) {
+			return nil
+		}
+		if reference.alias == "" {
+			table := reference.table
+			if strings.EqualFold(table, qualifier) || strings.EqualFold(table[strings.LastIndex(table, ".")+1:], qualifier) {
+				return nil
+			}
+		}
+	}
+	return cErrors.New("COLUMN: '" + columnName + "' does not refer to a table of the query")
+}
+
+// parseTableReference splits "table alias" and "table AS alias"
+func parseTableReference(tableName string) *tableReference {
+	fields := strings.Fields(tableName)
+	switch {
+	case len(fields) == 2:
+		return &tableReference{table: fields[0], alias: fields[1]}
+	case len(fields) == 3 && strings.EqualFold(fields[1], "AS"):
+		return &tableReference{table: fields[0], alias: fields[2]}
+	case len(fields) == 1:
+		return &tableReference{table: fields[0]}
+	}
+	return nil
+}
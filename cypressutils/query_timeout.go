@@ -0,0 +1,76 @@
+package cypressutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type queryTimeoutKey struct{}
+type appliedQueryTimeoutKey struct{}
+
+// QueryTimeoutError is reported instead of the driver's cancellation message when a statement outlives its deadline
+type QueryTimeoutError struct {
+	OrganizationId string
+	Timeout        time.Duration
+	Cause          error
+}
+
+func (err *QueryTimeoutError) Error() string {
+	if err.Timeout > 0 {
+		return fmt.Sprintf("QUERY TIMEOUT: Statement cancelled after %v where Organization Id = '%s'", err.Timeout, err.OrganizationId)
+	}
+	return fmt.Sprintf("QUERY TIMEOUT: Statement cancelled by the caller's deadline where Organization Id = '%s'", err.OrganizationId)
+}
+
+func (err *QueryTimeoutError) Unwrap() error {
+	return err.Cause
+}
+
+func IsQueryTimeout(err error) bool {
+	var timeoutErr *QueryTimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// WithQueryTimeout overrides the organization's query timeout for the statements run with the returned context.
+// A timeout of zero or less disables it
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// withStatementTimeout bounds one statement by the call's override, otherwise by the organization's QueryTimeout
+func withStatementTimeout(ctx context.Context, organizationId string) (context.Context, context.CancelFunc) {
+	timeout := time.Duration(0)
+
+	if override, exists := ctx.Value(queryTimeoutKey{}).(time.Duration); exists {
+		timeout = override
+	} else if conDSN, exists := lookupConDSN(organizationId); exists {
+		timeout = conDSN.QueryTimeout
+	}
+
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	ctx = context.WithValue(ctx, appliedQueryTimeoutKey{}, timeout)
+	return context.WithTimeout(ctx, timeout)
+}
+
+// markQueryTimeout flags the wrapper when its errors came from the statement running out of time
+func markQueryTimeout(ctx context.Context, organizationId string, twrapper *TransactionWrapper, err error) error {
+	if twrapper == nil || !twrapper.HasErrors || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	timeout, _ := ctx.Value(appliedQueryTimeoutKey{}).(time.Duration)
+	timeoutErr := &QueryTimeoutError{OrganizationId: organizationId, Timeout: timeout, Cause: err}
+
+	twrapper.SetHasTimedOut(true)
+	twrapper.AddError(timeoutErr.Error())
+
+	if err == nil {
+		return nil
+	}
+	return timeoutErr
+}
@@ -0,0 +1,427 @@
+package cypressutils
+
+import (
+	"bytes"
+	cErrors "github.com/pkg/errors"
+	"strings"
+)
+
+type ClauseKind uint
+
+const (
+	CLAUSE_NONE ClauseKind = iota
+	CLAUSE_SELECT
+	CLAUSE_INSERT
+	CLAUSE_VALUES
+	CLAUSE_ON_DUPLICATE_KEY
+	CLAUSE_UPDATE
+	CLAUSE_SET
+	CLAUSE_DELETE
+	CLAUSE_FROM
+	CLAUSE_JOIN
+	CLAUSE_WHERE
+	CLAUSE_GROUP_BY
+	CLAUSE_HAVING
+	CLAUSE_ORDER_BY
+	CLAUSE_LIMIT
+	CLAUSE_RETURNING
+	CLAUSE_WITH
+	CLAUSE_LOCK
+)
+
+// queryPart is either raw text or a statement. A builder is a list of them, e.g. a Prepend-ed
+// "WITH x AS (" followed by an UPDATE and a SELECT
+type queryPart struct {
+	text      string
+	statement *queryStatement
+}
+
+type selectItem struct {
+	column *Column
+	text   string
+}
+
+type assignment struct {
+	column, value string
+}
+
+type limitClause struct {
+	numOfRecordsVariable, offsetVariable string
+}
+
+// queryStatement keeps every clause apart so that they can be set in any order and are only put in order,
+// for a given dialect, by render
+type queryStatement struct {
+	statementType  StatementType
+	table          string
+	selectItems    []*selectItem
+	distinct       bool
+	distinctOn     []string
+	hasFrom        bool
+	fromTable      string
+	joins          []*joinClause
+	tables         []*tableReference
+	where          string
+	filterColumns  []string
+	groupBy        string
+	having         string
+	orderBy        []string
+	limit          *limitClause
+	insertColumns  []string
+	valueRows      [][]string
+	valuesText     string
+	valuesKeyword  bool
+	onDuplicateKey []string
+	upsert         *upsertClause
+	assignments    []*assignment
+	returning      string
+	rowLock        *RowLock
+
+	//RAW TEXT (CASE, WHEN, Append, ...) IS KEPT AT THE END OF THE CLAUSE THAT WAS OPEN WHEN IT WAS ADDED
+	openClause ClauseKind
+	trailing   map[ClauseKind][]string
+}
+
+func newQueryStatement() *queryStatement {
+	return &queryStatement{
+		statementType: STATEMENT_UNKNOWN,
+		openClause:    CLAUSE_NONE,
+		trailing:      make(map[ClauseKind][]string),
+	}
+}
+
+func (statement *queryStatement) isDataModification() bool {
+	return statement.statementType == STATEMENT_INSERT || statement.statementType == STATEMENT_UPDATE ||
+		statement.statementType == STATEMENT_DELETE
+}
+
+func (statement *queryStatement) addText(text string) {
+	if statement.openClause == CLAUSE_SELECT {
+		statement.selectItems = append(statement.selectItems, &selectItem{text: text})
+		return
+	}
+	statement.trailing[statement.openClause] = append(statement.trailing[statement.openClause], text)
+}
+
+func (statement *queryStatement) has(kind ClauseKind) bool {
+	switch kind {
+	case CLAUSE_SELECT:
+		return statement.statementType == STATEMENT_SELECT || len(statement.selectItems) > 0
+	case CLAUSE_INSERT:
+		return statement.statementType == STATEMENT_INSERT
+	case CLAUSE_VALUES:
+		return len(statement.valueRows) > 0 || statement.valuesText != ""
+	case CLAUSE_ON_DUPLICATE_KEY:
+		return len(statement.onDuplicateKey) > 0 || statement.upsert != nil
+	case CLAUSE_UPDATE:
+		return statement.statementType == STATEMENT_UPDATE
+	case CLAUSE_SET:
+		return len(statement.assignments) > 0
+	case CLAUSE_DELETE:
+		return statement.statementType == STATEMENT_DELETE
+	case CLAUSE_FROM:
+		return statement.hasFrom
+	case CLAUSE_JOIN:
+		return len(statement.joins) > 0
+	case CLAUSE_WHERE:
+		return statement.where != ""
+	case CLAUSE_GROUP_BY:
+		return statement.groupBy != ""
+	case CLAUSE_HAVING:
+		return statement.having != ""
+	case CLAUSE_ORDER_BY:
+		return len(statement.orderBy) > 0
+	case CLAUSE_LIMIT:
+		return statement.limit != nil
+	case CLAUSE_RETURNING:
+		return statement.returning != ""
+	case CLAUSE_LOCK:
+		return statement.rowLock != nil
+	}
+	return false
+}
+
+func (statement *queryStatement) remove(kind ClauseKind) {
+	switch kind {
+	case CLAUSE_SELECT:
+		statement.selectItems = nil
+	case CLAUSE_VALUES:
+		statement.valueRows, statement.valuesText, statement.valuesKeyword = nil, "", false
+	case CLAUSE_ON_DUPLICATE_KEY:
+		statement.onDuplicateKey, statement.upsert = nil, nil
+	case CLAUSE_SET:
+		statement.assignments = nil
+	case CLAUSE_FROM:
+		statement.hasFrom, statement.fromTable = false, ""
+	case CLAUSE_JOIN:
+		for _, join := range statement.joins {
+			statement.removeTableReference(join.table, join.alias)
+		}
+		statement.joins = nil
+	case CLAUSE_WHERE:
+		statement.where, statement.filterColumns = "", nil
+	case CLAUSE_GROUP_BY:
+		statement.groupBy = ""
+	case CLAUSE_HAVING:
+		statement.having = ""
+	case CLAUSE_ORDER_BY:
+		statement.orderBy = nil
+	case CLAUSE_LIMIT:
+		statement.limit = nil
+	case CLAUSE_RETURNING:
+		statement.returning = ""
+	case CLAUSE_LOCK:
+		statement.rowLock = nil
+	}
+	delete(statement.trailing, kind)
+}
+
+func (statement *queryStatement) clone() *queryStatement {
+	clone := *statement
+	clone.selectItems = append([]*selectItem(nil), statement.selectItems...)
+	clone.joins = make([]*joinClause, len(statement.joins))
+	for index, join := range statement.joins {
+		copied := *join
+		clone.joins[index] = &copied
+	}
+	clone.tables = append([]*tableReference(nil), statement.tables...)
+	clone.orderBy = append([]string(nil), statement.orderBy...)
+	clone.filterColumns = append([]string(nil), statement.filterColumns...)
+	clone.insertColumns = append([]string(nil), statement.insertColumns...)
+	clone.onDuplicateKey = append([]string(nil), statement.onDuplicateKey...)
+	clone.assignments = append([]*assignment(nil), statement.assignments...)
+	clone.valueRows = append([][]string(nil), statement.valueRows...)
+	clone.distinctOn = append([]string(nil), statement.distinctOn...)
+	if statement.limit != nil {
+		limit := *statement.limit
+		clone.limit = &limit
+	}
+	if statement.rowLock != nil {
+		rowLock := *statement.rowLock
+		clone.rowLock = &rowLock
+	}
+
+	clone.trailing = make(map[ClauseKind][]string, len(statement.trailing))
+	for kind, texts := range statement.trailing {
+		clone.trailing[kind] = append([]string(nil), texts...)
+	}
+	return &clone
+}
+
+// render puts the clauses in the order the statement type needs. returning, when given, overrides the
+// statement's own RETURNING columns
+func (statement *queryStatement) render(dialect Dialect, conflictColumns []string, returning string) (string, error) {
+	var pieces []string
+	write := func(kind ClauseKind, texts ...string) {
+		for _, text := range texts {
+			if text = strings.TrimSpace(text); text != "" {
+				pieces = append(pieces, text)
+			}
+		}
+		if kind != CLAUSE_NONE {
+			pieces = append(pieces, statement.trailing[kind]...)
+		}
+	}
+
+	if returning == "" {
+		returning = statement.returning
+	}
+	returningStyle := dialect.ReturningStyle()
+	if returning != "" && returningStyle == RETURNING_NOT_SUPPORTED {
+		return "", cErrors.New("RETURNING STATEMENT: not supported by " + string(dialect.GetDatabaseServer()))
+	}
+
+	if err := statement.checkAllowedColumns(); err != nil {
+		return "", err
+	}
+
+	lockHint, lockClause, err := statement.renderRowLock(dialect)
+	if err != nil {
+		return "", err
+	}
+
+	write(CLAUSE_NONE, statement.trailing[CLAUSE_NONE]...)
+
+	if statement.upsert != nil {
+		upsert, err := statement.renderUpsert(dialect, returning)
+		if err != nil {
+			return "", err
+		}
+		write(CLAUSE_NONE, upsert)
+		return strings.Join(pieces, " "), nil
+	}
+
+	switch statement.statementType {
+	case STATEMENT_INSERT:
+		write(CLAUSE_INSERT, "INSERT INTO "+quoteTableReference(dialect, statement.table), concatenateInsertColumns(dialect, statement.insertColumns))
+	case STATEMENT_UPDATE:
+		write(CLAUSE_UPDATE, "UPDATE "+quoteTableReference(dialect, statement.table)+" SET")
+		write(CLAUSE_SET, concatenateAssignments(dialect, statement.assignments))
+	case STATEMENT_DELETE:
+		write(CLAUSE_DELETE, "DELETE FROM "+quoteTableReference(dialect, statement.table))
+	case STATEMENT_SELECT:
+		selectKeyword, err := statement.renderSelectKeyword(dialect)
+		if err != nil {
+			return "", err
+		}
+		write(CLAUSE_SELECT, selectKeyword+" "+concatenateSelectItems(dialect, statement.selectItems))
+	default:
+		write(CLAUSE_SELECT, concatenateSelectItems(dialect, statement.selectItems))
+	}
+
+	if returning != "" && returningStyle == RETURNING_OUTPUT_CLAUSE {
+		if !statement.isDataModification() {
+			return "", cErrors.New("RETURNING STATEMENT: no position for the OUTPUT clause in a " +
+				"statement that is not an INSERT, UPDATE or DELETE")
+		}
+		write(CLAUSE_NONE, dialect.ReturningClause(statement.statementType, returning))
+	}
+
+	if statement.has(CLAUSE_VALUES) {
+		switch {
+		case len(statement.valueRows) > 0:
+			rows := make([]string, len(statement.valueRows))
+			for index, row := range statement.valueRows {
+				rows[index] = concatenateColumnNames(row)
+			}
+			write(CLAUSE_VALUES, "VALUES "+strings.Join(rows, ", "))
+		case statement.valuesKeyword:
+			write(CLAUSE_VALUES, "VALUES "+statement.valuesText)
+		default:
+			write(CLAUSE_VALUES, statement.valuesText)
+		}
+	}
+
+	if statement.hasFrom {
+		fromTable := quoteTableReference(dialect, statement.fromTable)
+		if lockHint != "" {
+			write(CLAUSE_FROM, "FROM "+fromTable+" "+lockHint)
+		} else {
+			write(CLAUSE_FROM, "FROM "+fromTable)
+		}
+	}
+	if statement.has(CLAUSE_JOIN) {
+		joins, err := statement.renderJoins(dialect)
+		if err != nil {
+			return "", err
+		}
+		write(CLAUSE_JOIN, joins)
+	}
+	if statement.has(CLAUSE_WHERE) {
+		write(CLAUSE_WHERE, "WHERE "+statement.where)
+	}
+	if statement.has(CLAUSE_GROUP_BY) {
+		write(CLAUSE_GROUP_BY, "GROUP BY "+quoteList(dialect, statement.groupBy, groupByItemRegex))
+	}
+	if statement.has(CLAUSE_HAVING) {
+		having, err := statement.resolveAliases(dialect, statement.having)
+		if err != nil {
+			return "", err
+		}
+		write(CLAUSE_HAVING, "HAVING "+having)
+	}
+	if statement.has(CLAUSE_ORDER_BY) {
+		orderBy := make([]string, len(statement.orderBy))
+		for index, item := range statement.orderBy {
+			orderBy[index] = quoteList(dialect, item, orderByItemRegex)
+		}
+		write(CLAUSE_ORDER_BY, "ORDER BY "+strings.Join(orderBy, ", "))
+	}
+	if statement.limit != nil {
+		write(CLAUSE_LIMIT, dialect.LimitOffset(statement.limit.numOfRecordsVariable, statement.limit.offsetVariable,
+			statement.has(CLAUSE_ORDER_BY)))
+	}
+	if lockClause != "" {
+		write(CLAUSE_LOCK, lockClause)
+	}
+
+	if statement.has(CLAUSE_ON_DUPLICATE_KEY) {
+		onDuplicateKey, err := dialect.OnDuplicateKeyUpdate(conflictColumns, statement.onDuplicateKey)
+		if err != nil {
+			return "", err
+		}
+		write(CLAUSE_ON_DUPLICATE_KEY, onDuplicateKey)
+	}
+
+	if returning != "" && returningStyle == RETURNING_AFTER_STATEMENT {
+		write(CLAUSE_RETURNING, dialect.ReturningClause(statement.statementType, returning))
+	} else {
+		write(CLAUSE_RETURNING)
+	}
+
+	return strings.Join(pieces, " "), nil
+}
+
+func (statement *queryStatement) renderJoins(dialect Dialect) (string, error) {
+	joins := make([]string, 0, len(statement.joins))
+	for _, join := range statement.joins {
+		joinPhrase, err := join.render(dialect)
+		if err != nil {
+			return "", err
+		}
+		joins = append(joins, joinPhrase)
+	}
+	return strings.Join(joins, " "), nil
+}
+
+func (statement *queryStatement) addTableName(tableName string) {
+	if reference := parseTableReference(tableName); reference != nil {
+		statement.tables = append(statement.tables, reference)
+	}
+}
+
+func (statement *queryStatement) removeTableReference(table, alias string) {
+	for index, reference := range statement.tables {
+		if reference.table == table && reference.alias == alias {
+			statement.tables = append(statement.tables[:index:index], statement.tables[index+1:]...)
+			return
+		}
+	}
+}
+
+/**************** UTILITY FUNCTIONS ****************/
+
+func concatenateSelectItems(dialect Dialect, selectItems []*selectItem) string {
+	var buf bytes.Buffer
+	previousWasColumn := false
+
+	for _, item := range selectItems {
+		if item.column == nil {
+			if buf.Len() > 0 {
+				buf.WriteString(" ")
+			}
+			buf.WriteString(strings.TrimSpace(item.text))
+			previousWasColumn = false
+			continue
+		}
+
+		if previousWasColumn {
+			buf.WriteString(", ")
+		} else if buf.Len() > 0 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(concatenateColumnNamesForFetch(dialect, []*Column{item.column}))
+		previousWasColumn = true
+	}
+	return buf.String()
+}
+
+func concatenateInsertColumns(dialect Dialect, columns []string) string {
+	if columns == nil {
+		return ""
+	}
+	return concatenateColumnNames(quoteIdentifiers(dialect, columns))
+}
+
+func concatenateAssignments(dialect Dialect, assignments []*assignment) string {
+	var buf bytes.Buffer
+	for index, assignment := range assignments {
+		if index > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(quoteIdentifier(dialect, assignment.column))
+		buf.WriteString(" = ")
+		buf.WriteString(assignment.value)
+	}
+	return buf.String()
+}
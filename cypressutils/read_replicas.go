@@ -0,0 +1,162 @@
+package cypressutils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"sync/atomic"
+)
+
+const REPLICA_ORGANIZATION_ID_SEPARATOR = "#replica-"
+
+type primaryConnectionKey struct{}
+
+var replicaCursors sync.Map
+
+// WithPrimaryConnection makes the reads run with the returned context go to the primary, e.g. to read back
+// what was just written
+func WithPrimaryConnection(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryConnectionKey{}, true)
+}
+
+// ReplicaOrganizationId is the key the replica's pool is registered under. It can be handed to Stats,
+// IsHealthy or GetHealthSupervisor().GetStatus
+func ReplicaOrganizationId(organizationId string, index int) string {
+	return fmt.Sprintf("%s%s%d", organizationId, REPLICA_ORGANIZATION_ID_SEPARATOR, index)
+}
+
+// GetReadConnection hands out the organization's replicas round-robin, skipping the unhealthy ones. The
+// primary is returned when there is no healthy replica or when the context asks for it
+func GetReadConnection(ctx context.Context, organizationId string) (*sql.DB, error) {
+	if forcePrimary, _ := ctx.Value(primaryConnectionKey{}).(bool); forcePrimary {
+		return GetConnection(organizationId)
+	}
+
+	conDSN, exists := lookupConDSN(organizationId)
+	if !exists || len(conDSN.Replicas) == 0 {
+		return GetConnection(organizationId)
+	}
+
+	value, _ := replicaCursors.LoadOrStore(organizationId, new(atomic.Uint64))
+	start := value.(*atomic.Uint64).Add(1) - 1
+
+	replicaCount := uint64(len(conDSN.Replicas))
+	for offset := uint64(0); offset < replicaCount; offset++ {
+		replicaOrganizationId := ReplicaOrganizationId(organizationId, int((start+offset)%replicaCount))
+
+		dbPool, exists := lookupConnectionPool(replicaOrganizationId)
+		if exists && IsHealthy(replicaOrganizationId) {
+			return dbPool, nil
+		}
+	}
+
+	return GetConnection(organizationId)
+}
+
+// setupReplicas registers a pool per replica of the primary and retires the ones the primary no longer lists.
+// A replica that cannot be reached is left out, its reads go to the primary
+func setupReplicas(organizationId string, conDSN *DBConDSN, oldConDSN *DBConDSN) {
+	for index := range conDSN.Replicas {
+		replicaOrganizationId := ReplicaOrganizationId(organizationId, index)
+		replica := replicaConDSN(organizationId, conDSN, index)
+
+		//A RECONNECT OF THE PRIMARY LEAVES UNCHANGED REPLICAS ALONE
+		if existing, exists := lookupConDSN(replicaOrganizationId); exists && existing.sameAs(replica) {
+			continue
+		}
+
+		err := SetupDSN(replicaOrganizationId, replica)
+		if err != nil {
+			logrus.Error("Failed to set up replica where Organization Id = '" + replicaOrganizationId + "': " + err.Error())
+			closeReplica(replicaOrganizationId)
+		}
+	}
+
+	if oldConDSN != nil {
+		closeReplicas(organizationId, oldConDSN, len(conDSN.Replicas))
+	}
+}
+
+// closeReplicas closes the replicas of conDSN from index fromIndex on
+func closeReplicas(organizationId string, conDSN *DBConDSN, fromIndex int) {
+	for index := fromIndex; index < len(conDSN.Replicas); index++ {
+		closeReplica(ReplicaOrganizationId(organizationId, index))
+	}
+
+	if fromIndex == 0 {
+		replicaCursors.Delete(organizationId)
+	}
+}
+
+func closeReplica(replicaOrganizationId string) {
+	if _, exists := lookupConnectionPool(replicaOrganizationId); !exists {
+		return
+	}
+
+	if err := CloseConnection(replicaOrganizationId); err != nil {
+		logrus.Error("Failed to close replica where Organization Id = '" + replicaOrganizationId + "': " + err.Error())
+	}
+}
+
+// replicaConDSN fills what the replica leaves blank from the primary. Usually only the host and port differ
+func replicaConDSN(organizationId string, conDSN *DBConDSN, index int) *DBConDSN {
+	replica := *conDSN.Replicas[index]
+	replica.OrganizationId = ReplicaOrganizationId(organizationId, index)
+	replica.Replicas = nil
+
+	if replica.DatabaseServer == "" {
+		replica.DatabaseServer = conDSN.DatabaseServer
+	}
+	if replica.DatabaseName == "" {
+		replica.DatabaseName = conDSN.DatabaseName
+	}
+	if replica.DatabaseHost == "" {
+		replica.DatabaseHost = conDSN.DatabaseHost
+	}
+	if replica.UserName == "" {
+		replica.UserName = conDSN.UserName
+	}
+	if replica.Password == "" {
+		replica.Password = conDSN.Password
+	}
+	if replica.ConnectionParameters == "" {
+		replica.ConnectionParameters = conDSN.ConnectionParameters
+	}
+	if replica.Port == 0 {
+		replica.Port = conDSN.Port
+	}
+	if replica.MaxIdleConnections == 0 {
+		replica.MaxIdleConnections = conDSN.MaxIdleConnections
+	}
+	if replica.MaxOpenConnections == 0 {
+		replica.MaxOpenConnections = conDSN.MaxOpenConnections
+	}
+	if replica.ConnMaxLifetime == 0 {
+		replica.ConnMaxLifetime = conDSN.ConnMaxLifetime
+	}
+	if replica.ConnMaxIdleTime == 0 {
+		replica.ConnMaxIdleTime = conDSN.ConnMaxIdleTime
+	}
+	if replica.QueryTimeout == 0 {
+		replica.QueryTimeout = conDSN.QueryTimeout
+	}
+	if replica.FullScanThreshold == 0 {
+		replica.FullScanThreshold = conDSN.FullScanThreshold
+	}
+	return &replica
+}
+
+func replicasSameAs(replicas, others []*DBConDSN) bool {
+	if len(replicas) != len(others) {
+		return false
+	}
+
+	for index, replica := range replicas {
+		if !replica.sameAs(others[index]) {
+			return false
+		}
+	}
+	return true
+}
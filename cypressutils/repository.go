@@ -1,15 +1,21 @@
 package cypressutils
 
 import (
+	"context"
 	"fmt"
 	cErrors "github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"math"
 	"runtime/debug"
 	"strconv"
+	"strings"
 )
 
 func Insert(organizationId, tableName string, recordHashMap *CypressHashMap) (twrapper *TransactionWrapper) {
+	return InsertContext(context.Background(), organizationId, tableName, recordHashMap)
+}
+
+func InsertContext(ctx context.Context, organizationId, tableName string, recordHashMap *CypressHashMap) (twrapper *TransactionWrapper) {
 	queryArguments := NewMap()
 
 	for pair := recordHashMap.GetData().Oldest(); pair != nil; pair = pair.Next() {
@@ -17,13 +23,17 @@ func Insert(organizationId, tableName string, recordHashMap *CypressHashMap) (tw
 		queryArguments.PutValue(":"+field, pair.Value)
 	}
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Insert().Into(tableName).Columns(queryArguments.GetKeysNoStartColon()).Values(queryArguments.GetKeysWithStartColon())
 
-	return insert(organizationId, queryBuilder, queryArguments)
+	return insert(ctx, organizationId, queryBuilder, queryArguments)
 }
 
 func InsertFromMap(organizationId, tableName string, recordHashMap map[string]interface{}) (twrapper *TransactionWrapper) {
+	return InsertFromMapContext(context.Background(), organizationId, tableName, recordHashMap)
+}
+
+func InsertFromMapContext(ctx context.Context, organizationId, tableName string, recordHashMap map[string]interface{}) (twrapper *TransactionWrapper) {
 	queryArguments := NewMap()
 
 	for key, _value := range recordHashMap {
@@ -31,13 +41,45 @@ func InsertFromMap(organizationId, tableName string, recordHashMap map[string]in
 		queryArguments.PutValue(":"+field, _value)
 	}
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Insert().Into(tableName).Columns(queryArguments.GetKeysNoStartColon()).Values(queryArguments.GetKeysWithStartColon())
 
-	return insert(organizationId, queryBuilder, queryArguments)
+	return insert(ctx, organizationId, queryBuilder, queryArguments)
 }
 
 func InsertOnDuplicate(organizationId, tableName string, recordHashMap *CypressHashMap, onDuplicateColumns []string) (twrapper *TransactionWrapper) {
+	return InsertOnDuplicateContext(context.Background(), organizationId, tableName, recordHashMap, onDuplicateColumns)
+}
+
+// InsertOnDuplicateContext updates onDuplicateColumns of the row whose primary key is taken, see UpsertContext
+func InsertOnDuplicateContext(ctx context.Context, organizationId, tableName string, recordHashMap *CypressHashMap, onDuplicateColumns []string) (twrapper *TransactionWrapper) {
+	if onDuplicateColumns == nil {
+		return InsertContext(ctx, organizationId, tableName, recordHashMap)
+	}
+	return UpsertContext(ctx, organizationId, tableName, recordHashMap, nil, onDuplicateColumns)
+}
+
+func InsertFromMapOnDuplicate(organizationId, tableName string, onDuplicateColumns []string, recordHashMap map[string]interface{}) (twrapper *TransactionWrapper) {
+	return InsertFromMapOnDuplicateContext(context.Background(), organizationId, tableName, onDuplicateColumns, recordHashMap)
+}
+
+func InsertFromMapOnDuplicateContext(ctx context.Context, organizationId, tableName string, onDuplicateColumns []string, recordHashMap map[string]interface{}) (twrapper *TransactionWrapper) {
+	record := NewMap()
+
+	for key, _value := range recordHashMap {
+		record.PutValue(fmt.Sprintf("%v", key), _value)
+	}
+
+	return InsertOnDuplicateContext(ctx, organizationId, tableName, record, onDuplicateColumns)
+}
+
+func Upsert(organizationId, tableName string, recordHashMap *CypressHashMap, conflictColumns, updateColumns []string, options ...*UpsertOptions) (twrapper *TransactionWrapper) {
+	return UpsertContext(context.Background(), organizationId, tableName, recordHashMap, conflictColumns, updateColumns, options...)
+}
+
+// UpsertContext inserts the record or, when it conflicts on conflictColumns, updates updateColumns of the existing
+// row. Without conflictColumns the primary key is used, without updateColumns every column but the conflict ones
+func UpsertContext(ctx context.Context, organizationId, tableName string, recordHashMap *CypressHashMap, conflictColumns, updateColumns []string, options ...*UpsertOptions) (twrapper *TransactionWrapper) {
 	queryArguments := NewMap()
 
 	for pair := recordHashMap.GetData().Oldest(); pair != nil; pair = pair.Next() {
@@ -45,39 +87,246 @@ func InsertOnDuplicate(organizationId, tableName string, recordHashMap *CypressH
 		queryArguments.PutValue(":"+field, pair.Value)
 	}
 
-	queryBuilder := NewQueryBuilder()
-	queryBuilder.Insert().Into(tableName).Columns(queryArguments.GetKeysNoStartColon()).Values(queryArguments.GetKeysWithStartColon())
-	if onDuplicateColumns != nil {
-		queryBuilder.OnDuplicateKey(onDuplicateColumns)
+	conflictColumns, err := upsertConflictColumns(ctx, organizationId, tableName, conflictColumns)
+	if err != nil {
+		twrapper = NewTransactionWrapper()
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
 	}
 
-	return insert(organizationId, queryBuilder, queryArguments)
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
+	queryBuilder.Insert().Into(tableName).Columns(queryArguments.GetKeysNoStartColon()).Values(queryArguments.GetKeysWithStartColon()).
+		OnConflict(conflictColumns, updateColumns, options...)
+
+	return insert(ctx, organizationId, queryBuilder, queryArguments)
 }
 
-func InsertFromMapOnDuplicate(organizationId, tableName string, onDuplicateColumns []string, recordHashMap map[string]interface{}) (twrapper *TransactionWrapper) {
-	queryArguments := NewMap()
+func BatchUpsert(organizationId, tableName string, queryArgsList *CypressArrayList, conflictColumns, updateColumns []string, options ...*UpsertOptions) (twrapper *TransactionWrapper) {
+	return BatchUpsertContext(context.Background(), organizationId, tableName, queryArgsList, conflictColumns, updateColumns, options...)
+}
 
-	for key, _value := range recordHashMap {
-		field := fmt.Sprintf("%v", key)
-		queryArguments.PutValue(":"+field, _value)
+// BatchUpsertContext is UpsertContext for many records, in a statement per chunk of records that stays within the
+// parameter limit of the server. The chunks run in one transaction, a failing chunk rolls back all of them. Every
+// record needs the columns of the first one. The data is the list of the rows written, where the dialect can return them
+func BatchUpsertContext(ctx context.Context, organizationId, tableName string, queryArgsList *CypressArrayList, conflictColumns, updateColumns []string, options ...*UpsertOptions) (twrapper *TransactionWrapper) {
+	conflictColumns, err := upsertConflictColumns(ctx, organizationId, tableName, conflictColumns)
+	if err != nil {
+		twrapper = NewTransactionWrapper()
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper
 	}
 
-	queryBuilder := NewQueryBuilder()
-	queryBuilder.Insert().Into(tableName).Columns(queryArguments.GetKeysNoStartColon()).Values(queryArguments.GetKeysWithStartColon())
-	if onDuplicateColumns != nil {
-		queryBuilder.OnDuplicateKey(onDuplicateColumns)
+	dialect := GetOrganizationDialect(organizationId)
+	queryBuilders, queryArgumentsList, err := batchUpsertQueries(dialect, tableName, queryArgsList, conflictColumns, updateColumns, options)
+	if err != nil {
+		twrapper = NewTransactionWrapper()
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	returning := dialect.ReturningStyle() != RETURNING_NOT_SUPPORTED
+	twrapper = batchInsert(ctx, organizationId, queryBuilders, queryArgumentsList, BATCH_ALL_OR_NOTHING, returning)
+	if !returning {
+		twrapper.SetData(NewList())
 	}
+	return twrapper
+}
+
+func BulkUpdate(organizationId, tableName string, keyColumns []string, records *CypressArrayList) (twrapper *TransactionWrapper) {
+	return BulkUpdateContext(context.Background(), organizationId, tableName, keyColumns, records)
+}
+
+// BulkUpdateContext updates the row of every record, found by its keyColumns, to the values of its other columns
+// with a statement per chunk of records rather than per record. The data is the []int64 of the rows each chunk
+// affected, MySQL only counts the rows that changed. The chunks run in one transaction, a failing chunk rolls
+// back all of them
+func BulkUpdateContext(ctx context.Context, organizationId, tableName string, keyColumns []string, records *CypressArrayList) (twrapper *TransactionWrapper) {
+	twrapper = NewTransactionWrapper()
+	queryBuilders, queryArgumentsList, err := bulkUpdateQueries(GetOrganizationDialect(organizationId), tableName, keyColumns, records)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	return bulkUpdate(ctx, organizationId, queryBuilders, queryArgumentsList)
+}
+
+// batchUpsertQueries lines every record up with the columns of the first one and splits the records in chunks
+// that stay within the parameter limit of the dialect. The named variables are numbered by record, e.g. :name__0
+func batchUpsertQueries(dialect Dialect, tableName string, queryArgsList *CypressArrayList, conflictColumns, updateColumns []string,
+	options []*UpsertOptions) ([]*QueryBuilder, []*CypressHashMap, error) {
+	if queryArgsList == nil || queryArgsList.Size() == 0 {
+		err := cErrors.New("UPSERT: No records provided")
+		ThrowException(err)
+		return nil, nil, err
+	}
+
+	columns := queryArgsList.GetRecord(0).GetKeysNoStartColon()
+	if len(columns) == 0 {
+		err := cErrors.New("UPSERT: Records have no columns")
+		ThrowException(err)
+		return nil, nil, err
+	}
+
+	chunkSize := dialect.MaxParameters() / len(columns)
+	if chunkSize == 0 {
+		err := cErrors.New(fmt.Sprintf("UPSERT: %d columns exceed the %d parameters %s takes", len(columns),
+			dialect.MaxParameters(), dialect.GetDatabaseServer()))
+		ThrowException(err)
+		return nil, nil, err
+	}
+
+	var queryBuilders []*QueryBuilder
+	var queryArgumentsList []*CypressHashMap
+
+	for start := 0; start < queryArgsList.Size(); start += chunkSize {
+		queryBuilder := NewQueryBuilder(dialect)
+		queryBuilder.Insert().Into(tableName).Columns(columns)
+		queryArguments := NewMap()
+		queryArguments.SetTableName(tableName)
+
+		for index := start; index < queryArgsList.Size() && index < start+chunkSize; index++ {
+			values := recordValues(queryArgsList.GetRecord(index))
+
+			namedVariables := make([]string, len(columns))
+			for columnIndex, column := range columns {
+				value, exists := values[strings.ToLower(column)]
+				if !exists {
+					err := cErrors.New(fmt.Sprintf("UPSERT: Record %d has no %s", index, column))
+					ThrowException(err)
+					return nil, nil, err
+				}
+				namedVariables[columnIndex] = fmt.Sprintf(":%s__%d", column, index)
+				queryArguments.PutValue(namedVariables[columnIndex], value)
+				delete(values, strings.ToLower(column))
+			}
+			for column := range values {
+				err := cErrors.New(fmt.Sprintf("UPSERT: Record %d has %s which the first record does not have", index, column))
+				ThrowException(err)
+				return nil, nil, err
+			}
+			queryBuilder.AddValues(namedVariables)
+		}
 
-	return insert(organizationId, queryBuilder, queryArguments)
+		queryBuilder.OnConflict(conflictColumns, updateColumns, options...)
+		if dialect.ReturningStyle() != RETURNING_NOT_SUPPORTED {
+			queryBuilder.Returning("*")
+		}
+		if err := queryBuilder.renderError(); err != nil {
+			return nil, nil, err
+		}
+
+		queryBuilders = append(queryBuilders, queryBuilder)
+		queryArgumentsList = append(queryArgumentsList, queryArguments)
+	}
+	return queryBuilders, queryArgumentsList, nil
+}
+
+// upsertConflictColumns falls back on the primary key, MySQL finds the conflicting key itself
+func upsertConflictColumns(ctx context.Context, organizationId, tableName string, conflictColumns []string) ([]string, error) {
+	if len(conflictColumns) > 0 || GetOrganizationDialect(organizationId).GetDatabaseServer() == MySQL {
+		return conflictColumns, nil
+	}
+
+	dbConn, err := GetConnection(organizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	cypressList, err := getPrimaryKeyColumns(ctx, organizationId, dbConn, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return primaryKeyColumnNames(tableName, cypressList)
+}
+
+func primaryKeyColumnNames(tableName string, cypressList *CypressArrayList) ([]string, error) {
+	var columns []string
+	for index := 0; cypressList != nil && index < cypressList.Size(); index++ {
+		if column := cypressList.GetRecord(index).GetStringValue("column_name"); column != "" {
+			columns = append(columns, column)
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil, cErrors.New("UPSERT: " + tableName + " has no primary key, pass the conflict columns")
+	}
+	return columns, nil
 }
 
 func BatchInsert(organizationId, tableName string, queryArgsList *CypressArrayList) (twrapper *TransactionWrapper) {
-	queryBuilder := NewQueryBuilder()
-	queryBuilder.Insert().Into(tableName).Columns(queryArgsList.GetRecord(0).GetKeysNoStartColon())
-	return batchInsert(organizationId, queryBuilder, queryArgsList)
+	return BatchInsertContext(context.Background(), organizationId, tableName, queryArgsList)
+}
+
+// BatchInsertContext inserts all the records or none. The data is true once they are in, the messages tell how many
+// records every chunk inserted, BatchInsertColumnsContext hands the counts back as data
+func BatchInsertContext(ctx context.Context, organizationId, tableName string, queryArgsList *CypressArrayList) (twrapper *TransactionWrapper) {
+	twrapper = BatchInsertColumnsContext(ctx, organizationId, tableName, nil, queryArgsList, BATCH_ALL_OR_NOTHING, "")
+	twrapper.SetData(!twrapper.HasErrors)
+	return twrapper
+}
+
+func BatchInsertColumns(organizationId, tableName string, columns []string, queryArgsList *CypressArrayList, mode BatchMode, returning string) (twrapper *TransactionWrapper) {
+	return BatchInsertColumnsContext(context.Background(), organizationId, tableName, columns, queryArgsList, mode, returning)
+}
+
+// BatchInsertColumnsContext inserts the records in as many statements as the parameter limit of the server needs,
+// every record lined up with columns. returning, e.g. "id", gives back the generated keys on the servers with a
+// RETURNING or an OUTPUT clause. Every chunk reports how many records it inserted or why it failed
+func BatchInsertColumnsContext(ctx context.Context, organizationId, tableName string, columns []string, queryArgsList *CypressArrayList,
+	mode BatchMode, returning string) (twrapper *TransactionWrapper) {
+	twrapper = NewTransactionWrapper()
+	queryBuilders, queryArgumentsList, err := batchInsertQueries(GetOrganizationDialect(organizationId), tableName, columns, queryArgsList, returning)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+	return batchInsert(ctx, organizationId, queryBuilders, queryArgumentsList, mode, returning != "")
+}
+
+// BulkLoad loads records in bulk, with COPY on PostgreSQL. When columns are not given they are those of all the records
+func BulkLoad(organizationId, tableName string, columns []string, records *CypressArrayList) (twrapper *TransactionWrapper) {
+	return BulkLoadContext(context.Background(), organizationId, tableName, columns, records)
+}
+
+func BulkLoadContext(ctx context.Context, organizationId, tableName string, columns []string, records *CypressArrayList) (twrapper *TransactionWrapper) {
+	if len(columns) == 0 && records != nil {
+		columns = recordsColumns(records)
+	}
+	return bulkLoad(ctx, organizationId, tableName, columns, NewListRowSource(columns, records))
+}
+
+func BulkLoadRows(organizationId, tableName string, columns []string, source RowSource) (twrapper *TransactionWrapper) {
+	return BulkLoadRowsContext(context.Background(), organizationId, tableName, columns, source)
+}
+
+// BulkLoadRowsContext loads the rows of source, in the order of columns, all of them or none
+func BulkLoadRowsContext(ctx context.Context, organizationId, tableName string, columns []string, source RowSource) (twrapper *TransactionWrapper) {
+	return bulkLoad(ctx, organizationId, tableName, columns, source)
+}
+
+func Explain(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, analyze bool) (twrapper *TransactionWrapper) {
+	return ExplainContext(context.Background(), organizationId, queryBuilder, queryArguments, analyze)
+}
+
+// ExplainContext gives the plan of the builder's statement as a *QueryPlan. analyze executes the statement to
+// get the actual rows, and rolls it back
+func ExplainContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, analyze bool) (twrapper *TransactionWrapper) {
+	return explain(ctx, organizationId, queryBuilder, queryArguments, analyze)
 }
 
 func GetPrimaryKeyColumns(organizationId string, tableName string) (twrapper *TransactionWrapper) {
+	return GetPrimaryKeyColumnsContext(context.Background(), organizationId, tableName)
+}
+
+func GetPrimaryKeyColumnsContext(ctx context.Context, organizationId string, tableName string) (twrapper *TransactionWrapper) {
 	twrapper = NewTransactionWrapper()
 
 	defer func() {
@@ -95,9 +344,7 @@ func GetPrimaryKeyColumns(organizationId string, tableName string) (twrapper *Tr
 		return twrapper
 	}
 
-	defer dbConn.Close()
-
-	list, err := getPrimaryKeyColumns(organizationId, dbConn, tableName)
+	list, err := getPrimaryKeyColumns(ctx, organizationId, dbConn, tableName)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -111,6 +358,11 @@ func GetPrimaryKeyColumns(organizationId string, tableName string) (twrapper *Tr
 }
 
 func Update(organizationId, tableName string, updateSet *CypressHashMap, filterPredicate *FilterPredicate,
+	queryArguments *CypressHashMap, selectPreUpdate bool, pagePageSize []int) (twrapper *TransactionWrapper) {
+	return UpdateContext(context.Background(), organizationId, tableName, updateSet, filterPredicate, queryArguments, selectPreUpdate, pagePageSize)
+}
+
+func UpdateContext(ctx context.Context, organizationId, tableName string, updateSet *CypressHashMap, filterPredicate *FilterPredicate,
 	queryArguments *CypressHashMap, selectPreUpdate bool, pagePageSize []int) (twrapper *TransactionWrapper) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -132,6 +384,12 @@ func Update(organizationId, tableName string, updateSet *CypressHashMap, filterP
 		return twrapper
 	}
 
+	if err := unboundedPredicateError(STATEMENT_UPDATE, tableName, filterPredicate); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
 	updateSetVariables := NewMap()
 	for pair := updateSet.GetData().Oldest(); pair != nil; pair = pair.Next() {
 		field := fmt.Sprintf("%v", pair.Key)
@@ -139,9 +397,44 @@ func Update(organizationId, tableName string, updateSet *CypressHashMap, filterP
 		queryArguments.PutValue(":"+field, pair.Value)
 	}
 
+	dialect := GetOrganizationDialect(organizationId)
+
+	if selectPreUpdate && dialect.ReturningStyle() == RETURNING_OUTPUT_CLAUSE {
+		//THE OUTPUT CLAUSE ALREADY HOLDS BOTH SIDES OF THE UPDATE, NO SELF JOIN NEEDED
+		if pagePageSize != nil {
+			twrapper.SetHasErrors(true)
+			twrapper.AddError("UPDATE: paging the pre-update values is not supported by " + string(dialect.GetDatabaseServer()))
+			return twrapper
+		}
+
+		strOutputCols := ""
+		for index, column := range updateSetVariables.GetKeysNoStartColon() {
+			if index > 0 {
+				strOutputCols += ", "
+			}
+			strOutputCols += "INSERTED." + column + ", DELETED." + column + " AS the_old_col_" + column
+		}
+
+		queryBuilder := NewQueryBuilder(dialect)
+		queryBuilder.Update(tableName).Set(updateSetVariables).Returning(strOutputCols)
+
+		if filterPredicate != nil && filterPredicate.GetClause() != "" {
+			queryBuilder.WherePred(filterPredicate)
+		} else {
+			queryBuilder.AllowFullTable()
+		}
+
+		return update(ctx, organizationId, queryBuilder, queryArguments)
+	}
+
 	if selectPreUpdate {
+		if dialect.ReturningStyle() == RETURNING_NOT_SUPPORTED {
+			twrapper.SetHasErrors(true)
+			twrapper.AddError("UPDATE: selecting the pre-update values is not supported by " + string(dialect.GetDatabaseServer()))
+			return twrapper
+		}
 
-		primKeysWrapper := GetPrimaryKeyColumns(organizationId, tableName)
+		primKeysWrapper := GetPrimaryKeyColumnsContext(ctx, organizationId, tableName)
 		if primKeysWrapper.HasErrors {
 			twrapper.CopyFrom(primKeysWrapper)
 			return twrapper
@@ -166,7 +459,7 @@ func Update(organizationId, tableName string, updateSet *CypressHashMap, filterP
 			theONString += "nvls." + hashMap.GetStringValue("column_name") + " = ovls." + hashMap.GetStringValue("column_name") + " "
 		}
 
-		queryBuilder := NewQueryBuilder()
+		queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 
 		if pagePageSize != nil {
 			queryBuilder.Prepend("WITH the_updates AS (")
@@ -220,37 +513,55 @@ func Update(organizationId, tableName string, updateSet *CypressHashMap, filterP
 			}
 		}
 
-		return update(organizationId, queryBuilder, queryArguments)
+		return update(ctx, organizationId, queryBuilder, queryArguments)
 	}
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Update(tableName).Set(updateSetVariables)
 
 	if filterPredicate != nil && filterPredicate.GetClause() != "" {
 		queryBuilder.WherePred(filterPredicate)
+	} else {
+		queryBuilder.AllowFullTable()
 	}
 
-	return update(organizationId, queryBuilder, queryArguments)
+	return update(ctx, organizationId, queryBuilder, queryArguments)
 }
 
 func Delete(organizationId, tableName string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
+	return DeleteContext(context.Background(), organizationId, tableName, filterPredicate, queryArguments)
+}
+
+func DeleteContext(ctx context.Context, organizationId, tableName string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap) (twrapper *TransactionWrapper) {
 	twrapper = NewTransactionWrapper()
 	if queryArguments == nil {
 		queryArguments = NewMap()
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder()
+	if err := unboundedPredicateError(STATEMENT_DELETE, tableName, filterPredicate); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.DeleteFrom(tableName)
 
 	if filterPredicate != nil && filterPredicate.GetClause() != "" {
 		queryBuilder.WherePred(filterPredicate)
+	} else {
+		queryBuilder.AllowFullTable()
 	}
 
-	return deleteData(organizationId, queryBuilder, queryArguments)
+	return deleteData(ctx, organizationId, queryBuilder, queryArguments)
 }
 
 func JoinSelectQuery(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper) {
+	return JoinSelectQueryContext(context.Background(), organizationId, queryBuilder, queryArguments, pagePageSize)
+}
+
+func JoinSelectQueryContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper) {
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Println(r)
@@ -260,12 +571,11 @@ func JoinSelectQuery(organizationId string, queryBuilder *QueryBuilder, queryArg
 
 	twrapper = NewTransactionWrapper()
 	tableName := queryBuilder.GetTableName()
-	if queryArguments == nil {
-		queryArguments = NewMap()
-	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
 	queryArguments.SetTableName(tableName)
 
-	qrQueryBuilder := NewQueryBuilder().RawQuery(queryBuilder.ToString())
+	qrQueryBuilder := rawQueryBuilderFrom(queryBuilder, GetOrganizationDialect(organizationId))
+	windowCounted := false
 	if pagePageSize != nil {
 		err := validatePagePageSize(pagePageSize)
 		if err != nil {
@@ -279,14 +589,22 @@ func JoinSelectQuery(organizationId string, queryBuilder *QueryBuilder, queryArg
 			queryArguments.AddQueryArgument(":num_of_records", pagePageSize[1])
 			queryArguments.AddQueryArgument(":offset", (pagePageSize[0]-1)*pagePageSize[1])
 			qrQueryBuilder.Limit()
+			windowCounted = addWindowCount(qrQueryBuilder)
 		}
 	}
 
-	twrapper = selectData(organizationId, qrQueryBuilder.ToString(), queryArguments)
+	if err := qrQueryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	twrapper = selectData(ctx, organizationId, qrQueryBuilder.ToString(), queryArguments)
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			twrapper.SetData(paginate(tableName, twrapper.GetData(), JoinCountQuery(organizationId, queryBuilder, queryArguments),
+			twrapper.SetData(paginate(tableName, twrapper.GetData(),
+				pageCount(ctx, organizationId, queryBuilder, queryArguments, twrapper.GetData(), windowCounted),
 				pagePageSize[0],
 				pagePageSize[1],
 			))
@@ -298,33 +616,26 @@ func JoinSelectQuery(organizationId string, queryBuilder *QueryBuilder, queryArg
 }
 
 func JoinCountQuery(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) int {
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("COUNT(*) AS count")
-	countQueryBuilder.From()
-	countQueryBuilder.JoinPhrase(queryBuilder.GetJoinStatement())
-
-	if queryBuilder.GetWhereClause() != "" {
-		countQueryBuilder.WhereStr(queryBuilder.GetWhereClause())
-	}
+	return JoinCountQueryContext(context.Background(), organizationId, queryBuilder, queryArguments)
+}
 
-	twrapper := selectData(organizationId, countQueryBuilder.ToString(), queryArguments)
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	hashMap := cypressList.GetRecord(0)
-	count, _ := strconv.Atoi(hashMap.GetStringValue("count"))
-	return count
+func JoinCountQueryContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) int {
+	return CountContext(ctx, organizationId, queryBuilder, queryArguments)
 }
 
 func JoinExistsQuery(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) bool {
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("1")
-	countQueryBuilder.From()
-	countQueryBuilder.JoinPhrase(queryBuilder.GetJoinStatement())
-	countQueryBuilder.WhereStr(queryBuilder.GetWhereClause())
+	return JoinExistsQueryContext(context.Background(), organizationId, queryBuilder, queryArguments)
+}
 
-	twrapper := selectData(organizationId, countQueryBuilder.ToString(), queryArguments)
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	return !(cypressList == nil || cypressList.Size() < 1)
+func JoinExistsQueryContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) bool {
+	return ExistsContext(ctx, organizationId, queryBuilder, queryArguments)
 }
 
 func SelectWithQueryBuilder(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper) {
+	return SelectWithQueryBuilderContext(context.Background(), organizationId, queryBuilder, queryArguments, pagePageSize)
+}
+
+func SelectWithQueryBuilderContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper) {
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Println(r)
@@ -334,12 +645,11 @@ func SelectWithQueryBuilder(organizationId string, queryBuilder *QueryBuilder, q
 
 	twrapper = NewTransactionWrapper()
 	tableName := queryBuilder.GetTableName()
-	if queryArguments == nil {
-		queryArguments = NewMap()
-	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
 	queryArguments.SetTableName(tableName)
 
-	qrQueryBuilder := NewQueryBuilder().RawQuery(queryBuilder.ToString())
+	qrQueryBuilder := rawQueryBuilderFrom(queryBuilder, GetOrganizationDialect(organizationId))
+	windowCounted := false
 
 	if pagePageSize != nil {
 		err := validatePagePageSize(pagePageSize)
@@ -354,14 +664,22 @@ func SelectWithQueryBuilder(organizationId string, queryBuilder *QueryBuilder, q
 			queryArguments.AddQueryArgument(":num_of_records", pagePageSize[1])
 			queryArguments.AddQueryArgument(":offset", (pagePageSize[0]-1)*pagePageSize[1])
 			qrQueryBuilder.Limit()
+			windowCounted = addWindowCount(qrQueryBuilder)
 		}
 	}
 
-	twrapper = selectData(organizationId, qrQueryBuilder.ToString(), queryArguments)
+	if err := qrQueryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	twrapper = selectData(ctx, organizationId, qrQueryBuilder.ToString(), queryArguments)
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			twrapper.SetData(paginate(tableName, twrapper.GetData(), Count(organizationId, queryBuilder, queryArguments),
+			twrapper.SetData(paginate(tableName, twrapper.GetData(),
+				pageCount(ctx, organizationId, queryBuilder, queryArguments, twrapper.GetData(), windowCounted),
 				pagePageSize[0],
 				pagePageSize[1],
 			))
@@ -371,32 +689,99 @@ func SelectWithQueryBuilder(organizationId string, queryBuilder *QueryBuilder, q
 	return twrapper
 }
 
-func Count(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) int {
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("COUNT(*) AS count")
-	countQueryBuilder.FromTable(queryBuilder.GetTableName())
-	if queryBuilder.GetWhereClause() != "" {
-		countQueryBuilder.WhereStr(queryBuilder.GetWhereClause())
+func SelectWithCursor(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, cursorPage *CursorPage) (twrapper *TransactionWrapper) {
+	return SelectWithCursorContext(context.Background(), organizationId, queryBuilder, queryArguments, cursorPage)
+}
+
+// SelectWithCursorContext pages through the builder with a keyset instead of an OFFSET. The data is a
+// PageableWrapper whose NextCursor and PreviousCursor are passed back in the CursorPage for the pages around it
+func SelectWithCursorContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, cursorPage *CursorPage) (twrapper *TransactionWrapper) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Println(r)
+			debug.PrintStack()
+		}
+	}()
+
+	twrapper = NewTransactionWrapper()
+	tableName := queryBuilder.GetTableName()
+	queryArguments = queryBuilder.withArguments(queryArguments)
+	queryArguments.SetTableName(tableName)
+
+	pagedArguments := queryArguments.CloneMe().SetTableName(tableName)
+	qrQueryBuilder, cursor, err := cursorPage.keysetQuery(queryBuilder, GetOrganizationDialect(organizationId), pagedArguments)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
 	}
 
-	twrapper := selectData(organizationId, countQueryBuilder.ToString(), queryArguments)
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	hashMap := cypressList.GetRecord(0)
+	if err := qrQueryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
 
-	count, _ := strconv.Atoi(hashMap.GetStringValue("count"))
+	twrapper = selectData(ctx, organizationId, qrQueryBuilder.ToString(), pagedArguments)
+	if twrapper.HasErrors {
+		return twrapper
+	}
+
+	totalCount := -1
+	if !cursorPage.SkipCount {
+		totalCount = CountContext(ctx, organizationId, queryBuilder, queryArguments)
+	}
+
+	records, _ := twrapper.GetData().(*CypressArrayList)
+	twrapper.SetData(cursorPage.wrap(tableName, records, cursor, totalCount))
+	return twrapper
+}
+
+func Count(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) int {
+	return CountContext(context.Background(), organizationId, queryBuilder, queryArguments)
+}
+
+// CountContext counts the rows the whole builder selects, joins, GROUP BY, HAVING and DISTINCT included,
+// leaving out its ORDER BY and LIMIT
+func CountContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) int {
+	queryArguments = queryBuilder.withArguments(queryArguments)
+	countBuilder := countQueryBuilder(queryBuilder, GetOrganizationDialect(organizationId))
+	if err := countBuilder.renderError(); err != nil {
+		return 0
+	}
+
+	twrapper := selectData(ctx, organizationId, countBuilder.ToString(), countBuilder.withArguments(queryArguments))
+	count, err := countFrom(twrapper)
+	if err != nil {
+		ThrowException(err)
+	}
 	return count
 }
 
 func Exists(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) bool {
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("1")
-	countQueryBuilder.FromTable(queryBuilder.GetTableName())
-	countQueryBuilder.WhereStr(queryBuilder.GetWhereClause())
+	return ExistsContext(context.Background(), organizationId, queryBuilder, queryArguments)
+}
 
-	twrapper := selectData(organizationId, countQueryBuilder.ToString(), queryArguments)
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	return !(cypressList == nil || cypressList.Size() < 1)
+func ExistsContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) bool {
+	queryArguments = queryBuilder.withArguments(queryArguments)
+	existsBuilder := existsQueryBuilder(queryBuilder, GetOrganizationDialect(organizationId))
+	if err := existsBuilder.renderError(); err != nil {
+		return false
+	}
+
+	twrapper := selectData(ctx, organizationId, existsBuilder.ToString(), existsBuilder.withArguments(queryArguments))
+	exists, err := existsFrom(twrapper)
+	if err != nil {
+		ThrowException(err)
+	}
+	return exists
 }
 
 func Select(organizationId, tableName, columns string, pagePageSize []int) (twrapper *TransactionWrapper) {
+	return SelectContext(context.Background(), organizationId, tableName, columns, pagePageSize)
+}
+
+func SelectContext(ctx context.Context, organizationId, tableName, columns string, pagePageSize []int) (twrapper *TransactionWrapper) {
 	defer func() {
 		if r := recover(); r != nil {
 			//exceptions.ThrowException(r)
@@ -408,7 +793,7 @@ func Select(organizationId, tableName, columns string, pagePageSize []int) (twra
 	twrapper = NewTransactionWrapper()
 	queryArguments := NewMap()
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
@@ -433,11 +818,17 @@ func Select(organizationId, tableName, columns string, pagePageSize []int) (twra
 		}
 	}
 
-	twrapper = selectData(organizationId, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	twrapper = selectData(ctx, organizationId, queryBuilder.ToString(), queryArguments)
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			twrapper.SetData(paginate(tableName, twrapper.GetData(), Count(organizationId, queryBuilder, queryArguments),
+			twrapper.SetData(paginate(tableName, twrapper.GetData(), CountContext(ctx, organizationId, queryBuilder, queryArguments),
 				pagePageSize[0],
 				pagePageSize[1],
 			))
@@ -447,6 +838,10 @@ func Select(organizationId, tableName, columns string, pagePageSize []int) (twra
 }
 
 func SelectOrderBy(organizationId, tableName, columns, columnOrderBy string, pagePageSize []int) (twrapper *TransactionWrapper) {
+	return SelectOrderByContext(context.Background(), organizationId, tableName, columns, columnOrderBy, pagePageSize)
+}
+
+func SelectOrderByContext(ctx context.Context, organizationId, tableName, columns, columnOrderBy string, pagePageSize []int) (twrapper *TransactionWrapper) {
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Println(r)
@@ -457,7 +852,7 @@ func SelectOrderBy(organizationId, tableName, columns, columnOrderBy string, pag
 	twrapper = NewTransactionWrapper()
 	queryArguments := NewMap()
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
@@ -486,11 +881,17 @@ func SelectOrderBy(organizationId, tableName, columns, columnOrderBy string, pag
 		}
 	}
 
-	twrapper = selectData(organizationId, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	twrapper = selectData(ctx, organizationId, queryBuilder.ToString(), queryArguments)
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			twrapper.SetData(paginate(tableName, twrapper.GetData(), Count(organizationId, queryBuilder, queryArguments),
+			twrapper.SetData(paginate(tableName, twrapper.GetData(), CountContext(ctx, organizationId, queryBuilder, queryArguments),
 				pagePageSize[0],
 				pagePageSize[1],
 			))
@@ -500,6 +901,10 @@ func SelectOrderBy(organizationId, tableName, columns, columnOrderBy string, pag
 }
 
 func SelectGroupBy(organizationId, tableName, columns, groupByColumns string, havingPredicate *FilterPredicate, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper) {
+	return SelectGroupByContext(context.Background(), organizationId, tableName, columns, groupByColumns, havingPredicate, queryArguments, pagePageSize)
+}
+
+func SelectGroupByContext(ctx context.Context, organizationId, tableName, columns, groupByColumns string, havingPredicate *FilterPredicate, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper) {
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Println(r)
@@ -514,7 +919,7 @@ func SelectGroupBy(organizationId, tableName, columns, groupByColumns string, ha
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
@@ -545,12 +950,18 @@ func SelectGroupBy(organizationId, tableName, columns, groupByColumns string, ha
 		}
 	}
 
-	twrapper = selectData(organizationId, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	twrapper = selectData(ctx, organizationId, queryBuilder.ToString(), queryArguments)
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
 			twrapper.SetData(paginate(tableName, twrapper.GetData(),
-				CountGroupBy(organizationId, tableName, groupByColumns, havingPredicate, queryArguments),
+				CountGroupByContext(ctx, organizationId, tableName, groupByColumns, havingPredicate, queryArguments),
 				pagePageSize[0],
 				pagePageSize[1],
 			))
@@ -560,6 +971,10 @@ func SelectGroupBy(organizationId, tableName, columns, groupByColumns string, ha
 }
 
 func SelectGroupByOrderBy(organizationId, tableName, columns, groupByColumns string, havingPredicate *FilterPredicate, columnOrderBy string, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper) {
+	return SelectGroupByOrderByContext(context.Background(), organizationId, tableName, columns, groupByColumns, havingPredicate, columnOrderBy, queryArguments, pagePageSize)
+}
+
+func SelectGroupByOrderByContext(ctx context.Context, organizationId, tableName, columns, groupByColumns string, havingPredicate *FilterPredicate, columnOrderBy string, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper) {
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Println(r)
@@ -573,7 +988,7 @@ func SelectGroupByOrderBy(organizationId, tableName, columns, groupByColumns str
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
@@ -607,12 +1022,18 @@ func SelectGroupByOrderBy(organizationId, tableName, columns, groupByColumns str
 		}
 	}
 
-	twrapper = selectData(organizationId, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	twrapper = selectData(ctx, organizationId, queryBuilder.ToString(), queryArguments)
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
 			twrapper.SetData(paginate(tableName, twrapper.GetData(),
-				CountGroupBy(organizationId, tableName, groupByColumns, havingPredicate, queryArguments),
+				CountGroupByContext(ctx, organizationId, tableName, groupByColumns, havingPredicate, queryArguments),
 				pagePageSize[0],
 				pagePageSize[1],
 			))
@@ -622,29 +1043,18 @@ func SelectGroupByOrderBy(organizationId, tableName, columns, groupByColumns str
 }
 
 func CountGroupBy(organizationId, tableName, groupByColumns string, havingPredicate *FilterPredicate, queryArguments *CypressHashMap) int {
-	if queryArguments == nil {
-		queryArguments = NewMap()
-	}
-	queryArguments.SetTableName(tableName)
-
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("COUNT(*) AS count")
-	countQueryBuilder.FromTable(tableName)
-
-	if groupByColumns != "" {
-		countQueryBuilder.GroupBy(groupByColumns)
-	}
-	if havingPredicate != nil {
-		countQueryBuilder.HavingPred(havingPredicate)
-	}
+	return CountGroupByContext(context.Background(), organizationId, tableName, groupByColumns, havingPredicate, queryArguments)
+}
 
-	twrapper := selectData(organizationId, countQueryBuilder.ToString(), queryArguments)
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	hashMap := cypressList.GetRecord(0)
-	count, _ := strconv.Atoi(hashMap.GetStringValue("count"))
-	return count
+func CountGroupByContext(ctx context.Context, organizationId, tableName, groupByColumns string, havingPredicate *FilterPredicate, queryArguments *CypressHashMap) int {
+	return CountWhereGroupByContext(ctx, organizationId, tableName, nil, groupByColumns, havingPredicate, queryArguments)
 }
 
 func SelectWhere(organizationId, tableName, columns string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper) {
+	return SelectWhereContext(context.Background(), organizationId, tableName, columns, filterPredicate, queryArguments, pagePageSize)
+}
+
+func SelectWhereContext(ctx context.Context, organizationId, tableName, columns string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper) {
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Println(r)
@@ -658,7 +1068,7 @@ func SelectWhere(organizationId, tableName, columns string, filterPredicate *Fil
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
@@ -685,11 +1095,17 @@ func SelectWhere(organizationId, tableName, columns string, filterPredicate *Fil
 		}
 	}
 
-	twrapper = selectData(organizationId, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	twrapper = selectData(ctx, organizationId, queryBuilder.ToString(), queryArguments)
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			twrapper.SetData(paginate(tableName, twrapper.GetData(), CountWhere(organizationId, tableName, filterPredicate, queryArguments),
+			twrapper.SetData(paginate(tableName, twrapper.GetData(), CountWhereContext(ctx, organizationId, tableName, filterPredicate, queryArguments),
 				pagePageSize[0],
 				pagePageSize[1],
 			))
@@ -699,19 +1115,23 @@ func SelectWhere(organizationId, tableName, columns string, filterPredicate *Fil
 }
 
 func CountWhere(organizationId, tableName string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap) int {
+	return CountWhereContext(context.Background(), organizationId, tableName, filterPredicate, queryArguments)
+}
+
+func CountWhereContext(ctx context.Context, organizationId, tableName string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap) int {
 	if queryArguments == nil {
 		queryArguments = NewMap()
 	}
 	queryArguments.SetTableName(tableName)
 
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("COUNT(*) AS count")
+	countQueryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select().SelectColumn("COUNT(*) AS count")
 	countQueryBuilder.FromTable(tableName)
 
 	if filterPredicate != nil && filterPredicate.GetClause() != "" {
 		countQueryBuilder.WherePred(filterPredicate)
 	}
 
-	twrapper := selectData(organizationId, countQueryBuilder.ToString(), queryArguments)
+	twrapper := selectData(ctx, organizationId, countQueryBuilder.ToString(), queryArguments)
 	cypressList := twrapper.GetData().(*CypressArrayList)
 	hashMap := cypressList.GetRecord(0)
 	count, _ := strconv.Atoi(hashMap.GetStringValue("count"))
@@ -723,6 +1143,14 @@ func SelectWhereOrderBy(organizationId, tableName, columns string,
 	columnOrderBy string,
 	queryArguments *CypressHashMap,
 	pagePageSize []int) (twrapper *TransactionWrapper) {
+	return SelectWhereOrderByContext(context.Background(), organizationId, tableName, columns, filterPredicate, columnOrderBy, queryArguments, pagePageSize)
+}
+
+func SelectWhereOrderByContext(ctx context.Context, organizationId, tableName, columns string,
+	filterPredicate *FilterPredicate,
+	columnOrderBy string,
+	queryArguments *CypressHashMap,
+	pagePageSize []int) (twrapper *TransactionWrapper) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -737,7 +1165,7 @@ func SelectWhereOrderBy(organizationId, tableName, columns string,
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
@@ -768,11 +1196,17 @@ func SelectWhereOrderBy(organizationId, tableName, columns string,
 		}
 	}
 
-	twrapper = selectData(organizationId, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	twrapper = selectData(ctx, organizationId, queryBuilder.ToString(), queryArguments)
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			twrapper.SetData(paginate(tableName, twrapper.GetData(), CountWhere(organizationId, tableName, filterPredicate, queryArguments),
+			twrapper.SetData(paginate(tableName, twrapper.GetData(), CountWhereContext(ctx, organizationId, tableName, filterPredicate, queryArguments),
 				pagePageSize[0],
 				pagePageSize[1],
 			))
@@ -786,6 +1220,14 @@ func SelectWhereGroupBy(organizationId, tableName, columns string,
 	groupByColumns string, havingPredicate *FilterPredicate,
 	queryArguments *CypressHashMap,
 	pagePageSize []int) (twrapper *TransactionWrapper) {
+	return SelectWhereGroupByContext(context.Background(), organizationId, tableName, columns, wherePredicate, groupByColumns, havingPredicate, queryArguments, pagePageSize)
+}
+
+func SelectWhereGroupByContext(ctx context.Context, organizationId, tableName, columns string,
+	wherePredicate *FilterPredicate,
+	groupByColumns string, havingPredicate *FilterPredicate,
+	queryArguments *CypressHashMap,
+	pagePageSize []int) (twrapper *TransactionWrapper) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -800,7 +1242,7 @@ func SelectWhereGroupBy(organizationId, tableName, columns string,
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
@@ -835,12 +1277,18 @@ func SelectWhereGroupBy(organizationId, tableName, columns string,
 		}
 	}
 
-	twrapper = selectData(organizationId, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	twrapper = selectData(ctx, organizationId, queryBuilder.ToString(), queryArguments)
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
 			twrapper.SetData(paginate(tableName, twrapper.GetData(),
-				CountWhereGroupBy(organizationId, tableName, wherePredicate, groupByColumns, havingPredicate, queryArguments),
+				CountWhereGroupByContext(ctx, organizationId, tableName, wherePredicate, groupByColumns, havingPredicate, queryArguments),
 				pagePageSize[0], pagePageSize[1],
 			))
 		}
@@ -854,6 +1302,15 @@ func SelectWhereGroupByOrderBy(organizationId, tableName, columns string,
 	columnOrderBy string,
 	queryArguments *CypressHashMap,
 	pagePageSize []int) (twrapper *TransactionWrapper) {
+	return SelectWhereGroupByOrderByContext(context.Background(), organizationId, tableName, columns, wherePredicate, groupByColumns, havingPredicate, columnOrderBy, queryArguments, pagePageSize)
+}
+
+func SelectWhereGroupByOrderByContext(ctx context.Context, organizationId, tableName, columns string,
+	wherePredicate *FilterPredicate,
+	groupByColumns string, havingPredicate *FilterPredicate,
+	columnOrderBy string,
+	queryArguments *CypressHashMap,
+	pagePageSize []int) (twrapper *TransactionWrapper) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -868,7 +1325,7 @@ func SelectWhereGroupByOrderBy(organizationId, tableName, columns string,
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
@@ -908,12 +1365,18 @@ func SelectWhereGroupByOrderBy(organizationId, tableName, columns string,
 		}
 	}
 
-	twrapper = selectData(organizationId, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper
+	}
+
+	twrapper = selectData(ctx, organizationId, queryBuilder.ToString(), queryArguments)
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
 			twrapper.SetData(paginate(tableName, twrapper.GetData(),
-				CountWhereGroupBy(organizationId, tableName, wherePredicate, groupByColumns, havingPredicate, queryArguments),
+				CountWhereGroupByContext(ctx, organizationId, tableName, wherePredicate, groupByColumns, havingPredicate, queryArguments),
 				pagePageSize[0], pagePageSize[1],
 			))
 		}
@@ -922,30 +1385,43 @@ func SelectWhereGroupByOrderBy(organizationId, tableName, columns string,
 }
 
 func CountWhereGroupBy(organizationId, tableName string, wherePredicate *FilterPredicate, groupByColumns string, havingPredicate *FilterPredicate, queryArguments *CypressHashMap) int {
+	return CountWhereGroupByContext(context.Background(), organizationId, tableName, wherePredicate, groupByColumns, havingPredicate, queryArguments)
+}
+
+// CountWhereGroupByContext counts the groups, the rows a grouped select returns
+func CountWhereGroupByContext(ctx context.Context, organizationId, tableName string, wherePredicate *FilterPredicate, groupByColumns string, havingPredicate *FilterPredicate, queryArguments *CypressHashMap) int {
 	if queryArguments == nil {
 		queryArguments = NewMap()
 	}
 	queryArguments.SetTableName(tableName)
 
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("COUNT(*) AS count")
-	countQueryBuilder.FromTable(tableName)
+	return CountContext(ctx, organizationId, groupedQueryBuilder(organizationId, tableName, wherePredicate, groupByColumns, havingPredicate), queryArguments)
+}
+
+// groupedQueryBuilder is the grouped select the group counts count
+func groupedQueryBuilder(organizationId, tableName string, wherePredicate *FilterPredicate, groupByColumns string, havingPredicate *FilterPredicate) *QueryBuilder {
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select().SelectExpression("1 AS counted").FromTable(tableName)
 
 	if wherePredicate != nil && wherePredicate.GetClause() != "" {
-		countQueryBuilder.WherePred(wherePredicate)
+		queryBuilder.WherePred(wherePredicate)
 	}
-
 	if groupByColumns != "" {
-		countQueryBuilder.GroupBy(groupByColumns)
+		queryBuilder.GroupBy(groupByColumns)
 	}
 	if havingPredicate != nil {
-		countQueryBuilder.HavingPred(havingPredicate)
+		queryBuilder.HavingPred(havingPredicate)
 	}
+	return queryBuilder
+}
 
-	twrapper := selectData(organizationId, countQueryBuilder.ToString(), queryArguments)
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	hashMap := cypressList.GetRecord(0)
-	count, _ := strconv.Atoi(hashMap.GetStringValue("count"))
-	return count
+// pageCount is the total of a paged select, read from the page when it was counted with COUNT(*) OVER ()
+func pageCount(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, records interface{}, windowCounted bool) int {
+	if windowCounted {
+		if count, ok := takeWindowCount(records); ok {
+			return count
+		}
+	}
+	return CountContext(ctx, organizationId, queryBuilder, queryArguments)
 }
 
 func paginate(tableName string, records interface{}, totalCount, page, pageSize int) *PageableWrapper {
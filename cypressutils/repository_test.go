@@ -0,0 +1,262 @@
+package cypressutils_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codecypress/go-ancillary-utils/cypressfakedb"
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+func TestInsertReturnsTheInsertedRecord(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+	fakeDB.Expect("INSERT INTO").WithColumns("id", "name").AddRow(7, "Jane")
+
+	record := cypressutils.NewMap()
+	record.PutValue("name", "Jane")
+
+	twrapper := cypressutils.Insert(organizationId, "people", record)
+	if twrapper.HasErrors {
+		t.Fatalf("Insert failed: %s", twrapper.GetErrors())
+	}
+
+	statement := fakeDB.GetLastStatement()
+	if statement.Query != `INSERT INTO "people" ("name") VALUES ($1) RETURNING *` {
+		t.Errorf("query = %s", statement.Query)
+	}
+	if len(statement.Args) != 1 || statement.Args[0] != "Jane" {
+		t.Errorf("args = %v", statement.Args)
+	}
+
+	inserted := twrapper.GetData().(*cypressutils.CypressHashMap)
+	if inserted.GetStringValue("id") != "7" {
+		t.Errorf("inserted id = %s, want 7", inserted.GetStringValue("id"))
+	}
+}
+
+func TestUpdateSelectsThePreUpdateValues(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+	fakeDB.Expect("information_schema.table_constraints").WithColumns("column_name", "column_default", "is_identity").
+		AddRow("id", "", "NO")
+	fakeDB.Expect("UPDATE").WithColumns("name", "the_old_col_name").AddRow("Jane", "John")
+
+	updateSet := cypressutils.NewMap()
+	updateSet.PutValue("name", "Jane")
+	queryArguments := cypressutils.NewMap()
+	queryArguments.PutValue(":id", 7)
+
+	twrapper := cypressutils.Update(organizationId, "public.people", updateSet, cypressutils.NewFilterPredicate("ovls.id = :id"),
+		queryArguments, true, nil)
+	if twrapper.HasErrors {
+		t.Fatalf("Update failed: %s", twrapper.GetErrors())
+	}
+
+	query := fakeDB.GetLastStatement().Query
+	for _, fragment := range []string{"nvls.id = ovls.id", "ovls.id = $", "RETURNING nvls.name, ovls.name AS the_old_col_name"} {
+		if !strings.Contains(query, fragment) {
+			t.Errorf("%q missing from %s", fragment, query)
+		}
+	}
+
+	cypressList := twrapper.GetData().(*cypressutils.CypressArrayList)
+	if cypressList.Size() != 1 || cypressList.GetRecord(0).GetStringValue("the_old_col_name") != "John" {
+		t.Errorf("pre-update values = %v", twrapper.GetData())
+	}
+}
+
+func TestSelectWhereOrderByPagesAndCounts(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+	fakeDB.Expect("ORDER BY").WithColumns("id", "name").AddRow(3, "Ann").AddRow(4, "Bob")
+	fakeDB.Expect("COUNT(*)").WithColumns("count").AddRow(12)
+
+	queryArguments := cypressutils.NewMap()
+	queryArguments.PutValue(":status", "active")
+
+	twrapper := cypressutils.SelectWhereOrderBy(organizationId, "people", "id, name", cypressutils.NewFilterPredicate("status = :status"),
+		"name", queryArguments, []int{2, 2})
+	if twrapper.HasErrors {
+		t.Fatalf("SelectWhereOrderBy failed: %s", twrapper.GetErrors())
+	}
+
+	statements := fakeDB.GetStatements()
+	if len(statements) != 2 {
+		t.Fatalf("%d statements run, want the page and the count", len(statements))
+	}
+	if page := statements[0]; !strings.Contains(page.Query, `ORDER BY "name"`) || !strings.Contains(page.Query, "LIMIT") {
+		t.Errorf("page query = %s", page.Query)
+	} else if len(page.Args) != 3 || page.Args[1] != int64(2) || page.Args[2] != int64(2) {
+		t.Errorf("page args = %v, want the status, 2 records and an offset of 2", page.Args)
+	}
+
+	pageableWrapper := twrapper.GetData().(*cypressutils.PageableWrapper)
+	if pageableWrapper.GetTotalCount() != 12 || pageableWrapper.GetLastPage() != 6 {
+		t.Errorf("total = %d, last page = %d, want 12 and 6", pageableWrapper.GetTotalCount(), pageableWrapper.GetLastPage())
+	}
+	if cypressList := pageableWrapper.GetData().(*cypressutils.CypressArrayList); cypressList.Size() != 2 {
+		t.Errorf("%d records on the page, want 2", cypressList.Size())
+	}
+}
+
+func TestBulkUpdateRollsBackWhenAChunkFails(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+	fakeDB.Expect("UPDATE").WithError(errors.New("deadlock detected"))
+
+	records := cypressutils.NewList()
+	record := cypressutils.NewMap()
+	record.PutValue("id", 7)
+	record.PutValue("name", "Jane")
+	records.AddNewRecord(record)
+
+	twrapper := cypressutils.BulkUpdate(organizationId, "people", []string{"id"}, records)
+	if !twrapper.HasErrors {
+		t.Fatal("BulkUpdate succeeded on a failing chunk")
+	}
+	if fakeDB.GetBegins() != 1 || fakeDB.GetRollbacks() != 1 || fakeDB.GetCommits() != 0 {
+		t.Errorf("begins = %d, rollbacks = %d, commits = %d, want 1, 1 and 0", fakeDB.GetBegins(), fakeDB.GetRollbacks(), fakeDB.GetCommits())
+	}
+}
+
+func TestQueryTimeoutCancelsASlowStatement(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+	fakeDB.Expect("SELECT").WithColumns("id").AddRow(1).WithDelay(time.Second)
+
+	ctx := cypressutils.WithQueryTimeout(context.Background(), 20*time.Millisecond)
+	twrapper := cypressutils.SelectContext(ctx, organizationId, "people", "id", nil)
+	if !twrapper.HasTimedOut {
+		t.Errorf("statement not marked as timed out: %s", twrapper.GetErrors())
+	}
+}
+
+func TestSelectReadsFromAReplica(t *testing.T) {
+	organizationId := t.Name()
+	fakeDB, replicaDBs, err := cypressfakedb.RegisterOrganizationWithReplicas(organizationId, 1)
+	if err != nil {
+		t.Fatalf("registering %s: %v", organizationId, err)
+	}
+	t.Cleanup(func() {
+		cypressfakedb.UnregisterOrganization(organizationId, fakeDB)
+	})
+
+	replicaScript := replicaDBs[0].Expect("SELECT").WithColumns("id").AddRow(1)
+
+	twrapper := cypressutils.Select(organizationId, "people", "id", nil)
+	if twrapper.HasErrors {
+		t.Fatalf("Select failed: %s", twrapper.GetErrors())
+	}
+	if !replicaScript.WasUsed() || len(fakeDB.GetStatements()) != 0 {
+		t.Errorf("select ran on the primary, %d statements", len(fakeDB.GetStatements()))
+	}
+}
+
+func TestBatchUpsertBindsEveryRecordByColumnName(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+	fakeDB.Expect("INSERT INTO").WithColumns("id", "name").AddRow(7, "Jane").AddRow(8, "John")
+
+	records := cypressutils.NewList()
+	first := cypressutils.NewMap()
+	first.PutValue("id", 7)
+	first.PutValue("name", "Jane")
+	records.AddNewRecord(first)
+	second := cypressutils.NewMap()
+	second.PutValue("name", "John")
+	second.PutValue("id", 8)
+	records.AddNewRecord(second)
+
+	twrapper := cypressutils.BatchUpsert(organizationId, "people", records, []string{"id"}, []string{"name"})
+	if twrapper.HasErrors {
+		t.Fatalf("BatchUpsert failed: %s", twrapper.GetErrors())
+	}
+
+	var statement *cypressfakedb.RecordedStatement
+	for _, executed := range fakeDB.GetStatements() {
+		if strings.HasPrefix(executed.Query, "INSERT INTO") {
+			statement = executed
+		}
+	}
+	if statement == nil || !strings.Contains(statement.Query, `ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`) {
+		t.Fatalf("upsert not run: %v", fakeDB.GetStatements())
+	}
+	if len(statement.Args) != 4 || statement.Args[2] != int64(8) || statement.Args[3] != "John" {
+		t.Errorf("args = %v, want the second record bound as id, name", statement.Args)
+	}
+	if cypressList := twrapper.GetData().(*cypressutils.CypressArrayList); cypressList.Size() != 2 {
+		t.Errorf("%d records returned, want 2", cypressList.Size())
+	}
+}
+
+func TestBatchUpsertRejectsARecordMissingAColumn(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+
+	records := cypressutils.NewList()
+	first := cypressutils.NewMap()
+	first.PutValue("id", 7)
+	first.PutValue("name", "Jane")
+	records.AddNewRecord(first)
+	second := cypressutils.NewMap()
+	second.PutValue("id", 8)
+	second.PutValue("email", "john@example.com")
+	records.AddNewRecord(second)
+
+	twrapper := cypressutils.BatchUpsert(organizationId, "people", records, []string{"id"}, []string{"name"})
+	if !twrapper.HasErrors {
+		t.Fatal("BatchUpsert accepted a record without a name")
+	}
+	if len(fakeDB.GetStatements()) != 0 {
+		t.Errorf("%d statements run for rejected records", len(fakeDB.GetStatements()))
+	}
+}
+
+func TestBatchUpsertChunksWithinTheParameterLimit(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t, cypressutils.MicrosoftSQL)
+
+	records := cypressutils.NewList()
+	for index := 0; index < 1100; index++ {
+		record := cypressutils.NewMap()
+		record.PutValue("id", index)
+		record.PutValue("name", "Jane")
+		records.AddNewRecord(record)
+	}
+
+	twrapper := cypressutils.BatchUpsert(organizationId, "people", records, []string{"id"}, []string{"name"})
+	if twrapper.HasErrors {
+		t.Fatalf("BatchUpsert failed: %s", twrapper.GetErrors())
+	}
+
+	var merges int
+	for _, statement := range fakeDB.GetStatements() {
+		if strings.HasPrefix(statement.Query, "MERGE") {
+			merges++
+			if len(statement.Args) > 2100 {
+				t.Errorf("%d parameters in one statement", len(statement.Args))
+			}
+		}
+	}
+	if merges != 2 {
+		t.Errorf("%d MERGE statements, want 2", merges)
+	}
+	if fakeDB.GetBegins() != 1 || fakeDB.GetCommits() != 1 {
+		t.Errorf("begins = %d, commits = %d, want the chunks in one transaction", fakeDB.GetBegins(), fakeDB.GetCommits())
+	}
+}
+
+func TestBulkLoadFoldsTheNamesItCopiesInto(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+
+	records := cypressutils.NewList()
+	record := cypressutils.NewMap()
+	record.PutValue("Name", "Jane")
+	records.AddNewRecord(record)
+
+	twrapper := cypressutils.BulkLoad(organizationId, `public."People"`, []string{"Name"}, records)
+	if twrapper.HasErrors {
+		t.Fatalf("BulkLoad failed: %s", twrapper.GetErrors())
+	}
+
+	statement := fakeDB.GetLastStatement()
+	if statement == nil || statement.Query != `COPY "public"."People" ("name") FROM STDIN` {
+		t.Errorf("statements = %v", fakeDB.GetStatements())
+	}
+}
@@ -0,0 +1,161 @@
+package cypressutils
+
+import (
+	cErrors "github.com/pkg/errors"
+	"strings"
+)
+
+type LockMode uint
+type LockWait uint
+
+const (
+	LOCK_FOR_UPDATE LockMode = iota
+	LOCK_FOR_SHARE
+)
+
+const (
+	// LOCK_WAIT blocks until the rows are free
+	LOCK_WAIT LockWait = iota
+	LOCK_NOWAIT
+	LOCK_SKIP_LOCKED
+)
+
+// RowLock locks the rows a SELECT returns until the end of the transaction, e.g. ForUpdate().SkipLocked()
+// to claim jobs off a queue that other workers read at the same time
+type RowLock struct {
+	Mode LockMode
+	Wait LockWait
+}
+
+func ForUpdate() *RowLock {
+	return &RowLock{Mode: LOCK_FOR_UPDATE}
+}
+
+func ForShare() *RowLock {
+	return &RowLock{Mode: LOCK_FOR_SHARE}
+}
+
+// NoWait fails the statement when a row is already locked
+func (rowLock *RowLock) NoWait() *RowLock {
+	rowLock.Wait = LOCK_NOWAIT
+	return rowLock
+}
+
+// SkipLocked leaves out the rows that are already locked
+func (rowLock *RowLock) SkipLocked() *RowLock {
+	rowLock.Wait = LOCK_SKIP_LOCKED
+	return rowLock
+}
+
+/**************** BUILDER ****************/
+
+func (builder *QueryBuilder) Distinct() *QueryBuilder {
+	builder.statement().distinct = true
+	return builder
+}
+
+// DistinctOn keeps the first row of every combination of columns, in the ORDER BY, which must start with
+// them. PostgreSQL only
+func (builder *QueryBuilder) DistinctOn(columns ...string) *QueryBuilder {
+	if len(columns) == 0 {
+		err := cErrors.New("DISTINCT ON: columns cannot be empty")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+	for _, column := range columns {
+		if err := validateIdentifier(column, "DISTINCT ON: "); err != nil {
+			builder.Err = err
+			return builder
+		}
+	}
+	builder.statement().distinctOn = columns
+	return builder
+}
+
+// Lock locks the rows the SELECT returns, see RowLock. It only holds within a transaction
+func (builder *QueryBuilder) Lock(rowLock *RowLock) *QueryBuilder {
+	if rowLock == nil {
+		err := cErrors.New("LOCK: row lock is nil")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	statement := builder.statement()
+	if statement.statementType != STATEMENT_SELECT {
+		err := cErrors.New("LOCK: only the rows of a SELECT can be locked")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	copied := *rowLock
+	statement.rowLock = &copied
+	return builder
+}
+
+func (builder *QueryBuilder) ForUpdate() *QueryBuilder {
+	return builder.Lock(ForUpdate())
+}
+
+func (builder *QueryBuilder) ForShare() *QueryBuilder {
+	return builder.Lock(ForShare())
+}
+
+// NoWait applies to the lock set with ForUpdate, ForShare or Lock
+func (builder *QueryBuilder) NoWait() *QueryBuilder {
+	return builder.lockWait(LOCK_NOWAIT)
+}
+
+func (builder *QueryBuilder) SkipLocked() *QueryBuilder {
+	return builder.lockWait(LOCK_SKIP_LOCKED)
+}
+
+func (builder *QueryBuilder) lockWait(wait LockWait) *QueryBuilder {
+	statement := builder.statement()
+	if statement.rowLock == nil {
+		err := cErrors.New("LOCK: NOWAIT and SKIP LOCKED need ForUpdate or ForShare first")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+	statement.rowLock.Wait = wait
+	return builder
+}
+
+/**************** RENDERING ****************/
+
+func (statement *queryStatement) renderSelectKeyword(dialect Dialect) (string, error) {
+	switch {
+	case len(statement.distinctOn) > 0:
+		if dialect.GetDatabaseServer() != PostgreSQL {
+			return "", cErrors.New("DISTINCT ON: not supported by " + string(dialect.GetDatabaseServer()) +
+				", use ROW_NUMBER() over a window partitioned by the columns")
+		}
+		return "SELECT DISTINCT ON (" + strings.Join(quoteIdentifiers(dialect, statement.distinctOn), ", ") + ")", nil
+	case statement.distinct:
+		return "SELECT DISTINCT", nil
+	}
+	return "SELECT", nil
+}
+
+// renderRowLock gives the lock as the dialect writes it, a hint on the FROM table or a clause at the end
+func (statement *queryStatement) renderRowLock(dialect Dialect) (tableHint, clause string, err error) {
+	if statement.rowLock == nil {
+		return "", "", nil
+	}
+
+	switch {
+	case statement.distinct || len(statement.distinctOn) > 0 || statement.groupBy != "":
+		return "", "", cErrors.New("LOCK: the rows of a DISTINCT or grouped SELECT cannot be locked")
+	case statement.limit != nil && dialect.GetDatabaseServer() == Oracle:
+		return "", "", cErrors.New("LOCK: Oracle cannot lock the rows of a paged SELECT")
+	}
+
+	tableHint, clause, err = dialect.RowLock(statement.rowLock)
+	if err == nil && tableHint != "" && statement.fromTable == "" {
+		err = cErrors.New("LOCK: " + string(dialect.GetDatabaseServer()) + " locks through a hint on the FROM table, set it with FromTable")
+	}
+	return tableHint, clause, err
+}
@@ -0,0 +1,357 @@
+package cypressutils
+
+import (
+	"context"
+	"fmt"
+	cErrors "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const MASTER_ORGANIZATION_ID = "-1L"
+
+// TenantRegistry keeps connectionsDSNs in sync with the organization connection rows stored in the master
+// database. Only the organizations it registered itself are ever retired, entries added through SetupDSN are left alone
+type TenantRegistry struct {
+	mutex                sync.Mutex
+	refreshMutex         sync.Mutex
+	tableName            string
+	refreshAfter         time.Duration
+	managedOrganizations map[string]bool
+	stopChannel          chan struct{}
+	waitGroup            sync.WaitGroup
+	defaultMaxIdleConns  int
+	defaultMaxOpenConns  int
+	defaultConnLifetime  time.Duration
+	defaultConnIdleTime  time.Duration
+	defaultQueryTimeout  time.Duration
+	decryptCredentials   bool
+	lastRefreshed        time.Time
+	lastRefreshError     error
+}
+
+var (
+	tenantRegistry      *TenantRegistry
+	tenantRegistryMutex sync.Mutex
+)
+
+func NewTenantRegistry(tableName string, refreshAfter time.Duration) *TenantRegistry {
+	maxIdleConns, _ := ConfSlingRingInitialPoolSize()
+	maxOpenConns, _ := ConfSlingRingMaxPoolSize()
+	connMaxLifetime, _ := ConfSlingRingMaxLifetime()
+	connMaxIdleTime, _ := ConfSlingRingMaxIdleTime()
+	queryTimeout, _ := ConfSlingRingQueryTimeout()
+
+	return &TenantRegistry{
+		tableName:            tableName,
+		refreshAfter:         refreshAfter,
+		managedOrganizations: make(map[string]bool),
+		defaultMaxIdleConns:  maxIdleConns,
+		defaultMaxOpenConns:  maxOpenConns,
+		defaultConnLifetime:  connMaxLifetime,
+		defaultConnIdleTime:  connMaxIdleTime,
+		defaultQueryTimeout:  queryTimeout,
+		decryptCredentials:   true,
+	}
+}
+
+// StartTenantRegistry loads the tenants listed in the master table configured under /API/DB/TENANTS and
+// keeps re-reading them every REFRESH_AFTER. It is a no-op when the conf file has no tenants table. A registry
+// started before is stopped and the organizations it managed are handed over to the new one. The error is the one
+// of the first refresh, the new registry is running all the same
+func StartTenantRegistry() error {
+	tableName, err := ConfGetTenantsTable()
+	if err != nil || strings.TrimSpace(tableName) == "" {
+		logrus.Warn("TENANT REGISTRY: No tenants table configured. Only the master connection will be available")
+		return nil
+	}
+
+	refreshAfter, err := ConfGetTenantsRefreshAfter()
+	if err != nil {
+		refreshAfter = 0
+	}
+
+	tenantRegistryMutex.Lock()
+	defer tenantRegistryMutex.Unlock()
+
+	registry := NewTenantRegistry(tableName, refreshAfter)
+	if previous := tenantRegistry; previous != nil {
+		previous.Stop()
+		tenantRegistry = nil
+
+		for _, organizationId := range previous.GetManagedOrganizations() {
+			registry.managedOrganizations[organizationId] = true
+		}
+	}
+
+	//THE NEW REGISTRY IS INSTALLED EVEN WHEN ITS FIRST REFRESH FAILS, IT HAS TAKEN OVER FROM THE PREVIOUS ONE AND KEEPS RETRYING
+	err = registry.Start()
+	tenantRegistry = registry
+	return err
+}
+
+func StopTenantRegistry() {
+	tenantRegistryMutex.Lock()
+	defer tenantRegistryMutex.Unlock()
+
+	if tenantRegistry != nil {
+		tenantRegistry.Stop()
+		tenantRegistry = nil
+	}
+}
+
+func GetTenantRegistry() *TenantRegistry {
+	tenantRegistryMutex.Lock()
+	defer tenantRegistryMutex.Unlock()
+	return tenantRegistry
+}
+
+func (registry *TenantRegistry) SetDecryptCredentials(decryptCredentials bool) *TenantRegistry {
+	registry.decryptCredentials = decryptCredentials
+	return registry
+}
+
+// Start refreshes the registry and then keeps refreshing it every refreshAfter. When the first refresh fails the
+// error is logged and returned, and the following refreshes retry it
+func (registry *TenantRegistry) Start() error {
+	err := registry.Refresh()
+	if err != nil {
+		logrus.Error("TENANT REGISTRY: First refresh failed: " + err.Error())
+	}
+
+	if registry.refreshAfter <= 0 {
+		return err
+	}
+
+	registry.stopChannel = make(chan struct{})
+	registry.waitGroup.Add(1)
+
+	go func() {
+		defer registry.waitGroup.Done()
+
+		ticker := time.NewTicker(registry.refreshAfter)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-registry.stopChannel:
+				return
+			case <-ticker.C:
+				if err := registry.Refresh(); err != nil {
+					logrus.Error("TENANT REGISTRY: Refresh failed: " + err.Error())
+				}
+			}
+		}
+	}()
+
+	return err
+}
+
+func (registry *TenantRegistry) Stop() {
+	if registry.stopChannel != nil {
+		close(registry.stopChannel)
+		registry.waitGroup.Wait()
+		registry.stopChannel = nil
+	}
+}
+
+// Refresh re-reads the tenants table and adds new organizations, re-creates the pools of the ones whose
+// connection details changed and closes the pools of the ones no longer listed. An organization whose row
+// cannot be read is still listed and keeps the pool it has. Refreshes run one at a time, the registry itself
+// is only locked while the changes are worked out, not while the pools connect
+func (registry *TenantRegistry) Refresh() error {
+	registry.refreshMutex.Lock()
+	defer registry.refreshMutex.Unlock()
+
+	conDSNs, unreadableOrganizations, err := registry.fetchConDSNs()
+
+	registry.mutex.Lock()
+	registry.lastRefreshed = time.Now()
+	registry.lastRefreshError = err
+
+	if err != nil {
+		registry.mutex.Unlock()
+		ThrowException(cErrors.Cause(err))
+		return err
+	}
+
+	listedOrganizations := make(map[string]bool)
+	for _, organizationId := range unreadableOrganizations {
+		listedOrganizations[organizationId] = true
+	}
+
+	var changedConDSNs []*DBConDSN
+	for _, conDSN := range conDSNs {
+		organizationId := conDSN.OrganizationId
+		listedOrganizations[organizationId] = true
+
+		if organizationId == MASTER_ORGANIZATION_ID {
+			logrus.Warn("TENANT REGISTRY: Skipping row that uses the master organization id '" + MASTER_ORGANIZATION_ID + "'")
+			continue
+		}
+
+		existing, exists := lookupConDSN(organizationId)
+		if exists && existing.sameAs(conDSN) {
+			registry.managedOrganizations[organizationId] = true
+			continue
+		}
+		changedConDSNs = append(changedConDSNs, conDSN)
+	}
+
+	var retiredOrganizations []string
+	for organizationId := range registry.managedOrganizations {
+		if listedOrganizations[organizationId] {
+			continue
+		}
+		retiredOrganizations = append(retiredOrganizations, organizationId)
+		delete(registry.managedOrganizations, organizationId)
+	}
+	registry.mutex.Unlock()
+
+	for _, conDSN := range changedConDSNs {
+		organizationId := conDSN.OrganizationId
+		if err := SetupDSN(organizationId, conDSN); err != nil {
+			logrus.Error("TENANT REGISTRY: Failed to setup connection where Organization Id = '" + organizationId + "': " + err.Error())
+			continue
+		}
+
+		registry.mutex.Lock()
+		registry.managedOrganizations[organizationId] = true
+		registry.mutex.Unlock()
+	}
+
+	for _, organizationId := range retiredOrganizations {
+		logrus.Info("TENANT REGISTRY: Retiring connection where Organization Id = '" + organizationId + "'")
+		if err := CloseConnection(organizationId); err != nil {
+			logrus.Error("TENANT REGISTRY: Failed to retire connection where Organization Id = '" + organizationId + "': " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// fetchConDSNs also returns the organizations whose rows could not be parsed, e.g. for a bad port or credentials
+// that failed to decrypt
+func (registry *TenantRegistry) fetchConDSNs() ([]*DBConDSN, []string, error) {
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(MASTER_ORGANIZATION_ID)).Select().SelectColumn("*").FromTable(registry.tableName)
+	if queryBuilder.Err != nil {
+		return nil, nil, queryBuilder.Err
+	}
+
+	twrapper := selectData(context.Background(), MASTER_ORGANIZATION_ID, queryBuilder.ToString(), NewMap())
+	if twrapper.HasErrors {
+		return nil, nil, cErrors.New("TENANT REGISTRY: " + twrapper.GetErrors("; "))
+	}
+
+	cypressList, ok := twrapper.GetData().(*CypressArrayList)
+	if !ok {
+		return nil, nil, cErrors.New("TENANT REGISTRY: Unexpected result while reading " + registry.tableName)
+	}
+
+	conDSNs := make([]*DBConDSN, 0, cypressList.Size())
+	var unreadableOrganizations []string
+
+	length := cypressList.Size()
+	for index := 0; index < length; index++ {
+		hashMap := cypressList.GetRecord(index)
+		conDSN, err := registry.parseConDSN(hashMap)
+		if err != nil {
+			logrus.Error(err.Error())
+			if organizationId := hashMap.GetStringValue("organization_id"); organizationId != "" {
+				unreadableOrganizations = append(unreadableOrganizations, organizationId)
+			}
+			continue
+		}
+		conDSNs = append(conDSNs, conDSN)
+	}
+
+	return conDSNs, unreadableOrganizations, nil
+}
+
+func (registry *TenantRegistry) parseConDSN(hashMap *CypressHashMap) (*DBConDSN, error) {
+	organizationId := hashMap.GetStringValue("organization_id")
+	if organizationId == "" {
+		return nil, cErrors.New("TENANT REGISTRY: Row without organization_id in " + registry.tableName)
+	}
+
+	port, err := strconv.Atoi(hashMap.GetStringValueOrIfNull("port", "0"))
+	if err != nil {
+		return nil, cErrors.New(fmt.Sprintf("TENANT REGISTRY: Invalid port for Organization Id = '%s': %v", organizationId, err))
+	}
+
+	userName := hashMap.GetStringValue("username")
+	password := hashMap.GetStringValue("password")
+
+	if registry.decryptCredentials {
+		userName, err = DecryptDES(userName)
+		if err != nil {
+			return nil, cErrors.New("TENANT REGISTRY: Failed to decrypt username for Organization Id = '" + organizationId + "'")
+		}
+
+		password, err = DecryptDES(password)
+		if err != nil {
+			return nil, cErrors.New("TENANT REGISTRY: Failed to decrypt password for Organization Id = '" + organizationId + "'")
+		}
+	}
+
+	maxIdleConns, _ := strconv.Atoi(hashMap.GetStringValueOrIfNull("max_idle_connections", "0"))
+	maxOpenConns, _ := strconv.Atoi(hashMap.GetStringValueOrIfNull("max_open_connections", "0"))
+
+	if maxIdleConns <= 0 {
+		maxIdleConns = registry.defaultMaxIdleConns
+	}
+	if maxOpenConns <= 0 {
+		maxOpenConns = registry.defaultMaxOpenConns
+	}
+
+	queryTimeout := registry.defaultQueryTimeout
+	//FRACTIONS OF A SECOND ARE KEPT, 0.25 IS A TIMEOUT OF 250ms
+	if queryTimeoutSeconds, _ := strconv.ParseFloat(hashMap.GetStringValueOrIfNull("query_timeout_seconds", "0"), 64); queryTimeoutSeconds > 0 {
+		queryTimeout = time.Duration(queryTimeoutSeconds * float64(time.Second))
+	}
+
+	fullScanThreshold, _ := strconv.ParseInt(hashMap.GetStringValueOrIfNull("full_scan_threshold", "0"), 10, 64)
+
+	return &DBConDSN{
+		OrganizationId:       organizationId,
+		DatabaseServer:       DbTypes(strings.ToLower(hashMap.GetStringValue("database_server"))),
+		DatabaseName:         hashMap.GetStringValue("database_name"),
+		DatabaseHost:         hashMap.GetStringValue("database_host"),
+		UserName:             userName,
+		Password:             password,
+		ConnectionParameters: hashMap.GetStringValue("connection_parameters"),
+		Port:                 port,
+		MaxIdleConnections:   maxIdleConns,
+		MaxOpenConnections:   maxOpenConns,
+		ConnMaxLifetime:      registry.defaultConnLifetime,
+		ConnMaxIdleTime:      registry.defaultConnIdleTime,
+		QueryTimeout:         queryTimeout,
+		FullScanThreshold:    fullScanThreshold,
+	}, nil
+}
+
+func (registry *TenantRegistry) GetManagedOrganizations() []string {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	organizations := make([]string, 0, len(registry.managedOrganizations))
+	for organizationId := range registry.managedOrganizations {
+		organizations = append(organizations, organizationId)
+	}
+	return organizations
+}
+
+func (registry *TenantRegistry) GetLastRefreshed() time.Time {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	return registry.lastRefreshed
+}
+
+func (registry *TenantRegistry) GetLastRefreshError() error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	return registry.lastRefreshError
+}
@@ -0,0 +1,36 @@
+package cypressutils_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codecypress/go-ancillary-utils/cypressfakedb"
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+func TestTenantRegistryKeepsRefreshingAfterAFailedStart(t *testing.T) {
+	fakeDB, err := cypressfakedb.RegisterOrganization(cypressutils.MASTER_ORGANIZATION_ID)
+	if err != nil {
+		t.Fatalf("registering the master organization: %v", err)
+	}
+	t.Cleanup(func() {
+		cypressfakedb.UnregisterOrganization(cypressutils.MASTER_ORGANIZATION_ID, fakeDB)
+	})
+	fakeDB.Expect("tenants").WithError(errors.New("connection reset by peer")).Times(1)
+	fakeDB.Expect("tenants").WithColumns("organization_id")
+
+	registry := cypressutils.NewTenantRegistry("tenants", 10*time.Millisecond)
+	if err = registry.Start(); err == nil {
+		t.Fatal("Start did not report the failed first refresh")
+	}
+	t.Cleanup(registry.Stop)
+
+	deadline := time.Now().Add(time.Second)
+	for registry.GetLastRefreshError() != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("registry never refreshed after the failed start: %v", registry.GetLastRefreshError())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
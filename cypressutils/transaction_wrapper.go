@@ -8,6 +8,7 @@ import (
 type TransactionWrapper struct {
 	HasErrors         bool        `json:"has_errors"`
 	HasWarnings       bool        `json:"has_warnings"`
+	HasTimedOut       bool        `json:"has_timed_out"`
 	StatusCode        int         `json:"status_code"`
 	Errors            []string    `json:"errors"`
 	Messages          []string    `json:"messages"`
@@ -38,6 +39,7 @@ func NewTransactionWrapper(data ...bool) *TransactionWrapper {
 func (wrapper *TransactionWrapper) CopyFrom(otherWrapper *TransactionWrapper) {
 	wrapper.HasErrors = otherWrapper.HasErrors
 	wrapper.HasWarnings = otherWrapper.HasWarnings
+	wrapper.HasTimedOut = otherWrapper.HasTimedOut
 	for _, str := range otherWrapper.Errors {
 		wrapper.Errors = append(wrapper.Errors, str)
 	}
@@ -63,6 +65,10 @@ func (wrapper *TransactionWrapper) SetHasWarnings(hasWarnings bool) {
 	wrapper.HasWarnings = hasWarnings
 }
 
+func (wrapper *TransactionWrapper) SetHasTimedOut(hasTimedOut bool) {
+	wrapper.HasTimedOut = hasTimedOut
+}
+
 func (wrapper *TransactionWrapper) GetData() interface{} {
 	return wrapper.Data
 }
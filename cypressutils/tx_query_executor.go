@@ -3,27 +3,39 @@ package cypressutils
 //THIS CLASS BEGINS AND ENDS TRANSACTIONS BUT LEAVES IT UPON THE USER TO DO THE COMMITS
 
 import (
-	"bytes"
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	cErrors "github.com/pkg/errors"
+	"io"
+	"strconv"
 	"strings"
+	"time"
 )
 
-func txInsert(organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
+func txInsert(ctx context.Context, organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
 	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "insert", time.Now(), twrapper)
 
-	if err := queryBuilder.Err; err != nil {
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer func() {
+		err = markQueryTimeout(ctx, organizationId, twrapper, err)
+	}()
+
+	if err := queryBuilder.renderError(); err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
 		return twrapper, err
 	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
 
-	tempQuery := queryBuilder.ToString() + " RETURNING *"
+	tempQuery := queryBuilder.ToStringReturning("*")
 
 	validateQueryArguments(tempQuery, queryArguments)
 
-	namedParameter := NewNamedParameterQuery(tempQuery, queryArguments)
+	namedParameter := NewNamedParameterQuery(tempQuery, queryArguments, GetOrganizationDialect(organizationId))
 
 	twrapper.AddQueryExecuted(tempQuery)
 	/*if showSql, _ := ConfShowSQL(); showSql {
@@ -33,7 +45,20 @@ func txInsert(organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, que
 	parsedQuery := namedParameter.GetParsedQuery()
 	parsedParameters := namedParameter.GetParsedParameters()
 
-	resRows, err := tx.Query(parsedQuery, parsedParameters...)
+	if queryBuilder.GetDialect().ReturningStyle() == RETURNING_NOT_SUPPORTED {
+		result, err := tx.ExecContext(ctx, parsedQuery, parsedParameters...)
+		if err != nil {
+			twrapper.SetHasErrors(true)
+			twrapper.AddError(err.Error())
+			ThrowException(cErrors.Cause(err))
+			return twrapper, err
+		}
+
+		twrapper.SetData(insertedRecordFromArguments(queryArguments, result))
+		return twrapper, nil
+	}
+
+	resRows, err := tx.QueryContext(ctx, parsedQuery, parsedParameters...)
 
 	if err != nil {
 		twrapper.SetHasErrors(true)
@@ -42,6 +67,8 @@ func txInsert(organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, que
 		return twrapper, err
 	}
 
+	defer resRows.Close()
+
 	columns, err := resRows.Columns()
 	if err != nil {
 		twrapper.SetHasErrors(true)
@@ -98,70 +125,149 @@ func txInsert(organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, que
 	return twrapper, nil
 }
 
-func txBatchInsert(organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, queryArgsList *CypressArrayList) (twrapper *TransactionWrapper, err error) {
-	twrapper = NewTransactionWrapper(false)
-
-	if err := queryBuilder.Err; err != nil {
-		twrapper.SetHasErrors(true)
-		twrapper.AddError(err.Error())
-		return twrapper, err
-	}
+// txBatchInsert inserts the chunks one after the other in tx, within the statement timeout of the organization
+func txBatchInsert(ctx context.Context, organizationId string, tx *sql.Tx, queryBuilders []*QueryBuilder, queryArgumentsList []*CypressHashMap,
+	mode BatchMode, returning bool) (twrapper *TransactionWrapper, err error) {
+	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "batchInsert", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer func() {
+		err = markQueryTimeout(ctx, organizationId, twrapper, err)
+	}()
+
+	chunksWrapper, err := insertChunks(ctx, organizationId, tx, queryBuilders, queryArgumentsList, mode, returning)
+	twrapper.CopyFrom(chunksWrapper)
+	twrapper.SetData(chunksWrapper.GetData())
+	return twrapper, err
+}
 
-	parsedQueryParams := []interface{}{}
-	shouldAddMAXComma := false
+// insertChunks inserts the chunks one after the other in tx. BATCH_ALL_OR_NOTHING stops at the first chunk that
+// fails and returns its error for the transaction to be rolled back, BATCH_BEST_EFFORT rolls the chunk back to a
+// savepoint and goes on, the errors are in the wrapper. An error with BATCH_BEST_EFFORT means a savepoint could not
+// be set, undone or released and the transaction is to be rolled back too. The data is the rows returned when
+// returning is set, the number of rows of every chunk otherwise
+func insertChunks(ctx context.Context, organizationId string, tx *sql.Tx, queryBuilders []*QueryBuilder, queryArgumentsList []*CypressHashMap,
+	mode BatchMode, returning bool) (twrapper *TransactionWrapper, err error) {
+	twrapper = NewTransactionWrapper()
 
-	var insertValuesQMark bytes.Buffer
+	dialect := GetOrganizationDialect(organizationId)
+	insertedRecords := NewList()
+	recordsInserted := make([]int64, len(queryBuilders))
+
+	var failed bool
+	for index, queryBuilder := range queryBuilders {
+		var chunkWrapper *TransactionWrapper
+		var chunkErr error
+
+		if mode == BATCH_BEST_EFFORT {
+			_, chunkErr = tx.ExecContext(ctx, dialect.Savepoint(batchInsertSavepoint))
+			if chunkErr != nil {
+				ThrowException(cErrors.Cause(chunkErr))
+				twrapper.SetHasErrors(true)
+				twrapper.AddError(fmt.Sprintf("Chunk %d: %s", index+1, chunkErr.Error()))
+				return twrapper, chunkErr
+			}
+		}
 
-	length := queryArgsList.Size()
-	for i := 0; i < length; i++ {
-		if shouldAddMAXComma {
-			insertValuesQMark.WriteString(",")
+		if returning {
+			chunkWrapper, chunkErr = txSelectData(ctx, organizationId, tx, queryBuilder.ToString(), queryArgumentsList[index])
 		} else {
-			shouldAddMAXComma = true
+			chunkWrapper, chunkErr = txExecute(ctx, organizationId, tx, queryBuilder, queryArgumentsList[index])
 		}
+		twrapper.QueryExecutedList = append(twrapper.QueryExecutedList, chunkWrapper.QueryExecutedList...)
+		twrapper.HasTimedOut = twrapper.HasTimedOut || chunkWrapper.HasTimedOut
+
+		if chunkErr != nil {
+			failed = true
+			twrapper.AddError(fmt.Sprintf("Chunk %d: %s", index+1, chunkErr.Error()))
+			if mode == BATCH_ALL_OR_NOTHING {
+				err = chunkErr
+				break
+			}
 
-		insertValuesQMark.WriteString("(")
-		hashMap := queryArgsList.GetRecord(i)
-		shouldAddMinComma := false
-		for pair := hashMap.GetData().Oldest(); pair != nil; pair = pair.Next() {
-			//column := fmt.Sprintf("%v", pair.Key)
-			parsedQueryParams = append(parsedQueryParams, pair.Value)
-			if shouldAddMinComma {
-				insertValuesQMark.WriteString(",")
-			} else {
-				shouldAddMinComma = true
+			_, rollbackErr := tx.ExecContext(ctx, dialect.RollbackToSavepoint(batchInsertSavepoint))
+			if rollbackErr == nil {
+				rollbackErr = releaseSavepoint(ctx, tx, dialect, batchInsertSavepoint)
 			}
-			insertValuesQMark.WriteString("?")
+			if rollbackErr != nil {
+				ThrowException(cErrors.Cause(rollbackErr))
+				twrapper.AddError(fmt.Sprintf("Chunk %d: %s", index+1, rollbackErr.Error()))
+				err = rollbackErr
+				break
+			}
+			continue
 		}
 
-		insertValuesQMark.WriteString(")")
+		if returning {
+			cypressList := chunkWrapper.GetData().(*CypressArrayList)
+			for recordIndex := 0; recordIndex < cypressList.Size(); recordIndex++ {
+				insertedRecords.AddNewRecord(cypressList.GetRecord(recordIndex))
+			}
+			recordsInserted[index] = int64(cypressList.Size())
+		} else {
+			recordsInserted[index] = chunkWrapper.GetData().(int64)
+		}
+		twrapper.AddMessage(fmt.Sprintf("Chunk %d: %d record(s) inserted", index+1, recordsInserted[index]))
+
+		if mode == BATCH_BEST_EFFORT {
+			if releaseErr := releaseSavepoint(ctx, tx, dialect, batchInsertSavepoint); releaseErr != nil {
+				ThrowException(cErrors.Cause(releaseErr))
+				failed = true
+				twrapper.AddError(fmt.Sprintf("Chunk %d: %s", index+1, releaseErr.Error()))
+				err = releaseErr
+				break
+			}
+		}
 	}
 
-	queryBuilder.ValuesConcatenated(insertValuesQMark.String())
+	twrapper.SetHasErrors(failed)
+	if returning {
+		twrapper.SetData(insertedRecords)
+	} else {
+		twrapper.SetData(recordsInserted)
+	}
+	return twrapper, err
+}
 
-	tempQuery := queryBuilder.ToString()
+// releaseSavepoint lets go of a savepoint that is no longer needed, on the servers that release them
+func releaseSavepoint(ctx context.Context, tx *sql.Tx, dialect Dialect, name string) error {
+	release := dialect.ReleaseSavepoint(name)
+	if release == "" {
+		return nil
+	}
 
-	twrapper.AddQueryExecuted(tempQuery)
-	/*if showSql, _ := ConfShowSQL(); showSql {
-		fmt.Println(FormatSQL(tempQuery))
-	}*/
+	_, err := tx.ExecContext(ctx, release)
+	return err
+}
 
-	_, err = tx.Exec(tempQuery, parsedQueryParams...)
-	if err != nil {
-		twrapper.SetHasErrors(true)
-		twrapper.AddError(err.Error())
-		ThrowException(cErrors.Cause(err))
-		return twrapper, err
+// txBulkUpdate runs the chunks of a bulk update one after the other in tx and stops at the first that fails,
+// returning its error for the transaction to be rolled back. The data is the number of rows of every chunk
+func txBulkUpdate(ctx context.Context, organizationId string, tx *sql.Tx, queryBuilders []*QueryBuilder,
+	queryArgumentsList []*CypressHashMap) (twrapper *TransactionWrapper, err error) {
+	twrapper = NewTransactionWrapper()
+
+	rowsAffected := make([]int64, 0, len(queryBuilders))
+	for index, queryBuilder := range queryBuilders {
+		chunkWrapper, err := txExecute(ctx, organizationId, tx, queryBuilder, queryArgumentsList[index])
+		twrapper.CopyFrom(chunkWrapper)
+		if err != nil {
+			twrapper.SetData(rowsAffected)
+			return twrapper, err
+		}
+
+		chunkRowsAffected := chunkWrapper.GetData().(int64)
+		rowsAffected = append(rowsAffected, chunkRowsAffected)
+		twrapper.AddMessage(fmt.Sprintf("Chunk %d: %d record(s) updated", index+1, chunkRowsAffected))
 	}
 
-	twrapper.SetData(true)
-	//Stats Give the Connection Pool Details. Lol. Found them by accident
-	//dbConn.Stats().MaxLifetimeClosed
+	twrapper.SetData(rowsAffected)
 	return twrapper, nil
 }
 
-func trxGetAutoIncrementPrimaryKey(organizationId string, tx *sql.Tx, tableName string) (string, error) {
-	cypressList, err := _txGetPrimaryKeyColumns_(organizationId, tx, tableName)
+func trxGetAutoIncrementPrimaryKey(ctx context.Context, organizationId string, tx *sql.Tx, tableName string) (string, error) {
+	cypressList, err := _txGetPrimaryKeyColumns_(ctx, organizationId, tx, tableName)
 	if err != nil {
 		return "", err
 	}
@@ -169,7 +275,7 @@ func trxGetAutoIncrementPrimaryKey(organizationId string, tx *sql.Tx, tableName
 	length := cypressList.Size()
 	for index := 0; index < length; index++ {
 		hashMap := cypressList.GetRecord(index)
-		if strings.Contains(hashMap.GetStringValueOrIfNull("column_default", ""), "nextval") {
+		if GetOrganizationDialect(organizationId).IsAutoIncrementColumn(hashMap) {
 			return hashMap.GetStringValue("column_name"), nil
 		}
 	}
@@ -177,7 +283,10 @@ func trxGetAutoIncrementPrimaryKey(organizationId string, tx *sql.Tx, tableName
 	return "", nil
 }
 
-func _txGetPrimaryKeyColumns_(organizationId string, tx *sql.Tx, tableName string) (*CypressArrayList, error) {
+func _txGetPrimaryKeyColumns_(ctx context.Context, organizationId string, tx *sql.Tx, tableName string) (*CypressArrayList, error) {
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+
 	databaseName := GetConDSN(organizationId).GetDatabaseName()
 
 	arr := strings.Split(tableName, ".")
@@ -186,32 +295,20 @@ func _txGetPrimaryKeyColumns_(organizationId string, tx *sql.Tx, tableName strin
 		return nil, errors.New("missing schema in table name")
 	}
 
-	strSQL := "SELECT kcu.column_name AS column_name, c.column_default\n" +
-		"   FROM information_schema.table_constraints tco\n" +
-		"         JOIN information_schema.key_column_usage kcu\n" +
-		"              ON kcu.constraint_name = tco.constraint_name\n" +
-		"                  AND kcu.constraint_schema = tco.constraint_schema\n" +
-		"         JOIN information_schema.columns c\n" +
-		"              ON kcu.column_name = c.column_name\n" +
-		"                  AND kcu.table_schema = c.table_schema\n" +
-		"                  AND kcu.table_name = c.table_name\n" +
-		"\n" +
-		"   WHERE tco.constraint_type = 'PRIMARY KEY'\n" +
-		"       AND kcu.table_catalog = :database_name\n" +
-		"       AND kcu.table_schema = :schema_name\n" +
-		"       AND kcu.table_name = :table_name"
+	dialect := GetOrganizationDialect(organizationId)
+	strSQL := dialect.PrimaryKeyColumnsQuery()
 
 	queryArguments := NewMap()
 	queryArguments.PutValue(":database_name", databaseName)
 	queryArguments.PutValue(":schema_name", arr[0])
 	queryArguments.PutValue(":table_name", arr[1])
 
-	namedParameter := NewNamedParameterQuery(strSQL, queryArguments)
+	namedParameter := NewNamedParameterQuery(strSQL, queryArguments, dialect)
 
 	parsedQuery := namedParameter.GetParsedQuery()
 	parsedParameters := namedParameter.GetParsedParameters()
 
-	resRows, err := tx.Query(parsedQuery, parsedParameters...)
+	resRows, err := tx.QueryContext(ctx, parsedQuery, parsedParameters...)
 	if err != nil {
 		return nil, err
 	}
@@ -266,9 +363,15 @@ func _txGetPrimaryKeyColumns_(organizationId string, tx *sql.Tx, tableName strin
 	return cypressList, nil
 }
 
-func txGetPrimaryKeyColumns(organizationId string, tx *sql.Tx, tableName string) (twrapper *TransactionWrapper, err error) {
+func txGetPrimaryKeyColumns(ctx context.Context, organizationId string, tx *sql.Tx, tableName string) (twrapper *TransactionWrapper, err error) {
 	twrapper = NewTransactionWrapper()
 
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer func() {
+		err = markQueryTimeout(ctx, organizationId, twrapper, err)
+	}()
+
 	databaseName := GetConDSN(organizationId).GetDatabaseName()
 
 	arr := strings.Split(tableName, ".")
@@ -277,32 +380,20 @@ func txGetPrimaryKeyColumns(organizationId string, tx *sql.Tx, tableName string)
 		return nil, errors.New("missing schema in table name")
 	}
 
-	strSQL := "SELECT kcu.column_name AS column_name, c.column_default\n" +
-		"   FROM information_schema.table_constraints tco\n" +
-		"         JOIN information_schema.key_column_usage kcu\n" +
-		"              ON kcu.constraint_name = tco.constraint_name\n" +
-		"                  AND kcu.constraint_schema = tco.constraint_schema\n" +
-		"         JOIN information_schema.columns c\n" +
-		"              ON kcu.column_name = c.column_name\n" +
-		"                  AND kcu.table_schema = c.table_schema\n" +
-		"                  AND kcu.table_name = c.table_name\n" +
-		"\n" +
-		"   WHERE tco.constraint_type = 'PRIMARY KEY'\n" +
-		"       AND kcu.table_catalog = :database_name\n" +
-		"       AND kcu.table_schema = :schema_name\n" +
-		"       AND kcu.table_name = :table_name"
+	dialect := GetOrganizationDialect(organizationId)
+	strSQL := dialect.PrimaryKeyColumnsQuery()
 
 	queryArguments := NewMap()
 	queryArguments.PutValue(":database_name", databaseName)
 	queryArguments.PutValue(":schema_name", arr[0])
 	queryArguments.PutValue(":table_name", arr[1])
 
-	namedParameter := NewNamedParameterQuery(strSQL, queryArguments)
+	namedParameter := NewNamedParameterQuery(strSQL, queryArguments, dialect)
 
 	parsedQuery := namedParameter.GetParsedQuery()
 	parsedParameters := namedParameter.GetParsedParameters()
 
-	resRows, err := tx.Query(parsedQuery, parsedParameters...)
+	resRows, err := tx.QueryContext(ctx, parsedQuery, parsedParameters...)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -375,8 +466,15 @@ func txGetPrimaryKeyColumns(organizationId string, tx *sql.Tx, tableName string)
 	return twrapper, nil
 }
 
-func txRawQuery(organizationId string, tx *sql.Tx, query string, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
+func txRawQuery(ctx context.Context, organizationId string, tx *sql.Tx, query string, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
 	twrapper = NewTransactionWrapper(false)
+	defer recordQueryMetrics(organizationId, "rawQuery", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer func() {
+		err = markQueryTimeout(ctx, organizationId, twrapper, err)
+	}()
 
 	validateQueryArguments(query, queryArguments)
 
@@ -385,11 +483,11 @@ func txRawQuery(organizationId string, tx *sql.Tx, query string, queryArguments
 		fmt.Println(FormatSQL(query))
 	}*/
 
-	namedParameter := NewNamedParameterQuery(query, queryArguments)
+	namedParameter := NewNamedParameterQuery(query, queryArguments, GetOrganizationDialect(organizationId))
 	parsedSelectQuery := namedParameter.GetParsedQuery()
 	parsedSelectParameters := namedParameter.GetParsedParameters()
 
-	_, err = tx.Exec(parsedSelectQuery, parsedSelectParameters...)
+	_, err = tx.ExecContext(ctx, parsedSelectQuery, parsedSelectParameters...)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -402,8 +500,15 @@ func txRawQuery(organizationId string, tx *sql.Tx, query string, queryArguments
 	return twrapper, nil
 }
 
-func txSelectData(organizationId string, tx *sql.Tx, query string, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
+func txSelectData(ctx context.Context, organizationId string, tx *sql.Tx, query string, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
 	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "selectData", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer func() {
+		err = markQueryTimeout(ctx, organizationId, twrapper, err)
+	}()
 
 	validateQueryArguments(query, queryArguments)
 
@@ -412,11 +517,11 @@ func txSelectData(organizationId string, tx *sql.Tx, query string, queryArgument
 		fmt.Println(FormatSQL(query))
 	}*/
 
-	namedParameter := NewNamedParameterQuery(query, queryArguments)
+	namedParameter := NewNamedParameterQuery(query, queryArguments, GetOrganizationDialect(organizationId))
 	parsedSelectQuery := namedParameter.GetParsedQuery()
 	parsedSelectParameters := namedParameter.GetParsedParameters()
 
-	resRows, err := tx.Query(parsedSelectQuery, parsedSelectParameters...)
+	resRows, err := tx.QueryContext(ctx, parsedSelectQuery, parsedSelectParameters...)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -489,14 +594,26 @@ func txSelectData(organizationId string, tx *sql.Tx, query string, queryArgument
 	return twrapper, nil
 }
 
-func txUpdate(organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
+func txUpdate(ctx context.Context, organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
+	if maxAffectedRows(ctx) > 0 {
+		return txLimitAffectedRows(ctx, organizationId, tx, queryBuilder, queryArguments, STATEMENT_UPDATE)
+	}
+
 	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "update", time.Now(), twrapper)
 
-	if err := queryBuilder.Err; err != nil {
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer func() {
+		err = markQueryTimeout(ctx, organizationId, twrapper, err)
+	}()
+
+	if err := queryBuilder.renderError(); err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
 		return twrapper, err
 	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
 
 	_, err = validateQueryArguments(queryBuilder.ToString(), queryArguments)
 	if err != nil {
@@ -510,11 +627,11 @@ func txUpdate(organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, que
 		fmt.Println(FormatSQL(queryBuilder.ToString()))
 	}*/
 
-	namedParameter := NewNamedParameterQuery(queryBuilder.ToString(), queryArguments)
+	namedParameter := NewNamedParameterQuery(queryBuilder.ToString(), queryArguments, GetOrganizationDialect(organizationId))
 	parsedSelectQuery := namedParameter.GetParsedQuery()
 	parsedSelectParameters := namedParameter.GetParsedParameters()
 
-	resRows, err := tx.Query(parsedSelectQuery, parsedSelectParameters...)
+	resRows, err := tx.QueryContext(ctx, parsedSelectQuery, parsedSelectParameters...)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -582,14 +699,26 @@ func txUpdate(organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, que
 	return twrapper, nil
 }
 
-func txDelete(organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
+func txDelete(ctx context.Context, organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
+	if maxAffectedRows(ctx) > 0 {
+		return txLimitAffectedRows(ctx, organizationId, tx, queryBuilder, queryArguments, STATEMENT_DELETE)
+	}
+
 	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "deleteData", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer func() {
+		err = markQueryTimeout(ctx, organizationId, twrapper, err)
+	}()
 
-	if err := queryBuilder.Err; err != nil {
+	if err := queryBuilder.renderError(); err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
 		return twrapper, err
 	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
 
 	_, err = validateQueryArguments(queryBuilder.ToString(), queryArguments)
 	if err != nil {
@@ -603,11 +732,28 @@ func txDelete(organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, que
 		fmt.Println(FormatSQL(queryBuilder.ToString()))
 	}*/
 
-	namedParameter := NewNamedParameterQuery(queryBuilder.ToString()+" RETURNING *", queryArguments)
+	namedParameter := NewNamedParameterQuery(queryBuilder.ToStringReturning("*"), queryArguments, GetOrganizationDialect(organizationId))
 	parsedSelectQuery := namedParameter.GetParsedQuery()
 	parsedSelectParameters := namedParameter.GetParsedParameters()
 
-	resRows, err := tx.Query(parsedSelectQuery, parsedSelectParameters...)
+	if queryBuilder.GetDialect().ReturningStyle() == RETURNING_NOT_SUPPORTED {
+		result, err := tx.ExecContext(ctx, parsedSelectQuery, parsedSelectParameters...)
+		if err != nil {
+			twrapper.SetHasErrors(true)
+			twrapper.AddError(err.Error())
+			ThrowException(cErrors.Cause(err))
+			return twrapper, err
+		}
+
+		//THE DELETED ROWS CANNOT BE RETURNED, ONLY HOW MANY THEY WERE
+		if rowsAffected, err := result.RowsAffected(); err == nil {
+			twrapper.AddMessage(strconv.FormatInt(rowsAffected, 10) + " record(s) deleted")
+		}
+		twrapper.SetData(NewList())
+		return twrapper, nil
+	}
+
+	resRows, err := tx.QueryContext(ctx, parsedSelectQuery, parsedSelectParameters...)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -674,3 +820,113 @@ func txDelete(organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, que
 	twrapper.SetData(cypressList)
 	return twrapper, nil
 }
+
+// txExecute runs a statement that returns no rows. The data is the number of rows it affected
+func txExecute(ctx context.Context, organizationId string, tx *sql.Tx, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
+	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "execute", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer func() {
+		err = markQueryTimeout(ctx, organizationId, twrapper, err)
+	}()
+
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
+
+	_, err = validateQueryArguments(queryBuilder.ToString(), queryArguments)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper.AddQueryExecuted(queryBuilder.ToString())
+
+	namedParameter := NewNamedParameterQuery(queryBuilder.ToString(), queryArguments, GetOrganizationDialect(organizationId))
+	result, err := tx.ExecContext(ctx, namedParameter.GetParsedQuery(), namedParameter.GetParsedParameters()...)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper, err
+	}
+	twrapper.SetData(rowsAffected)
+	return twrapper, nil
+}
+
+// txCopyIn streams the rows to PostgreSQL with COPY FROM STDIN. The table and the columns are quoted like in any
+// other statement, so People and "People" name the table they name elsewhere
+func txCopyIn(ctx context.Context, organizationId string, tx *sql.Tx, tableName string, columns []string, source RowSource) (twrapper *TransactionWrapper, err error) {
+	twrapper = NewTransactionWrapper()
+	defer recordQueryMetrics(organizationId, "copyIn", time.Now(), twrapper)
+
+	ctx, cancel := withStatementTimeout(ctx, organizationId)
+	defer cancel()
+	defer func() {
+		err = markQueryTimeout(ctx, organizationId, twrapper, err)
+	}()
+
+	//THE STATEMENT IS WRITTEN AS pq.CopyIn WRITES IT, WITH THE NAMES QUOTED AND FOLDED BY THE DIALECT
+	dialect := GetOrganizationDialect(organizationId)
+	copyIn := "COPY " + dialect.QuoteIdentifier(tableName) + " (" + strings.Join(quoteIdentifiers(dialect, columns), ", ") + ") FROM STDIN"
+	twrapper.AddQueryExecuted(copyIn)
+
+	stmt, err := tx.PrepareContext(ctx, copyIn)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper, err
+	}
+	defer stmt.Close()
+
+	var rowsLoaded int64
+	for {
+		var row []interface{}
+		row, err = source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err == nil && len(row) != len(columns) {
+			err = cErrors.New(fmt.Sprintf("BULK LOAD: Row %d has %d values for %d columns", rowsLoaded, len(row), len(columns)))
+		}
+		if err == nil {
+			_, err = stmt.ExecContext(ctx, row...)
+		}
+		if err != nil {
+			twrapper.SetHasErrors(true)
+			twrapper.AddError(err.Error())
+			ThrowException(cErrors.Cause(err))
+			twrapper.SetData(rowsLoaded)
+			return twrapper, err
+		}
+		rowsLoaded++
+	}
+
+	//THE ROWS ARE ONLY WRITTEN ONCE THE STATEMENT IS EXECUTED WITHOUT ARGUMENTS
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		twrapper.SetData(int64(0))
+		return twrapper, err
+	}
+
+	twrapper.AddMessage(fmt.Sprintf("%d record(s) loaded", rowsLoaded))
+	twrapper.SetData(rowsLoaded)
+	return twrapper, nil
+}
@@ -1,6 +1,7 @@
 package cypressutils
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -10,13 +11,24 @@ import (
 )
 
 type TxRepository struct {
+	ctx            context.Context
 	dbConn         *sql.DB
 	tx             *sql.Tx
 	organizationId string
 }
 
 func NewTxRepository(organizationId string) (*TxRepository, error) {
-	txRepository := &TxRepository{organizationId: organizationId}
+	return NewTxRepositoryContext(context.Background(), organizationId, nil)
+}
+
+// NewTxRepositoryContext begins the transaction with the given options (isolation level, read only).
+// The transaction is rolled back by database/sql if ctx is done before Commit, and the Tx methods
+// without a context of their own run with ctx
+func NewTxRepositoryContext(ctx context.Context, organizationId string, txOptions *sql.TxOptions) (*TxRepository, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	txRepository := &TxRepository{ctx: ctx, organizationId: organizationId}
 
 	dbConn, err := GetConnection(organizationId)
 	if err != nil {
@@ -24,7 +36,7 @@ func NewTxRepository(organizationId string) (*TxRepository, error) {
 		return nil, err
 	}
 
-	tx, err := dbConn.Begin()
+	tx, err := dbConn.BeginTx(ctx, txOptions)
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return nil, err
@@ -35,11 +47,9 @@ func NewTxRepository(organizationId string) (*TxRepository, error) {
 	return txRepository, nil
 }
 
+// Close releases the references held by the repository. The underlying pool is shared and owned by the
+// connection manager so it is left open
 func (txRepository *TxRepository) Close() {
-	if txRepository.dbConn != nil {
-		txRepository.dbConn.Close()
-	}
-
 	txRepository.tx = nil
 	txRepository.dbConn = nil
 }
@@ -72,11 +82,19 @@ func (txRepository *TxRepository) GetTx() *sql.Tx {
 	return txRepository.tx
 }
 
+func (txRepository *TxRepository) GetContext() context.Context {
+	return txRepository.ctx
+}
+
 func (txRepository *TxRepository) GetDbConn() *sql.DB {
 	return txRepository.dbConn
 }
 
 func (txRepository *TxRepository) TxInsert(organizationId, tableName string, recordHashMap *CypressHashMap) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxInsertContext(txRepository.ctx, organizationId, tableName, recordHashMap)
+}
+
+func (txRepository *TxRepository) TxInsertContext(ctx context.Context, organizationId, tableName string, recordHashMap *CypressHashMap) (twrapper *TransactionWrapper, err error) {
 	queryArguments := NewMap()
 
 	for pair := recordHashMap.GetData().Oldest(); pair != nil; pair = pair.Next() {
@@ -84,13 +102,28 @@ func (txRepository *TxRepository) TxInsert(organizationId, tableName string, rec
 		queryArguments.PutValue(":"+field, pair.Value)
 	}
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Insert().Into(tableName).Columns(queryArguments.GetKeysNoStartColon()).Values(queryArguments.GetKeysWithStartColon())
 
-	return txInsert(organizationId, txRepository.tx, queryBuilder, queryArguments)
+	return txInsert(ctx, organizationId, txRepository.tx, queryBuilder, queryArguments)
 }
 
 func (txRepository *TxRepository) TxInsertOnDuplicate(organizationId, tableName string, recordHashMap *CypressHashMap, onDuplicateColumns []string) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxInsertOnDuplicateContext(txRepository.ctx, organizationId, tableName, recordHashMap, onDuplicateColumns)
+}
+
+func (txRepository *TxRepository) TxInsertOnDuplicateContext(ctx context.Context, organizationId, tableName string, recordHashMap *CypressHashMap, onDuplicateColumns []string) (twrapper *TransactionWrapper, err error) {
+	if onDuplicateColumns == nil {
+		return txRepository.TxInsertContext(ctx, organizationId, tableName, recordHashMap)
+	}
+	return txRepository.TxUpsertContext(ctx, organizationId, tableName, recordHashMap, nil, onDuplicateColumns)
+}
+
+func (txRepository *TxRepository) TxUpsert(organizationId, tableName string, recordHashMap *CypressHashMap, conflictColumns, updateColumns []string, options ...*UpsertOptions) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxUpsertContext(txRepository.ctx, organizationId, tableName, recordHashMap, conflictColumns, updateColumns, options...)
+}
+
+func (txRepository *TxRepository) TxUpsertContext(ctx context.Context, organizationId, tableName string, recordHashMap *CypressHashMap, conflictColumns, updateColumns []string, options ...*UpsertOptions) (twrapper *TransactionWrapper, err error) {
 	queryArguments := NewMap()
 
 	for pair := recordHashMap.GetData().Oldest(); pair != nil; pair = pair.Next() {
@@ -98,22 +131,138 @@ func (txRepository *TxRepository) TxInsertOnDuplicate(organizationId, tableName
 		queryArguments.PutValue(":"+field, pair.Value)
 	}
 
-	queryBuilder := NewQueryBuilder()
-	queryBuilder.Insert().Into(tableName).Columns(queryArguments.GetKeysNoStartColon()).Values(queryArguments.GetKeysWithStartColon())
-	if onDuplicateColumns != nil {
-		queryBuilder.OnDuplicateKey(onDuplicateColumns)
+	conflictColumns, err = txRepository.upsertConflictColumns(ctx, organizationId, tableName, conflictColumns)
+	if err != nil {
+		twrapper = NewTransactionWrapper()
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper, err
+	}
+
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
+	queryBuilder.Insert().Into(tableName).Columns(queryArguments.GetKeysNoStartColon()).Values(queryArguments.GetKeysWithStartColon()).
+		OnConflict(conflictColumns, updateColumns, options...)
+
+	return txInsert(ctx, organizationId, txRepository.tx, queryBuilder, queryArguments)
+}
+
+func (txRepository *TxRepository) TxBatchUpsert(organizationId, tableName string, queryArgsList *CypressArrayList, conflictColumns, updateColumns []string, options ...*UpsertOptions) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxBatchUpsertContext(txRepository.ctx, organizationId, tableName, queryArgsList, conflictColumns, updateColumns, options...)
+}
+
+// TxBatchUpsertContext is BatchUpsertContext within the transaction, a failing chunk leaves it to be rolled back
+func (txRepository *TxRepository) TxBatchUpsertContext(ctx context.Context, organizationId, tableName string, queryArgsList *CypressArrayList, conflictColumns, updateColumns []string, options ...*UpsertOptions) (twrapper *TransactionWrapper, err error) {
+	conflictColumns, err = txRepository.upsertConflictColumns(ctx, organizationId, tableName, conflictColumns)
+	if err != nil {
+		twrapper = NewTransactionWrapper()
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(cErrors.Cause(err))
+		return twrapper, err
+	}
+
+	dialect := GetOrganizationDialect(organizationId)
+	queryBuilders, queryArgumentsList, err := batchUpsertQueries(dialect, tableName, queryArgsList, conflictColumns, updateColumns, options)
+	if err != nil {
+		twrapper = NewTransactionWrapper()
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	returning := dialect.ReturningStyle() != RETURNING_NOT_SUPPORTED
+	twrapper, err = txBatchInsert(ctx, organizationId, txRepository.tx, queryBuilders, queryArgumentsList, BATCH_ALL_OR_NOTHING, returning)
+	if !returning {
+		twrapper.SetData(NewList())
+	}
+	return twrapper, err
+}
+
+func (txRepository *TxRepository) TxBulkUpdate(organizationId, tableName string, keyColumns []string, records *CypressArrayList) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxBulkUpdateContext(txRepository.ctx, organizationId, tableName, keyColumns, records)
+}
+
+// TxBulkUpdateContext is BulkUpdateContext within the transaction, a failing chunk leaves it to be rolled back
+func (txRepository *TxRepository) TxBulkUpdateContext(ctx context.Context, organizationId, tableName string, keyColumns []string, records *CypressArrayList) (twrapper *TransactionWrapper, err error) {
+	twrapper = NewTransactionWrapper()
+	queryBuilders, queryArgumentsList, err := bulkUpdateQueries(GetOrganizationDialect(organizationId), tableName, keyColumns, records)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
 	}
 
-	return txInsert(organizationId, txRepository.tx, queryBuilder, queryArguments)
+	return txBulkUpdate(ctx, organizationId, txRepository.tx, queryBuilders, queryArgumentsList)
+}
+
+func (txRepository *TxRepository) upsertConflictColumns(ctx context.Context, organizationId, tableName string, conflictColumns []string) ([]string, error) {
+	if len(conflictColumns) > 0 || GetOrganizationDialect(organizationId).GetDatabaseServer() == MySQL {
+		return conflictColumns, nil
+	}
+
+	cypressList, err := _txGetPrimaryKeyColumns_(ctx, organizationId, txRepository.tx, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return primaryKeyColumnNames(tableName, cypressList)
 }
 
 func (txRepository *TxRepository) TxBatchInsert(organizationId, tableName string, queryArgsList *CypressArrayList) (twrapper *TransactionWrapper, err error) {
-	queryBuilder := NewQueryBuilder()
-	queryBuilder.Insert().Into(tableName).Columns(queryArgsList.GetRecord(0).GetKeysNoStartColon())
-	return txBatchInsert(organizationId, txRepository.tx, queryBuilder, queryArgsList)
+	return txRepository.TxBatchInsertContext(txRepository.ctx, organizationId, tableName, queryArgsList)
+}
+
+// TxBatchInsertContext is BatchInsertContext within the transaction, the data is true once the records are in
+func (txRepository *TxRepository) TxBatchInsertContext(ctx context.Context, organizationId, tableName string, queryArgsList *CypressArrayList) (twrapper *TransactionWrapper, err error) {
+	twrapper, err = txRepository.TxBatchInsertColumnsContext(ctx, organizationId, tableName, nil, queryArgsList, BATCH_ALL_OR_NOTHING, "")
+	twrapper.SetData(err == nil)
+	return twrapper, err
+}
+
+func (txRepository *TxRepository) TxBatchInsertColumns(organizationId, tableName string, columns []string, queryArgsList *CypressArrayList,
+	mode BatchMode, returning string) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxBatchInsertColumnsContext(txRepository.ctx, organizationId, tableName, columns, queryArgsList, mode, returning)
+}
+
+// TxBatchInsertColumnsContext is BatchInsertColumnsContext within the transaction. With BATCH_ALL_OR_NOTHING a
+// failing chunk leaves the transaction to be rolled back, with BATCH_BEST_EFFORT only that chunk is undone
+func (txRepository *TxRepository) TxBatchInsertColumnsContext(ctx context.Context, organizationId, tableName string, columns []string,
+	queryArgsList *CypressArrayList, mode BatchMode, returning string) (twrapper *TransactionWrapper, err error) {
+	twrapper = NewTransactionWrapper()
+	queryBuilders, queryArgumentsList, err := batchInsertQueries(GetOrganizationDialect(organizationId), tableName, columns, queryArgsList, returning)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+	return txBatchInsert(ctx, organizationId, txRepository.tx, queryBuilders, queryArgumentsList, mode, returning != "")
+}
+
+func (txRepository *TxRepository) TxBulkLoad(organizationId, tableName string, columns []string, records *CypressArrayList) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxBulkLoadContext(txRepository.ctx, organizationId, tableName, columns, records)
+}
+
+func (txRepository *TxRepository) TxBulkLoadContext(ctx context.Context, organizationId, tableName string, columns []string, records *CypressArrayList) (twrapper *TransactionWrapper, err error) {
+	if len(columns) == 0 && records != nil {
+		columns = recordsColumns(records)
+	}
+	return txBulkLoad(ctx, organizationId, txRepository.tx, tableName, columns, NewListRowSource(columns, records))
+}
+
+func (txRepository *TxRepository) TxBulkLoadRows(organizationId, tableName string, columns []string, source RowSource) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxBulkLoadRowsContext(txRepository.ctx, organizationId, tableName, columns, source)
+}
+
+// TxBulkLoadRowsContext is BulkLoadRowsContext within the transaction, a failure leaves it to be rolled back
+func (txRepository *TxRepository) TxBulkLoadRowsContext(ctx context.Context, organizationId, tableName string, columns []string, source RowSource) (twrapper *TransactionWrapper, err error) {
+	return txBulkLoad(ctx, organizationId, txRepository.tx, tableName, columns, source)
 }
 
 func (txRepository *TxRepository) TxGetPrimaryKeyColumns(organizationId string, tableName string) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxGetPrimaryKeyColumnsContext(txRepository.ctx, organizationId, tableName)
+}
+
+func (txRepository *TxRepository) TxGetPrimaryKeyColumnsContext(ctx context.Context, organizationId string, tableName string) (twrapper *TransactionWrapper, err error) {
 	twrapper = NewTransactionWrapper()
 
 	defer func() {
@@ -123,7 +272,7 @@ func (txRepository *TxRepository) TxGetPrimaryKeyColumns(organizationId string,
 		}
 	}()
 
-	list, err := txGetPrimaryKeyColumns(organizationId, txRepository.tx, tableName)
+	list, err := txGetPrimaryKeyColumns(ctx, organizationId, txRepository.tx, tableName)
 	if err != nil {
 		twrapper.SetHasErrors(true)
 		twrapper.AddError(err.Error())
@@ -141,6 +290,14 @@ func (txRepository *TxRepository) TxUpdate(organizationId,
 	updateSet *CypressHashMap,
 	filterPredicate *FilterPredicate,
 	queryArguments *CypressHashMap, selectPreUpdate bool, pagePageSize []int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxUpdateContext(txRepository.ctx, organizationId, tableName, updateSet, filterPredicate, queryArguments, selectPreUpdate, pagePageSize)
+}
+
+func (txRepository *TxRepository) TxUpdateContext(ctx context.Context, organizationId,
+	tableName string,
+	updateSet *CypressHashMap,
+	filterPredicate *FilterPredicate,
+	queryArguments *CypressHashMap, selectPreUpdate bool, pagePageSize []int) (twrapper *TransactionWrapper, err error) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -162,6 +319,12 @@ func (txRepository *TxRepository) TxUpdate(organizationId,
 		return twrapper, err
 	}
 
+	if err = unboundedPredicateError(STATEMENT_UPDATE, tableName, filterPredicate); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
 	updateSetVariables := NewMap()
 	for pair := updateSet.GetData().Oldest(); pair != nil; pair = pair.Next() {
 		field := fmt.Sprintf("%v", pair.Key)
@@ -169,9 +332,46 @@ func (txRepository *TxRepository) TxUpdate(organizationId,
 		queryArguments.PutValue(":"+field, pair.Value)
 	}
 
+	dialect := GetOrganizationDialect(organizationId)
+
+	if selectPreUpdate && dialect.ReturningStyle() == RETURNING_OUTPUT_CLAUSE {
+		//THE OUTPUT CLAUSE ALREADY HOLDS BOTH SIDES OF THE UPDATE, NO SELF JOIN NEEDED
+		if pagePageSize != nil {
+			err = cErrors.New("UPDATE: paging the pre-update values is not supported by " + string(dialect.GetDatabaseServer()))
+			twrapper.SetHasErrors(true)
+			twrapper.AddError(err.Error())
+			return twrapper, err
+		}
+
+		strOutputCols := ""
+		for index, column := range updateSetVariables.GetKeysNoStartColon() {
+			if index > 0 {
+				strOutputCols += ", "
+			}
+			strOutputCols += "INSERTED." + column + ", DELETED." + column + " AS the_old_col_" + column
+		}
+
+		queryBuilder := NewQueryBuilder(dialect)
+		queryBuilder.Update(tableName).Set(updateSetVariables).Returning(strOutputCols)
+
+		if filterPredicate != nil && filterPredicate.GetClause() != "" {
+			queryBuilder.WherePred(filterPredicate)
+		} else {
+			queryBuilder.AllowFullTable()
+		}
+
+		return txUpdate(ctx, organizationId, txRepository.tx, queryBuilder, queryArguments)
+	}
+
 	if selectPreUpdate {
+		if dialect.ReturningStyle() == RETURNING_NOT_SUPPORTED {
+			err = cErrors.New("UPDATE: selecting the pre-update values is not supported by " + string(dialect.GetDatabaseServer()))
+			twrapper.SetHasErrors(true)
+			twrapper.AddError(err.Error())
+			return twrapper, err
+		}
 
-		primaryKeyColsList, err := _txGetPrimaryKeyColumns_(organizationId, txRepository.tx, tableName)
+		primaryKeyColsList, err := _txGetPrimaryKeyColumns_(ctx, organizationId, txRepository.tx, tableName)
 		if err != nil {
 			twrapper.SetHasErrors(true)
 			twrapper.AddError(err.Error())
@@ -195,7 +395,7 @@ func (txRepository *TxRepository) TxUpdate(organizationId,
 			theONString += "nvls." + hashMap.GetStringValue("column_name") + " = ovls." + hashMap.GetStringValue("column_name") + " "
 		}
 
-		queryBuilder := NewQueryBuilder()
+		queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 
 		if pagePageSize != nil {
 			queryBuilder.Prepend("WITH the_updates AS (")
@@ -249,37 +449,58 @@ func (txRepository *TxRepository) TxUpdate(organizationId,
 			}
 		}
 
-		return txUpdate(organizationId, txRepository.tx, queryBuilder, queryArguments)
+		return txUpdate(ctx, organizationId, txRepository.tx, queryBuilder, queryArguments)
 	}
 
-	queryBuilder := NewQueryBuilder()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.Update(tableName).Set(updateSetVariables)
 
 	if filterPredicate != nil && filterPredicate.GetClause() != "" {
 		queryBuilder.WherePred(filterPredicate)
+	} else {
+		queryBuilder.AllowFullTable()
 	}
 
-	return txUpdate(organizationId, txRepository.tx, queryBuilder, queryArguments)
+	return txUpdate(ctx, organizationId, txRepository.tx, queryBuilder, queryArguments)
 }
 
 func (txRepository *TxRepository) TxDelete(organizationId, tableName string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxDeleteContext(txRepository.ctx, organizationId, tableName, filterPredicate, queryArguments)
+}
+
+func (txRepository *TxRepository) TxDeleteContext(ctx context.Context, organizationId, tableName string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap) (twrapper *TransactionWrapper, err error) {
 	twrapper = NewTransactionWrapper()
 	if queryArguments == nil {
 		queryArguments = NewMap()
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder()
+	if err = unboundedPredicateError(STATEMENT_DELETE, tableName, filterPredicate); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId))
 	queryBuilder.DeleteFrom(tableName)
 
 	if filterPredicate != nil && filterPredicate.GetClause() != "" {
 		queryBuilder.WherePred(filterPredicate)
+	} else {
+		queryBuilder.AllowFullTable()
 	}
 
-	return txDelete(organizationId, txRepository.tx, queryBuilder, queryArguments)
+	return txDelete(ctx, organizationId, txRepository.tx, queryBuilder, queryArguments)
 }
 
 func (txRepository *TxRepository) TxJoinSelectQuery(organizationId string,
+	queryBuilder *QueryBuilder,
+	queryArguments *CypressHashMap,
+	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxJoinSelectQueryContext(txRepository.ctx, organizationId, queryBuilder, queryArguments, pagePageSize)
+}
+
+func (txRepository *TxRepository) TxJoinSelectQueryContext(ctx context.Context, organizationId string,
 	queryBuilder *QueryBuilder,
 	queryArguments *CypressHashMap,
 	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
@@ -292,12 +513,11 @@ func (txRepository *TxRepository) TxJoinSelectQuery(organizationId string,
 
 	twrapper = NewTransactionWrapper()
 	tableName := queryBuilder.GetTableName()
-	if queryArguments == nil {
-		queryArguments = NewMap()
-	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
 	queryArguments.SetTableName(tableName)
 
-	qrQueryBuilder := NewQueryBuilder().RawQuery(queryBuilder.ToString())
+	qrQueryBuilder := rawQueryBuilderFrom(queryBuilder, GetOrganizationDialect(organizationId))
+	windowCounted := false
 	if pagePageSize != nil {
 		err := validatePagePageSize(pagePageSize)
 		if err != nil {
@@ -311,10 +531,17 @@ func (txRepository *TxRepository) TxJoinSelectQuery(organizationId string,
 			queryArguments.AddQueryArgument(":num_of_records", pagePageSize[1])
 			queryArguments.AddQueryArgument(":offset", (pagePageSize[0]-1)*pagePageSize[1])
 			qrQueryBuilder.Limit()
+			windowCounted = addWindowCount(qrQueryBuilder)
 		}
 	}
 
-	twrapper, err = txSelectData(organizationId, txRepository.tx, qrQueryBuilder.ToString(), queryArguments)
+	if err := qrQueryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, qrQueryBuilder.ToString(), queryArguments)
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return twrapper, err
@@ -322,7 +549,7 @@ func (txRepository *TxRepository) TxJoinSelectQuery(organizationId string,
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			result, err := txRepository.TxJoinCountQuery(organizationId, queryBuilder, queryArguments)
+			result, err := txRepository.txPageCount(ctx, organizationId, queryBuilder, queryArguments, twrapper.GetData(), windowCounted)
 			if err != nil {
 				twrapper.SetHasErrors(true)
 				twrapper.AddError(err.Error())
@@ -336,42 +563,29 @@ func (txRepository *TxRepository) TxJoinSelectQuery(organizationId string,
 }
 
 func (txRepository *TxRepository) TxJoinCountQuery(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (int, error) {
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("COUNT(*) AS count")
-	countQueryBuilder.From()
-	countQueryBuilder.JoinPhrase(queryBuilder.GetJoinStatement())
-
-	if queryBuilder.GetWhereClause() != "" {
-		countQueryBuilder.WhereStr(queryBuilder.GetWhereClause())
-	}
-
-	twrapper, err := txSelectData(organizationId, txRepository.tx, countQueryBuilder.ToString(), queryArguments)
-	if err != nil {
-		ThrowException(cErrors.Cause(err))
-		return 0, err
-	}
+	return txRepository.TxJoinCountQueryContext(txRepository.ctx, organizationId, queryBuilder, queryArguments)
+}
 
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	hashMap := cypressList.GetRecord(0)
-	return hashMap.GetValue("count").(int), nil
+func (txRepository *TxRepository) TxJoinCountQueryContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (int, error) {
+	return txRepository.TxCountContext(ctx, organizationId, queryBuilder, queryArguments)
 }
 
 func (txRepository *TxRepository) TxJoinExistsQuery(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (bool, error) {
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("1")
-	countQueryBuilder.From()
-	countQueryBuilder.JoinPhrase(queryBuilder.GetJoinStatement())
-	countQueryBuilder.WhereStr(queryBuilder.GetWhereClause())
-
-	twrapper, err := txSelectData(organizationId, txRepository.tx, countQueryBuilder.ToString(), queryArguments)
-	if err != nil {
-		ThrowException(cErrors.Cause(err))
-		return false, err
-	}
+	return txRepository.TxJoinExistsQueryContext(txRepository.ctx, organizationId, queryBuilder, queryArguments)
+}
 
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	return !(cypressList == nil || cypressList.Size() < 1), nil
+func (txRepository *TxRepository) TxJoinExistsQueryContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (bool, error) {
+	return txRepository.TxExistsContext(ctx, organizationId, queryBuilder, queryArguments)
 }
 
 func (txRepository *TxRepository) TxSelectWithQueryBuilder(organizationId string,
+	queryBuilder *QueryBuilder,
+	queryArguments *CypressHashMap,
+	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectWithQueryBuilderContext(txRepository.ctx, organizationId, queryBuilder, queryArguments, pagePageSize)
+}
+
+func (txRepository *TxRepository) TxSelectWithQueryBuilderContext(ctx context.Context, organizationId string,
 	queryBuilder *QueryBuilder,
 	queryArguments *CypressHashMap,
 	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
@@ -384,12 +598,11 @@ func (txRepository *TxRepository) TxSelectWithQueryBuilder(organizationId string
 
 	twrapper = NewTransactionWrapper()
 	tableName := queryBuilder.GetTableName()
-	if queryArguments == nil {
-		queryArguments = NewMap()
-	}
+	queryArguments = queryBuilder.withArguments(queryArguments)
 	queryArguments.SetTableName(tableName)
 
-	qrQueryBuilder := NewQueryBuilder().RawQuery(queryBuilder.ToString())
+	qrQueryBuilder := rawQueryBuilderFrom(queryBuilder, GetOrganizationDialect(organizationId))
+	windowCounted := false
 
 	if pagePageSize != nil {
 		err := validatePagePageSize(pagePageSize)
@@ -404,10 +617,17 @@ func (txRepository *TxRepository) TxSelectWithQueryBuilder(organizationId string
 			queryArguments.AddQueryArgument(":num_of_records", pagePageSize[1])
 			queryArguments.AddQueryArgument(":offset", (pagePageSize[0]-1)*pagePageSize[1])
 			qrQueryBuilder.Limit()
+			windowCounted = addWindowCount(qrQueryBuilder)
 		}
 	}
 
-	twrapper, err = txSelectData(organizationId, txRepository.tx, qrQueryBuilder.ToString(), queryArguments)
+	if err := qrQueryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, qrQueryBuilder.ToString(), queryArguments)
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return twrapper, err
@@ -415,7 +635,7 @@ func (txRepository *TxRepository) TxSelectWithQueryBuilder(organizationId string
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			result, err := txRepository.TxCount(organizationId, queryBuilder, queryArguments)
+			result, err := txRepository.txPageCount(ctx, organizationId, queryBuilder, queryArguments, twrapper.GetData(), windowCounted)
 			if err != nil {
 				twrapper.SetHasErrors(true)
 				twrapper.AddError(err.Error())
@@ -428,39 +648,115 @@ func (txRepository *TxRepository) TxSelectWithQueryBuilder(organizationId string
 	return twrapper, nil
 }
 
+func (txRepository *TxRepository) TxSelectWithCursor(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, cursorPage *CursorPage) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectWithCursorContext(txRepository.ctx, organizationId, queryBuilder, queryArguments, cursorPage)
+}
+
+func (txRepository *TxRepository) TxSelectWithCursorContext(ctx context.Context, organizationId string,
+	queryBuilder *QueryBuilder,
+	queryArguments *CypressHashMap,
+	cursorPage *CursorPage) (twrapper *TransactionWrapper, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Println(r)
+			debug.PrintStack()
+		}
+	}()
+
+	twrapper = NewTransactionWrapper()
+	tableName := queryBuilder.GetTableName()
+	queryArguments = queryBuilder.withArguments(queryArguments)
+	queryArguments.SetTableName(tableName)
+
+	pagedArguments := queryArguments.CloneMe().SetTableName(tableName)
+	qrQueryBuilder, cursor, err := cursorPage.keysetQuery(queryBuilder, GetOrganizationDialect(organizationId), pagedArguments)
+	if err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	if err := qrQueryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, qrQueryBuilder.ToString(), pagedArguments)
+	if err != nil {
+		ThrowException(cErrors.Cause(err))
+		return twrapper, err
+	}
+
+	totalCount := -1
+	if !cursorPage.SkipCount {
+		totalCount, err = txRepository.TxCountContext(ctx, organizationId, queryBuilder, queryArguments)
+		if err != nil {
+			twrapper.SetHasErrors(true)
+			twrapper.AddError(err.Error())
+			return twrapper, err
+		}
+	}
+
+	records, _ := twrapper.GetData().(*CypressArrayList)
+	twrapper.SetData(cursorPage.wrap(tableName, records, cursor, totalCount))
+	return twrapper, nil
+}
+
 func (txRepository *TxRepository) TxCount(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (int, error) {
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("COUNT(*) AS count")
-	countQueryBuilder.FromTable(queryBuilder.GetTableName())
-	if queryBuilder.GetWhereClause() != "" {
-		countQueryBuilder.WhereStr(queryBuilder.GetWhereClause())
+	return txRepository.TxCountContext(txRepository.ctx, organizationId, queryBuilder, queryArguments)
+}
+
+// TxCountContext counts the rows the whole builder selects, see CountContext
+func (txRepository *TxRepository) TxCountContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (int, error) {
+	queryArguments = queryBuilder.withArguments(queryArguments)
+	countBuilder := countQueryBuilder(queryBuilder, GetOrganizationDialect(organizationId))
+	if err := countBuilder.renderError(); err != nil {
+		return 0, err
 	}
 
-	twrapper, err := txSelectData(organizationId, txRepository.tx, countQueryBuilder.ToString(), queryArguments)
+	twrapper, err := txSelectData(ctx, organizationId, txRepository.tx, countBuilder.ToString(), countBuilder.withArguments(queryArguments))
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return 0, err
 	}
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	hashMap := cypressList.GetRecord(0)
-	return hashMap.GetValue("count").(int), nil
+	return countFrom(twrapper)
 }
 
 func (txRepository *TxRepository) TxExists(organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (bool, error) {
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("1")
-	countQueryBuilder.FromTable(queryBuilder.GetTableName())
-	countQueryBuilder.WhereStr(queryBuilder.GetWhereClause())
+	return txRepository.TxExistsContext(txRepository.ctx, organizationId, queryBuilder, queryArguments)
+}
 
-	twrapper, err := txSelectData(organizationId, txRepository.tx, countQueryBuilder.ToString(), queryArguments)
+func (txRepository *TxRepository) TxExistsContext(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap) (bool, error) {
+	queryArguments = queryBuilder.withArguments(queryArguments)
+	existsBuilder := existsQueryBuilder(queryBuilder, GetOrganizationDialect(organizationId))
+	if err := existsBuilder.renderError(); err != nil {
+		return false, err
+	}
+
+	twrapper, err := txSelectData(ctx, organizationId, txRepository.tx, existsBuilder.ToString(), existsBuilder.withArguments(queryArguments))
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return false, err
 	}
+	return existsFrom(twrapper)
+}
 
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	return !(cypressList == nil || cypressList.Size() < 1), nil
+// txPageCount is the total of a paged select, read from the page when it was counted with COUNT(*) OVER ()
+func (txRepository *TxRepository) txPageCount(ctx context.Context, organizationId string, queryBuilder *QueryBuilder, queryArguments *CypressHashMap, records interface{}, windowCounted bool) (int, error) {
+	if windowCounted {
+		if count, ok := takeWindowCount(records); ok {
+			return count, nil
+		}
+	}
+	return txRepository.TxCountContext(ctx, organizationId, queryBuilder, queryArguments)
 }
 
 func (txRepository *TxRepository) TxSelect(organizationId, tableName, columns string, pagePageSize []int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectContext(txRepository.ctx, organizationId, tableName, columns, pagePageSize)
+}
+
+func (txRepository *TxRepository) TxSelectContext(ctx context.Context, organizationId, tableName, columns string, pagePageSize []int) (twrapper *TransactionWrapper, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Println(r)
@@ -471,7 +767,7 @@ func (txRepository *TxRepository) TxSelect(organizationId, tableName, columns st
 	twrapper = NewTransactionWrapper()
 	queryArguments := NewMap()
 
-	queryBuilder := NewQueryBuilder().Select()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
 	}
@@ -495,7 +791,13 @@ func (txRepository *TxRepository) TxSelect(organizationId, tableName, columns st
 		}
 	}
 
-	twrapper, err = txSelectData(organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return twrapper, err
@@ -503,7 +805,7 @@ func (txRepository *TxRepository) TxSelect(organizationId, tableName, columns st
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			result, err := txRepository.TxCount(organizationId, queryBuilder, queryArguments)
+			result, err := txRepository.TxCountContext(ctx, organizationId, queryBuilder, queryArguments)
 			if err != nil {
 				twrapper.SetHasErrors(true)
 				twrapper.AddError(err.Error())
@@ -516,6 +818,10 @@ func (txRepository *TxRepository) TxSelect(organizationId, tableName, columns st
 }
 
 func (txRepository *TxRepository) TxSelectOrderBy(organizationId, tableName, columns, columnOrderBy string, pagePageSize []int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectOrderByContext(txRepository.ctx, organizationId, tableName, columns, columnOrderBy, pagePageSize)
+}
+
+func (txRepository *TxRepository) TxSelectOrderByContext(ctx context.Context, organizationId, tableName, columns, columnOrderBy string, pagePageSize []int) (twrapper *TransactionWrapper, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Println(r)
@@ -526,7 +832,7 @@ func (txRepository *TxRepository) TxSelectOrderBy(organizationId, tableName, col
 	twrapper = NewTransactionWrapper()
 	queryArguments := NewMap()
 
-	queryBuilder := NewQueryBuilder().Select()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
 	}
@@ -554,7 +860,13 @@ func (txRepository *TxRepository) TxSelectOrderBy(organizationId, tableName, col
 		}
 	}
 
-	twrapper, err = txSelectData(organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return twrapper, err
@@ -562,7 +874,7 @@ func (txRepository *TxRepository) TxSelectOrderBy(organizationId, tableName, col
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			result, err := txRepository.TxCount(organizationId, queryBuilder, queryArguments)
+			result, err := txRepository.TxCountContext(ctx, organizationId, queryBuilder, queryArguments)
 
 			if err != nil {
 				twrapper.SetHasErrors(true)
@@ -583,6 +895,16 @@ func (txRepository *TxRepository) TxSelectGroupBy(organizationId,
 	havingPredicate *FilterPredicate,
 	queryArguments *CypressHashMap,
 	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectGroupByContext(txRepository.ctx, organizationId, tableName, columns, groupByColumns, havingPredicate, queryArguments, pagePageSize)
+}
+
+func (txRepository *TxRepository) TxSelectGroupByContext(ctx context.Context, organizationId,
+	tableName,
+	columns,
+	groupByColumns string,
+	havingPredicate *FilterPredicate,
+	queryArguments *CypressHashMap,
+	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -597,7 +919,7 @@ func (txRepository *TxRepository) TxSelectGroupBy(organizationId,
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder().Select()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
 	}
@@ -627,7 +949,13 @@ func (txRepository *TxRepository) TxSelectGroupBy(organizationId,
 		}
 	}
 
-	twrapper, err = txSelectData(organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return twrapper, err
@@ -635,7 +963,7 @@ func (txRepository *TxRepository) TxSelectGroupBy(organizationId,
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			result, err := txRepository.TxCountGroupBy(organizationId, tableName, groupByColumns, havingPredicate, queryArguments)
+			result, err := txRepository.TxCountGroupByContext(ctx, organizationId, tableName, groupByColumns, havingPredicate, queryArguments)
 			if err != nil {
 				twrapper.SetHasErrors(true)
 				twrapper.AddError(err.Error())
@@ -656,6 +984,17 @@ func (txRepository *TxRepository) TxSelectGroupByOrderBy(organizationId,
 	columnOrderBy string,
 	queryArguments *CypressHashMap,
 	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectGroupByOrderByContext(txRepository.ctx, organizationId, tableName, columns, groupByColumns, havingPredicate, columnOrderBy, queryArguments, pagePageSize)
+}
+
+func (txRepository *TxRepository) TxSelectGroupByOrderByContext(ctx context.Context, organizationId,
+	tableName,
+	columns,
+	groupByColumns string,
+	havingPredicate *FilterPredicate,
+	columnOrderBy string,
+	queryArguments *CypressHashMap,
+	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -670,7 +1009,7 @@ func (txRepository *TxRepository) TxSelectGroupByOrderBy(organizationId,
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder().Select()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
 	}
@@ -703,7 +1042,13 @@ func (txRepository *TxRepository) TxSelectGroupByOrderBy(organizationId,
 		}
 	}
 
-	twrapper, err = txSelectData(organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return twrapper, err
@@ -711,7 +1056,7 @@ func (txRepository *TxRepository) TxSelectGroupByOrderBy(organizationId,
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			result, err := txRepository.TxCountGroupBy(organizationId, tableName, groupByColumns, havingPredicate, queryArguments)
+			result, err := txRepository.TxCountGroupByContext(ctx, organizationId, tableName, groupByColumns, havingPredicate, queryArguments)
 			if err != nil {
 				twrapper.SetHasErrors(true)
 				twrapper.AddError(err.Error())
@@ -725,33 +1070,18 @@ func (txRepository *TxRepository) TxSelectGroupByOrderBy(organizationId,
 }
 
 func (txRepository *TxRepository) TxCountGroupBy(organizationId, tableName, groupByColumns string, havingPredicate *FilterPredicate, queryArguments *CypressHashMap) (int, error) {
-	if queryArguments == nil {
-		queryArguments = NewMap()
-	}
-	queryArguments.SetTableName(tableName)
-
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("COUNT(*) AS count")
-	countQueryBuilder.FromTable(tableName)
-
-	if groupByColumns != "" {
-		countQueryBuilder.GroupBy(groupByColumns)
-	}
-	if havingPredicate != nil {
-		countQueryBuilder.HavingPred(havingPredicate)
-	}
-
-	twrapper, err := txSelectData(organizationId, txRepository.tx, countQueryBuilder.ToString(), queryArguments)
-	if err != nil {
-		ThrowException(cErrors.Cause(err))
-		return 0, err
-	}
+	return txRepository.TxCountGroupByContext(txRepository.ctx, organizationId, tableName, groupByColumns, havingPredicate, queryArguments)
+}
 
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	hashMap := cypressList.GetRecord(0)
-	return hashMap.GetValue("count").(int), nil
+func (txRepository *TxRepository) TxCountGroupByContext(ctx context.Context, organizationId, tableName, groupByColumns string, havingPredicate *FilterPredicate, queryArguments *CypressHashMap) (int, error) {
+	return txRepository.TxCountWhereGroupByContext(ctx, organizationId, tableName, nil, groupByColumns, havingPredicate, queryArguments)
 }
 
 func (txRepository *TxRepository) TxSelectWhere(organizationId, tableName, columns string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectWhereContext(txRepository.ctx, organizationId, tableName, columns, filterPredicate, queryArguments, pagePageSize)
+}
+
+func (txRepository *TxRepository) TxSelectWhereContext(ctx context.Context, organizationId, tableName, columns string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap, pagePageSize []int) (twrapper *TransactionWrapper, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Println(r)
@@ -765,7 +1095,7 @@ func (txRepository *TxRepository) TxSelectWhere(organizationId, tableName, colum
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder().Select()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
 	}
@@ -791,7 +1121,13 @@ func (txRepository *TxRepository) TxSelectWhere(organizationId, tableName, colum
 		}
 	}
 
-	twrapper, err = txSelectData(organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return twrapper, err
@@ -799,7 +1135,7 @@ func (txRepository *TxRepository) TxSelectWhere(organizationId, tableName, colum
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			result, err := txRepository.TxCountWhere(organizationId, tableName, filterPredicate, queryArguments)
+			result, err := txRepository.TxCountWhereContext(ctx, organizationId, tableName, filterPredicate, queryArguments)
 			if err != nil {
 				twrapper.SetHasErrors(true)
 				twrapper.AddError(err.Error())
@@ -812,19 +1148,23 @@ func (txRepository *TxRepository) TxSelectWhere(organizationId, tableName, colum
 }
 
 func (txRepository *TxRepository) TxCountWhere(organizationId, tableName string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap) (int, error) {
+	return txRepository.TxCountWhereContext(txRepository.ctx, organizationId, tableName, filterPredicate, queryArguments)
+}
+
+func (txRepository *TxRepository) TxCountWhereContext(ctx context.Context, organizationId, tableName string, filterPredicate *FilterPredicate, queryArguments *CypressHashMap) (int, error) {
 	if queryArguments == nil {
 		queryArguments = NewMap()
 	}
 	queryArguments.SetTableName(tableName)
 
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("COUNT(*) AS count")
+	countQueryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select().SelectColumn("COUNT(*) AS count")
 	countQueryBuilder.FromTable(tableName)
 
 	if filterPredicate != nil && filterPredicate.GetClause() != "" {
 		countQueryBuilder.WherePred(filterPredicate)
 	}
 
-	twrapper, err := txSelectData(organizationId, txRepository.tx, countQueryBuilder.ToString(), queryArguments)
+	twrapper, err := txSelectData(ctx, organizationId, txRepository.tx, countQueryBuilder.ToString(), queryArguments)
 
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
@@ -841,6 +1181,14 @@ func (txRepository *TxRepository) TxSelectWhereOrderBy(organizationId, tableName
 	columnOrderBy string,
 	queryArguments *CypressHashMap,
 	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectWhereOrderByContext(txRepository.ctx, organizationId, tableName, columns, filterPredicate, columnOrderBy, queryArguments, pagePageSize)
+}
+
+func (txRepository *TxRepository) TxSelectWhereOrderByContext(ctx context.Context, organizationId, tableName, columns string,
+	filterPredicate *FilterPredicate,
+	columnOrderBy string,
+	queryArguments *CypressHashMap,
+	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -855,7 +1203,7 @@ func (txRepository *TxRepository) TxSelectWhereOrderBy(organizationId, tableName
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder().Select()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
 	}
@@ -885,7 +1233,13 @@ func (txRepository *TxRepository) TxSelectWhereOrderBy(organizationId, tableName
 		}
 	}
 
-	twrapper, err = txSelectData(organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return twrapper, err
@@ -893,7 +1247,7 @@ func (txRepository *TxRepository) TxSelectWhereOrderBy(organizationId, tableName
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			result, err := txRepository.TxCountWhere(organizationId, tableName, filterPredicate, queryArguments)
+			result, err := txRepository.TxCountWhereContext(ctx, organizationId, tableName, filterPredicate, queryArguments)
 			if err != nil {
 				twrapper.SetHasErrors(true)
 				twrapper.AddError(err.Error())
@@ -906,11 +1260,109 @@ func (txRepository *TxRepository) TxSelectWhereOrderBy(organizationId, tableName
 	return twrapper, nil
 }
 
+func (txRepository *TxRepository) TxSelectWhereLocked(organizationId, tableName, columns string,
+	filterPredicate *FilterPredicate,
+	queryArguments *CypressHashMap,
+	rowLock *RowLock,
+	numOfRecords int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectWhereOrderByLockedContext(txRepository.ctx, organizationId, tableName, columns, filterPredicate, "", queryArguments, rowLock, numOfRecords)
+}
+
+func (txRepository *TxRepository) TxSelectWhereLockedContext(ctx context.Context, organizationId, tableName, columns string,
+	filterPredicate *FilterPredicate,
+	queryArguments *CypressHashMap,
+	rowLock *RowLock,
+	numOfRecords int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectWhereOrderByLockedContext(ctx, organizationId, tableName, columns, filterPredicate, "", queryArguments, rowLock, numOfRecords)
+}
+
+func (txRepository *TxRepository) TxSelectWhereOrderByLocked(organizationId, tableName, columns string,
+	filterPredicate *FilterPredicate,
+	columnOrderBy string,
+	queryArguments *CypressHashMap,
+	rowLock *RowLock,
+	numOfRecords int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectWhereOrderByLockedContext(txRepository.ctx, organizationId, tableName, columns, filterPredicate, columnOrderBy, queryArguments, rowLock, numOfRecords)
+}
+
+// TxSelectWhereOrderByLockedContext selects, and locks until the transaction ends, the first numOfRecords rows in
+// the order of columnOrderBy, or every row when numOfRecords is 0. With ForUpdate().SkipLocked() concurrent
+// transactions each claim rows of their own, e.g. the next jobs of a queue. Oracle cannot lock a limited select
+func (txRepository *TxRepository) TxSelectWhereOrderByLockedContext(ctx context.Context, organizationId, tableName, columns string,
+	filterPredicate *FilterPredicate,
+	columnOrderBy string,
+	queryArguments *CypressHashMap,
+	rowLock *RowLock,
+	numOfRecords int) (twrapper *TransactionWrapper, err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Println(r)
+			debug.PrintStack()
+		}
+	}()
+
+	twrapper = NewTransactionWrapper()
+	if queryArguments == nil {
+		queryArguments = NewMap()
+	}
+	queryArguments.SetTableName(tableName)
+
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select()
+	if columns != "" {
+		queryBuilder.SelectColumn(columns)
+	}
+
+	queryBuilder.FromTable(tableName)
+	if filterPredicate != nil && filterPredicate.GetClause() != "" {
+		queryBuilder.WherePred(filterPredicate)
+	}
+
+	if columnOrderBy != "" {
+		queryBuilder.OrderBy(columnOrderBy)
+	}
+
+	if numOfRecords < 0 {
+		err := cErrors.New("LOCK: number of records cannot be negative")
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		ThrowException(err)
+		return twrapper, err
+	}
+	if numOfRecords > 0 {
+		queryArguments.AddQueryArgument(":num_of_records", numOfRecords)
+		queryArguments.AddQueryArgument(":offset", 0)
+		queryBuilder.Limit()
+	}
+	queryBuilder.Lock(rowLock)
+
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, queryBuilder.ToString(), queryBuilder.withArguments(queryArguments))
+	if err != nil {
+		ThrowException(cErrors.Cause(err))
+		return twrapper, err
+	}
+	return twrapper, nil
+}
+
 func (txRepository *TxRepository) TxSelectWhereGroupBy(organizationId, tableName, columns string,
 	wherePredicate *FilterPredicate,
 	groupByColumns string, havingPredicate *FilterPredicate,
 	queryArguments *CypressHashMap,
 	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectWhereGroupByContext(txRepository.ctx, organizationId, tableName, columns, wherePredicate, groupByColumns, havingPredicate, queryArguments, pagePageSize)
+}
+
+func (txRepository *TxRepository) TxSelectWhereGroupByContext(ctx context.Context, organizationId, tableName, columns string,
+	wherePredicate *FilterPredicate,
+	groupByColumns string, havingPredicate *FilterPredicate,
+	queryArguments *CypressHashMap,
+	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -925,7 +1377,7 @@ func (txRepository *TxRepository) TxSelectWhereGroupBy(organizationId, tableName
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder().Select()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
 	}
@@ -962,7 +1414,13 @@ func (txRepository *TxRepository) TxSelectWhereGroupBy(organizationId, tableName
 		}
 	}
 
-	twrapper, err = txSelectData(organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return twrapper, err
@@ -970,7 +1428,7 @@ func (txRepository *TxRepository) TxSelectWhereGroupBy(organizationId, tableName
 
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
-			result, err := txRepository.TxCountWhereGroupBy(organizationId, tableName, wherePredicate, groupByColumns, havingPredicate, queryArguments)
+			result, err := txRepository.TxCountWhereGroupByContext(ctx, organizationId, tableName, wherePredicate, groupByColumns, havingPredicate, queryArguments)
 			if err != nil {
 				twrapper.SetHasErrors(true)
 				twrapper.AddError(err.Error())
@@ -988,6 +1446,15 @@ func (txRepository *TxRepository) TxSelectWhereGroupByOrderBy(organizationId, ta
 	columnOrderBy string,
 	queryArguments *CypressHashMap,
 	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
+	return txRepository.TxSelectWhereGroupByOrderByContext(txRepository.ctx, organizationId, tableName, columns, wherePredicate, groupByColumns, havingPredicate, columnOrderBy, queryArguments, pagePageSize)
+}
+
+func (txRepository *TxRepository) TxSelectWhereGroupByOrderByContext(ctx context.Context, organizationId, tableName, columns string,
+	wherePredicate *FilterPredicate,
+	groupByColumns string, havingPredicate *FilterPredicate,
+	columnOrderBy string,
+	queryArguments *CypressHashMap,
+	pagePageSize []int) (twrapper *TransactionWrapper, err error) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -1002,7 +1469,7 @@ func (txRepository *TxRepository) TxSelectWhereGroupByOrderBy(organizationId, ta
 	}
 	queryArguments.SetTableName(tableName)
 
-	queryBuilder := NewQueryBuilder().Select()
+	queryBuilder := NewQueryBuilder(GetOrganizationDialect(organizationId)).Select()
 	if columns != "" {
 		queryBuilder.SelectColumn(columns)
 	}
@@ -1041,7 +1508,13 @@ func (txRepository *TxRepository) TxSelectWhereGroupByOrderBy(organizationId, ta
 		}
 	}
 
-	twrapper, err = txSelectData(organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
+	if err := queryBuilder.renderError(); err != nil {
+		twrapper.SetHasErrors(true)
+		twrapper.AddError(err.Error())
+		return twrapper, err
+	}
+
+	twrapper, err = txSelectData(ctx, organizationId, txRepository.tx, queryBuilder.ToString(), queryArguments)
 	if err != nil {
 		ThrowException(cErrors.Cause(err))
 		return twrapper, err
@@ -1050,7 +1523,7 @@ func (txRepository *TxRepository) TxSelectWhereGroupByOrderBy(organizationId, ta
 	if pagePageSize != nil {
 		if pagePageSize[0] > 0 {
 
-			result, err := txRepository.TxCountWhereGroupBy(organizationId, tableName, wherePredicate, groupByColumns, havingPredicate, queryArguments)
+			result, err := txRepository.TxCountWhereGroupByContext(ctx, organizationId, tableName, wherePredicate, groupByColumns, havingPredicate, queryArguments)
 			if err != nil {
 				twrapper.SetHasErrors(true)
 				twrapper.AddError(err.Error())
@@ -1064,40 +1537,14 @@ func (txRepository *TxRepository) TxSelectWhereGroupByOrderBy(organizationId, ta
 }
 
 func (txRepository *TxRepository) TxCountWhereGroupBy(organizationId, tableName string, wherePredicate *FilterPredicate, groupByColumns string, havingPredicate *FilterPredicate, queryArguments *CypressHashMap) (int, error) {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Println(r)
-			debug.PrintStack()
-		}
-	}()
+	return txRepository.TxCountWhereGroupByContext(txRepository.ctx, organizationId, tableName, wherePredicate, groupByColumns, havingPredicate, queryArguments)
+}
 
+func (txRepository *TxRepository) TxCountWhereGroupByContext(ctx context.Context, organizationId, tableName string, wherePredicate *FilterPredicate, groupByColumns string, havingPredicate *FilterPredicate, queryArguments *CypressHashMap) (int, error) {
 	if queryArguments == nil {
 		queryArguments = NewMap()
 	}
 	queryArguments.SetTableName(tableName)
 
-	countQueryBuilder := NewQueryBuilder().Select().SelectColumn("COUNT(*) AS count")
-	countQueryBuilder.FromTable(tableName)
-
-	if wherePredicate != nil && wherePredicate.GetClause() != "" {
-		countQueryBuilder.WherePred(wherePredicate)
-	}
-
-	if groupByColumns != "" {
-		countQueryBuilder.GroupBy(groupByColumns)
-	}
-	if havingPredicate != nil {
-		countQueryBuilder.HavingPred(havingPredicate)
-	}
-
-	twrapper, err := txSelectData(organizationId, txRepository.tx, countQueryBuilder.ToString(), queryArguments)
-
-	if err != nil {
-		ThrowException(cErrors.Cause(err))
-		return 0, err
-	}
-
-	cypressList := twrapper.GetData().(*CypressArrayList)
-	hashMap := cypressList.GetRecord(0)
-	return hashMap.GetValue("count").(int), nil
+	return txRepository.TxCountContext(ctx, organizationId, groupedQueryBuilder(organizationId, tableName, wherePredicate, groupByColumns, havingPredicate), queryArguments)
 }
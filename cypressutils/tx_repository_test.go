@@ -0,0 +1,59 @@
+package cypressutils_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+func TestTxRepositoryCommits(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+	fakeDB.Expect("INSERT INTO").WithColumns("id", "name").AddRow(7, "Jane")
+
+	txRepository, err := cypressutils.NewTxRepository(organizationId)
+	if err != nil {
+		t.Fatalf("NewTxRepository: %v", err)
+	}
+
+	record := cypressutils.NewMap()
+	record.PutValue("name", "Jane")
+	if _, err = txRepository.TxInsert(organizationId, "people", record); err != nil {
+		txRepository.Rollback()
+		t.Fatalf("TxInsert: %v", err)
+	}
+	if err = txRepository.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if statement := fakeDB.GetLastStatement(); statement == nil || !statement.InTx {
+		t.Errorf("insert did not run in the transaction")
+	}
+	if fakeDB.GetBegins() != 1 || fakeDB.GetCommits() != 1 || fakeDB.GetRollbacks() != 0 {
+		t.Errorf("begins = %d, commits = %d, rollbacks = %d, want 1, 1 and 0", fakeDB.GetBegins(), fakeDB.GetCommits(), fakeDB.GetRollbacks())
+	}
+}
+
+func TestTxRepositoryRollsBack(t *testing.T) {
+	organizationId, fakeDB := registerFakeOrganization(t)
+	fakeDB.Expect("INSERT INTO").WithError(errors.New("duplicate key value"))
+
+	txRepository, err := cypressutils.NewTxRepository(organizationId)
+	if err != nil {
+		t.Fatalf("NewTxRepository: %v", err)
+	}
+
+	record := cypressutils.NewMap()
+	record.PutValue("name", "Jane")
+	twrapper, err := txRepository.TxInsert(organizationId, "people", record)
+	if err == nil || !twrapper.HasErrors {
+		t.Fatal("TxInsert succeeded on a failing statement")
+	}
+	if err = txRepository.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+
+	if fakeDB.GetBegins() != 1 || fakeDB.GetRollbacks() != 1 || fakeDB.GetCommits() != 0 {
+		t.Errorf("begins = %d, rollbacks = %d, commits = %d, want 1, 1 and 0", fakeDB.GetBegins(), fakeDB.GetRollbacks(), fakeDB.GetCommits())
+	}
+}
@@ -0,0 +1,137 @@
+package cypressutils
+
+import (
+	cErrors "github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+// UPSERT_EXISTING_ROW and UPSERT_INCOMING_ROW are how an UpdateCondition refers to the stored row and to the row
+// being inserted, e.g. existing.version < excluded.version
+const (
+	UPSERT_EXISTING_ROW = "existing"
+	UPSERT_INCOMING_ROW = "excluded"
+)
+
+type UpsertOptions struct {
+	// DoNothing leaves the conflicting rows as they are
+	DoNothing bool
+
+	// UpdateCondition only updates the conflicting rows it holds for
+	UpdateCondition *FilterPredicate
+}
+
+// UpsertStatement is what a dialect needs to write an insert that updates, or skips, the rows that conflict.
+// Rows holds the named variables of every row, in the order of Columns
+type UpsertStatement struct {
+	TableName       string
+	Columns         []string
+	Rows            [][]string
+	ConflictColumns []string
+	UpdateColumns   []string
+	DoNothing       bool
+	UpdateCondition string
+	Returning       string
+}
+
+type upsertClause struct {
+	conflictColumns []string
+	updateColumns   []string
+	options         *UpsertOptions
+}
+
+var existingRowRegex = regexp.MustCompile(`\b` + UPSERT_EXISTING_ROW + `\.([A-Za-z_][A-Za-z0-9_$#]*)`)
+var incomingRowRegex = regexp.MustCompile(`\b` + UPSERT_INCOMING_ROW + `\.([A-Za-z_][A-Za-z0-9_$#]*)`)
+
+// OnConflict turns the INSERT into an upsert on conflictColumns. updateColumns default to every inserted column
+// that is not a conflict column
+func (builder *QueryBuilder) OnConflict(conflictColumns, updateColumns []string, options ...*UpsertOptions) *QueryBuilder {
+	statement := builder.statement()
+	if statement.statementType != STATEMENT_INSERT {
+		err := cErrors.New("UPSERT: OnConflict must follow an INSERT")
+		ThrowException(err)
+		builder.Err = err
+		return builder
+	}
+
+	for _, column := range append(append([]string{}, conflictColumns...), updateColumns...) {
+		if err := validateIdentifier(column, "UPSERT: "); err != nil {
+			builder.Err = err
+			return builder
+		}
+	}
+
+	upsert := &upsertClause{conflictColumns: conflictColumns, updateColumns: updateColumns, options: &UpsertOptions{}}
+	if options != nil && options[0] != nil {
+		upsert.options = options[0]
+		if upsert.options.UpdateCondition != nil {
+			builder.SetArguments(upsert.options.UpdateCondition.GetArguments())
+		}
+	}
+
+	statement = builder.openClause(CLAUSE_ON_DUPLICATE_KEY)
+	statement.upsert = upsert
+	return builder
+}
+
+func (statement *queryStatement) renderUpsert(dialect Dialect, returning string) (string, error) {
+	if len(statement.insertColumns) == 0 || len(statement.valueRows) == 0 {
+		return "", cErrors.New("UPSERT: " + statement.table + " needs Columns and Values")
+	}
+
+	updateColumns := statement.upsert.updateColumns
+	if len(updateColumns) == 0 {
+		for _, column := range statement.insertColumns {
+			if !containsFold(statement.upsert.conflictColumns, column) {
+				updateColumns = append(updateColumns, column)
+			}
+		}
+	}
+
+	upsert := &UpsertStatement{
+		TableName:       quoteTableReference(dialect, statement.table),
+		Columns:         quoteIdentifiers(dialect, statement.insertColumns),
+		Rows:            statement.valueRows,
+		ConflictColumns: quoteIdentifiers(dialect, statement.upsert.conflictColumns),
+		UpdateColumns:   quoteIdentifiers(dialect, updateColumns),
+		DoNothing:       statement.upsert.options.DoNothing || len(updateColumns) == 0,
+		Returning:       returning,
+	}
+	if condition := statement.upsert.options.UpdateCondition; condition != nil {
+		upsert.UpdateCondition = strings.TrimSpace(condition.GetClause())
+	}
+
+	return dialect.Upsert(upsert)
+}
+
+/**************** UTILITY FUNCTIONS ****************/
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionColumnsLast moves the update columns the condition reads from the existing row to the end, MySQL
+// evaluates the assignments left to right and the condition has to see the stored values
+func conditionColumnsLast(updateColumns []string, condition string) []string {
+	var columns, conditionColumns []string
+	for _, column := range updateColumns {
+		referenced := false
+		for _, match := range existingRowRegex.FindAllStringSubmatch(condition, -1) {
+			if identifierKey(match[1]) == identifierKey(column) {
+				referenced = true
+				break
+			}
+		}
+		if referenced {
+			conditionColumns = append(conditionColumns, column)
+		} else {
+			columns = append(columns, column)
+		}
+	}
+	return append(columns, conditionColumns...)
+}
@@ -0,0 +1,100 @@
+package cypressutils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/codecypress/go-ancillary-utils/cypressutils"
+)
+
+func upsertQuery(t *testing.T, databaseServer cypressutils.DbTypes, updateColumns []string, options ...*cypressutils.UpsertOptions) string {
+	t.Helper()
+
+	organizationId, fakeDB := registerFakeOrganization(t, databaseServer)
+
+	record := cypressutils.NewMap()
+	record.PutValue("id", 7)
+	record.PutValue("name", "Jane")
+	record.PutValue("version", 2)
+
+	twrapper := cypressutils.Upsert(organizationId, "people", record, []string{"id"}, updateColumns, options...)
+	if twrapper.HasErrors {
+		t.Fatalf("Upsert failed: %s", twrapper.GetErrors())
+	}
+
+	statement := fakeDB.GetLastStatement()
+	if len(statement.Args) != 3 || statement.Args[0] != int64(7) || statement.Args[1] != "Jane" || statement.Args[2] != int64(2) {
+		t.Errorf("args = %v", statement.Args)
+	}
+	return statement.Query
+}
+
+func TestUpsertRendersEachDialect(t *testing.T) {
+	tests := []struct {
+		databaseServer cypressutils.DbTypes
+		want           string
+	}{
+		{cypressutils.PostgreSQL, `INSERT INTO "people" AS existing ("id","name","version") VALUES ($1,$2,$3) ON CONFLICT ("id") DO UPDATE ` +
+			`SET "name" = EXCLUDED."name" RETURNING *`},
+		{cypressutils.MySQL, "INSERT INTO `people` (`id`,`name`,`version`) VALUES (?,?,?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)"},
+		{cypressutils.MicrosoftSQL, "MERGE INTO [people] WITH (HOLDLOCK) AS existing USING (VALUES (@p1,@p2,@p3)) AS excluded ([id], [name], [version]) " +
+			"ON existing.[id] = excluded.[id] WHEN MATCHED THEN UPDATE SET existing.[name] = excluded.[name] " +
+			"WHEN NOT MATCHED THEN INSERT ([id],[name],[version]) VALUES (excluded.[id], excluded.[name], excluded.[version]) OUTPUT INSERTED.*;"},
+		{cypressutils.Oracle, `MERGE INTO "PEOPLE" existing USING (SELECT :1 AS "ID", :2 AS "NAME", :3 AS "VERSION" FROM dual) excluded ` +
+			`ON (existing."ID" = excluded."ID") WHEN MATCHED THEN UPDATE SET existing."NAME" = excluded."NAME" ` +
+			`WHEN NOT MATCHED THEN INSERT ("ID","NAME","VERSION") VALUES (excluded."ID", excluded."NAME", excluded."VERSION")`},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.databaseServer), func(t *testing.T) {
+			if query := upsertQuery(t, test.databaseServer, []string{"name"}); query != test.want {
+				t.Errorf("query = %s, want %s", query, test.want)
+			}
+		})
+	}
+}
+
+func TestUpsertDoNothingLeavesConflictingRows(t *testing.T) {
+	tests := []struct {
+		databaseServer cypressutils.DbTypes
+		want           string
+		unwanted       string
+	}{
+		{cypressutils.PostgreSQL, `ON CONFLICT ("id") DO NOTHING`, "DO UPDATE"},
+		{cypressutils.MySQL, "ON DUPLICATE KEY UPDATE `id` = `id`", "VALUES(`name`)"},
+		{cypressutils.MicrosoftSQL, "WHEN NOT MATCHED THEN INSERT", "WHEN MATCHED"},
+		{cypressutils.Oracle, "WHEN NOT MATCHED THEN INSERT", "WHEN MATCHED"},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.databaseServer), func(t *testing.T) {
+			query := upsertQuery(t, test.databaseServer, nil, &cypressutils.UpsertOptions{DoNothing: true})
+			if !strings.Contains(query, test.want) || strings.Contains(query, test.unwanted) {
+				t.Errorf("query = %s, want %q without %q", query, test.want, test.unwanted)
+			}
+		})
+	}
+}
+
+func TestUpsertUpdateConditionGuardsTheUpdate(t *testing.T) {
+	tests := []struct {
+		databaseServer cypressutils.DbTypes
+		want           string
+	}{
+		{cypressutils.PostgreSQL, `"name" = EXCLUDED."name" WHERE existing.version < excluded.version RETURNING *`},
+		{cypressutils.MySQL, "`name` = IF(`people`.version < VALUES(version), VALUES(`name`), `name`), " +
+			"`version` = IF(`people`.version < VALUES(version), VALUES(`version`), `version`)"},
+		{cypressutils.MicrosoftSQL, "WHEN MATCHED AND (existing.version < excluded.version) THEN UPDATE"},
+		{cypressutils.Oracle, `existing."NAME" = excluded."NAME" WHERE existing.version < excluded.version WHEN NOT MATCHED`},
+	}
+
+	condition := &cypressutils.UpsertOptions{UpdateCondition: cypressutils.NewFilterPredicate("existing.version < excluded.version")}
+	for _, test := range tests {
+		t.Run(string(test.databaseServer), func(t *testing.T) {
+			//version IS LISTED FIRST, MySQL HAS TO UPDATE IT LAST FOR THE CONDITION TO READ THE STORED VALUE
+			if query := upsertQuery(t, test.databaseServer, []string{"version", "name"}, condition); !strings.Contains(query, test.want) {
+				t.Errorf("query = %s, want %q", query, test.want)
+			}
+		})
+	}
+}
@@ -537,7 +537,7 @@ func outputXML(buf *bytes.Buffer, n *xmlquery.Node, indentLevel *int, strIndentA
 
 	var tabs strings.Builder
 	for i := 0; i < *indentLevel; i++ {
-		fmt.Fprintf(&tabs, strIndentAmount)
+		fmt.Fprint(&tabs, strIndentAmount)
 	}
 
 	preserveSpaces = calculatePreserveSpaces(n, preserveSpaces)
@@ -596,7 +596,7 @@ func outputXML(buf *bytes.Buffer, n *xmlquery.Node, indentLevel *int, strIndentA
 				allAreText = false
 				break
 			} else {
-				fmt.Fprintf(&textChildren, child.InnerText())
+				fmt.Fprint(&textChildren, child.InnerText())
 			}
 		}
 
@@ -621,7 +621,7 @@ func outputXML(buf *bytes.Buffer, n *xmlquery.Node, indentLevel *int, strIndentA
 
 		var tabs strings.Builder
 		for i := 0; i < *indentLevel; i++ {
-			fmt.Fprintf(&tabs, strIndentAmount)
+			fmt.Fprint(&tabs, strIndentAmount)
 		}
 
 		*indentLevel = *indentLevel - 1